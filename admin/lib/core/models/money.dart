@@ -0,0 +1,80 @@
+import 'dart:math';
+
+import 'package:equatable/equatable.dart';
+import 'package:intl/intl.dart';
+
+/// An amount of money as the API sends it: an integer number of minor units
+/// (e.g. cents) and an ISO 4217 currency code.
+class Money extends Equatable {
+  static const String defaultCurrency = 'USD';
+
+  // Currencies whose minor unit is not a hundredth, matching the API
+  static const Map<String, int> _minorUnitDigits = {
+    'BHD': 3, 'IQD': 3, 'JOD': 3, 'KWD': 3, 'LYD': 3, 'OMR': 3, 'TND': 3,
+    'BIF': 0, 'CLP': 0, 'DJF': 0, 'GNF': 0, 'ISK': 0, 'JPY': 0, 'KMF': 0,
+    'KRW': 0, 'PYG': 0, 'RWF': 0, 'UGX': 0, 'VND': 0, 'VUV': 0, 'XAF': 0,
+    'XOF': 0, 'XPF': 0,
+  };
+
+  final int amount;
+  final String currency;
+
+  const Money({
+    required this.amount,
+    this.currency = defaultCurrency,
+  });
+
+  /// Parses {"amount": <minor units>, "currency": "<code>"}. Older API
+  /// versions sent a plain number in major units, which is still accepted.
+  factory Money.fromJson(dynamic json) {
+    if (json is Map<String, dynamic>) {
+      final currency = json['currency'] as String?;
+      return Money(
+        amount: (json['amount'] as num).toInt(),
+        currency: currency == null || currency.isEmpty
+            ? defaultCurrency
+            : currency.toUpperCase(),
+      );
+    }
+    if (json is num) {
+      return Money.fromDecimal(json.toDouble());
+    }
+    return const Money(amount: 0);
+  }
+
+  /// Creates a Money from an amount in major units, e.g. 12.5 dollars
+  factory Money.fromDecimal(double value, {String currency = defaultCurrency}) {
+    final scale = pow(10, digitsFor(currency));
+    return Money(amount: (value * scale).round(), currency: currency);
+  }
+
+  /// The number of decimal places of a currency's minor unit
+  static int digitsFor(String currency) => _minorUnitDigits[currency] ?? 2;
+
+  /// The amount in major units
+  double get value => amount / pow(10, digitsFor(currency));
+
+  /// The amount in major units with the currency's decimal places, e.g. "12.50"
+  String toDecimalString() => value.toStringAsFixed(digitsFor(currency));
+
+  /// Formats the amount with its currency symbol, e.g. "$12.50"
+  String format() {
+    return NumberFormat.simpleCurrency(
+      name: currency,
+      decimalDigits: digitsFor(currency),
+    ).format(value);
+  }
+
+  Map<String, dynamic> toJson() {
+    return {
+      'amount': amount,
+      'currency': currency,
+    };
+  }
+
+  @override
+  String toString() => '${toDecimalString()} $currency';
+
+  @override
+  List<Object?> get props => [amount, currency];
+}
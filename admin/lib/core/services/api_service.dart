@@ -10,14 +10,17 @@ class ApiService {
   final Dio dio;
   final SharedPreferences _prefs;
 
-  ApiService(this._prefs) : dio = Dio(
-    BaseOptions(
-      baseUrl: baseUrl,
-      connectTimeout: const Duration(seconds: 5),
-      receiveTimeout: const Duration(seconds: 3),
-      headers: {'Content-Type': 'application/json'},
-    ),
-  ) {
+  // Sends refresh requests without the interceptors below, so a failed
+  // refresh cannot trigger another one
+  final Dio _refreshDio;
+
+  // The refresh in progress, shared by requests that fail at the same time
+  // because a refresh token can only be used once
+  Future<bool>? _refreshing;
+
+  ApiService(this._prefs)
+      : dio = Dio(_options()),
+        _refreshDio = Dio(_options()) {
     dio.interceptors.add(
       InterceptorsWrapper(
         onRequest: (options, handler) async {
@@ -28,9 +31,26 @@ class ApiService {
           return handler.next(options);
         },
         onError: (DioException e, handler) async {
-          if (e.response?.statusCode == 401) {
-            await _prefs.remove('token');
-            await _prefs.remove('user');
+          if (e.response?.statusCode != 401) {
+            return handler.next(e);
+          }
+
+          // Access tokens are short-lived, so an expired one is renewed with
+          // the refresh token and the request is retried once
+          final options = e.requestOptions;
+          if (_usesSession(options) &&
+              options.extra['retried'] != true &&
+              await _refreshSession()) {
+            options.extra['retried'] = true;
+            try {
+              return handler.resolve(await dio.fetch(options));
+            } on DioException catch (retryError) {
+              return handler.next(retryError);
+            }
+          }
+
+          if (_usesSession(options)) {
+            await clearSession();
             // The auth bloc will handle navigation through state changes
           }
           return handler.next(e);
@@ -39,6 +59,54 @@ class ApiService {
     );
   }
 
+  static BaseOptions _options() {
+    return BaseOptions(
+      baseUrl: baseUrl,
+      connectTimeout: const Duration(seconds: 5),
+      receiveTimeout: const Duration(seconds: 3),
+      headers: {'Content-Type': 'application/json'},
+    );
+  }
+
+  /// Removes the stored tokens and user
+  Future<void> clearSession() async {
+    await _prefs.remove('token');
+    await _prefs.remove('refresh_token');
+    await _prefs.remove('user');
+  }
+
+  // Login requests answer a wrong password with 401 too, which must not be
+  // mistaken for an expired session
+  bool _usesSession(RequestOptions options) {
+    return !options.path.startsWith('/auth/') || options.path == '/auth/logout';
+  }
+
+  Future<bool> _refreshSession() {
+    return _refreshing ??= _refresh().whenComplete(() => _refreshing = null);
+  }
+
+  // Trades the refresh token for a new access token and refresh token,
+  // reporting whether it succeeded
+  Future<bool> _refresh() async {
+    final refreshToken = _prefs.getString('refresh_token');
+    if (refreshToken == null) {
+      return false;
+    }
+
+    try {
+      final response = await _refreshDio.post(
+        '/auth/refresh',
+        data: {'refresh_token': refreshToken},
+      );
+      final data = response.data['data'] as Map<String, dynamic>;
+      await _prefs.setString('token', data['token'] as String);
+      await _prefs.setString('refresh_token', data['refresh_token'] as String);
+      return true;
+    } catch (e) {
+      return false;
+    }
+  }
+
   Future<ApiResponse<T>> get<T>(
     String path, {
     Map<String, dynamic>? queryParameters,
@@ -12,6 +12,7 @@ class AuthBloc extends Bloc<AuthEvent, AuthState> {
   final ApiService _apiService;
   static const String _userKey = 'user';
   static const String _tokenKey = 'token';
+  static const String _refreshTokenKey = 'refresh_token';
 
   AuthBloc({
     required SharedPreferences prefs,
@@ -21,6 +22,7 @@ class AuthBloc extends Bloc<AuthEvent, AuthState> {
         super(AuthInitial()) {
     on<CheckAuthStatus>(_onCheckAuthStatus);
     on<LoginRequested>(_onLoginRequested);
+    on<TwoFactorCodeSubmitted>(_onTwoFactorCodeSubmitted);
     on<RegisterRequested>(_onRegisterRequested);
     on<LogoutRequested>(_onLogoutRequested);
   }
@@ -59,23 +61,65 @@ class AuthBloc extends Bloc<AuthEvent, AuthState> {
       );
 
       if (response.success && response.data != null) {
-        final token = response.data!['token'] as String;
-        final userData = response.data!['user'] as Map<String, dynamic>;
-        final user = User.fromJson(userData);
-        
-        await _prefs.setString(_tokenKey, token);
-        await _prefs.setString(_userKey, json.encode(user.toJson()));
-        emit(Authenticated(user));
+        // Users with two-factor authentication get a challenge instead of tokens
+        if (response.data!['two_factor_required'] == true) {
+          emit(TwoFactorRequired(response.data!['challenge_token'] as String));
+          return;
+        }
+        emit(await _startSession(response.data!));
+      } else {
+        emit(AuthError(response.message ?? 'Login failed'));
+      }
+    } on ApiException catch (e) {
+      emit(AuthError(e.message));
+    } catch (e) {
+      emit(const AuthError('An unexpected error occurred'));
+    }
+  }
+
+  Future<void> _onTwoFactorCodeSubmitted(
+    TwoFactorCodeSubmitted event,
+    Emitter<AuthState> emit,
+  ) async {
+    emit(AuthLoading());
+    try {
+      final response = await _apiService.post<Map<String, dynamic>>(
+        '/auth/login/two-factor',
+        data: {
+          'challenge_token': event.challengeToken,
+          if (event.isRecoveryCode)
+            'recovery_code': event.code
+          else
+            'code': event.code,
+        },
+        fromJson: (json) => json,
+      );
+
+      if (response.success && response.data != null) {
+        emit(await _startSession(response.data!));
       } else {
         emit(AuthError(response.message ?? 'Login failed'));
       }
     } on ApiException catch (e) {
+      // A challenge can only be tried once, so the user logs in again
       emit(AuthError(e.message));
     } catch (e) {
       emit(const AuthError('An unexpected error occurred'));
     }
   }
 
+  /// Stores the tokens and user of a completed login
+  Future<AuthState> _startSession(Map<String, dynamic> data) async {
+    final token = data['token'] as String;
+    final userData = data['user'] as Map<String, dynamic>;
+    final user = User.fromJson(userData);
+
+    await _prefs.setString(_tokenKey, token);
+    await _prefs.setString(_refreshTokenKey, data['refresh_token'] as String);
+    await _prefs.setString(_userKey, json.encode(user.toJson()));
+    return Authenticated(user);
+  }
+
   Future<void> _onRegisterRequested(
     RegisterRequested event,
     Emitter<AuthState> emit,
@@ -117,6 +161,18 @@ class AuthBloc extends Bloc<AuthEvent, AuthState> {
   ) async {
     emit(AuthLoading());
     try {
+      // Revoke the session on the server so its refresh token stops working.
+      // The user is signed out locally even if that fails.
+      if (_prefs.getString(_tokenKey) != null) {
+        try {
+          await _apiService.post<void>(
+            '/auth/logout',
+            fromJson: (json) {},
+          );
+        } on ApiException {
+          // Ignored, see above
+        }
+      }
       await _clearSession();
       emit(Unauthenticated());
     } catch (e) {
@@ -127,5 +183,6 @@ class AuthBloc extends Bloc<AuthEvent, AuthState> {
   Future<void> _clearSession() async {
     await _prefs.remove(_userKey);
     await _prefs.remove(_tokenKey);
+    await _prefs.remove(_refreshTokenKey);
   }
 } 
\ No newline at end of file
@@ -22,6 +22,21 @@ class LoginRequested extends AuthEvent {
   List<Object?> get props => [email, password];
 }
 
+class TwoFactorCodeSubmitted extends AuthEvent {
+  final String challengeToken;
+  final String code;
+  final bool isRecoveryCode;
+
+  const TwoFactorCodeSubmitted({
+    required this.challengeToken,
+    required this.code,
+    this.isRecoveryCode = false,
+  });
+
+  @override
+  List<Object?> get props => [challengeToken, code, isRecoveryCode];
+}
+
 class RegisterRequested extends AuthEvent {
   final String name;
   final String email;
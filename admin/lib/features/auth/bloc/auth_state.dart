@@ -21,6 +21,17 @@ class Authenticated extends AuthState {
   List<Object?> get props => [user];
 }
 
+/// The password was accepted and the user has to enter a code from their
+/// authenticator app, or a recovery code, to finish logging in
+class TwoFactorRequired extends AuthState {
+  final String challengeToken;
+
+  const TwoFactorRequired(this.challengeToken);
+
+  @override
+  List<Object?> get props => [challengeToken];
+}
+
 class Unauthenticated extends AuthState {}
 
 class AuthError extends AuthState {
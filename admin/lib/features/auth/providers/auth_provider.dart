@@ -7,6 +7,7 @@ class AuthProvider extends ChangeNotifier {
   final ApiService _apiService;
   final SharedPreferences _prefs;
   User? _currentUser;
+  String? _challengeToken;
   bool _isLoading = false;
   String? _error;
 
@@ -15,6 +16,7 @@ class AuthProvider extends ChangeNotifier {
   User? get currentUser => _currentUser;
   bool get isLoading => _isLoading;
   bool get isAuthenticated => _currentUser != null;
+  bool get isTwoFactorRequired => _challengeToken != null;
   String? get error => _error;
 
   Future<void> login(String email, String password) async {
@@ -33,12 +35,54 @@ class AuthProvider extends ChangeNotifier {
       );
 
       if (response.success && response.data != null) {
-        final token = response.data!['token'] as String;
-        final userData = response.data!['user'] as Map<String, dynamic>;
-        final user = User.fromJson(userData);
-        
-        await _prefs.setString('token', token);
-        _currentUser = user;
+        // Users with two-factor authentication get a challenge instead of
+        // tokens and finish with loginTwoFactor
+        if (response.data!['two_factor_required'] == true) {
+          _challengeToken = response.data!['challenge_token'] as String;
+        } else {
+          await _startSession(response.data!);
+        }
+        _error = null;
+      } else {
+        _error = response.message ?? 'Login failed';
+      }
+
+      _isLoading = false;
+      notifyListeners();
+    } catch (e) {
+      _isLoading = false;
+      _error = e.toString();
+      notifyListeners();
+      rethrow;
+    }
+  }
+
+  /// Finishes a login that requires two-factor authentication with a code
+  /// from the authenticator app or a recovery code. A challenge can only be
+  /// tried once; after a wrong code the user logs in again.
+  Future<void> loginTwoFactor(String code, {bool isRecoveryCode = false}) async {
+    final challengeToken = _challengeToken;
+    if (challengeToken == null) {
+      throw StateError('No two-factor login in progress');
+    }
+
+    try {
+      _isLoading = true;
+      _error = null;
+      _challengeToken = null;
+      notifyListeners();
+
+      final response = await _apiService.post<Map<String, dynamic>>(
+        '/auth/login/two-factor',
+        data: {
+          'challenge_token': challengeToken,
+          if (isRecoveryCode) 'recovery_code': code else 'code': code,
+        },
+        fromJson: (json) => json,
+      );
+
+      if (response.success && response.data != null) {
+        await _startSession(response.data!);
         _error = null;
       } else {
         _error = response.message ?? 'Login failed';
@@ -54,6 +98,16 @@ class AuthProvider extends ChangeNotifier {
     }
   }
 
+  /// Stores the token and user of a completed login
+  Future<void> _startSession(Map<String, dynamic> data) async {
+    final token = data['token'] as String;
+    final userData = data['user'] as Map<String, dynamic>;
+
+    await _prefs.setString('token', token);
+    await _prefs.setString('refresh_token', data['refresh_token'] as String);
+    _currentUser = User.fromJson(userData);
+  }
+
   Future<void> register(String name, String email, String password) async {
     try {
       _isLoading = true;
@@ -94,8 +148,18 @@ class AuthProvider extends ChangeNotifier {
   }
 
   Future<void> logout() async {
-    await _prefs.remove('token');
+    // Revoke the session on the server so its refresh token stops working.
+    // The user is signed out locally even if that fails.
+    if (_prefs.getString('token') != null) {
+      try {
+        await _apiService.post<void>('/auth/logout', fromJson: (json) {});
+      } catch (e) {
+        // Ignored, see above
+      }
+    }
+    await _apiService.clearSession();
     _currentUser = null;
+    _challengeToken = null;
     _error = null;
     notifyListeners();
   }
@@ -103,6 +103,23 @@ class _LoginScreenState extends State<LoginScreen> {
                       return null;
                     },
                   ),
+                  // Errors of a step that replaced this screen, such as a
+                  // wrong two-factor code, are shown here
+                  BlocBuilder<AuthBloc, AuthState>(
+                    builder: (context, state) {
+                      if (state is! AuthError) {
+                        return const SizedBox.shrink();
+                      }
+                      return Padding(
+                        padding: const EdgeInsets.only(top: 16),
+                        child: Text(
+                          state.message,
+                          style: const TextStyle(color: Colors.red),
+                          textAlign: TextAlign.center,
+                        ),
+                      );
+                    },
+                  ),
                   const SizedBox(height: 24),
                   BlocBuilder<AuthBloc, AuthState>(
                     builder: (context, state) {
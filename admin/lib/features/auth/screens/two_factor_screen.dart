@@ -0,0 +1,126 @@
+import 'package:flutter/material.dart';
+import 'package:flutter_bloc/flutter_bloc.dart';
+import '../bloc/auth_bloc.dart';
+import '../bloc/auth_event.dart';
+import '../bloc/auth_state.dart';
+
+/// Second login step for users with two-factor authentication
+class TwoFactorScreen extends StatefulWidget {
+  final String challengeToken;
+
+  const TwoFactorScreen({super.key, required this.challengeToken});
+
+  @override
+  State<TwoFactorScreen> createState() => _TwoFactorScreenState();
+}
+
+class _TwoFactorScreenState extends State<TwoFactorScreen> {
+  final _formKey = GlobalKey<FormState>();
+  final _codeController = TextEditingController();
+  bool _useRecoveryCode = false;
+
+  @override
+  void dispose() {
+    _codeController.dispose();
+    super.dispose();
+  }
+
+  void _handleSubmit() {
+    if (_formKey.currentState?.validate() ?? false) {
+      context.read<AuthBloc>().add(
+            TwoFactorCodeSubmitted(
+              challengeToken: widget.challengeToken,
+              code: _codeController.text.trim(),
+              isRecoveryCode: _useRecoveryCode,
+            ),
+          );
+    }
+  }
+
+  @override
+  Widget build(BuildContext context) {
+    return Scaffold(
+      body: Center(
+        child: SingleChildScrollView(
+          padding: const EdgeInsets.all(24),
+          child: Form(
+            key: _formKey,
+            child: Column(
+              mainAxisAlignment: MainAxisAlignment.center,
+              crossAxisAlignment: CrossAxisAlignment.stretch,
+              children: [
+                const Text(
+                  'Two-Factor Authentication',
+                  style: TextStyle(
+                    fontSize: 32,
+                    fontWeight: FontWeight.bold,
+                  ),
+                  textAlign: TextAlign.center,
+                ),
+                const SizedBox(height: 16),
+                Text(
+                  _useRecoveryCode
+                      ? 'Enter one of your recovery codes.'
+                      : 'Enter the code from your authenticator app.',
+                  textAlign: TextAlign.center,
+                ),
+                const SizedBox(height: 32),
+                TextFormField(
+                  controller: _codeController,
+                  decoration: InputDecoration(
+                    labelText: _useRecoveryCode ? 'Recovery code' : 'Code',
+                    prefixIcon: const Icon(Icons.security),
+                    border: const OutlineInputBorder(),
+                  ),
+                  keyboardType: _useRecoveryCode
+                      ? TextInputType.text
+                      : TextInputType.number,
+                  autofocus: true,
+                  validator: (value) {
+                    if (value == null || value.trim().isEmpty) {
+                      return 'Please enter the code';
+                    }
+                    return null;
+                  },
+                  onFieldSubmitted: (_) => _handleSubmit(),
+                ),
+                const SizedBox(height: 8),
+                Align(
+                  alignment: Alignment.centerRight,
+                  child: TextButton(
+                    onPressed: () {
+                      setState(() {
+                        _useRecoveryCode = !_useRecoveryCode;
+                        _codeController.clear();
+                      });
+                    },
+                    child: Text(
+                      _useRecoveryCode
+                          ? 'Use authenticator app'
+                          : 'Use a recovery code',
+                    ),
+                  ),
+                ),
+                const SizedBox(height: 16),
+                BlocBuilder<AuthBloc, AuthState>(
+                  builder: (context, state) {
+                    return FilledButton(
+                      onPressed: state is AuthLoading ? null : _handleSubmit,
+                      child: const Text('Verify'),
+                    );
+                  },
+                ),
+                const SizedBox(height: 8),
+                TextButton(
+                  onPressed: () =>
+                      context.read<AuthBloc>().add(CheckAuthStatus()),
+                  child: const Text('Back to login'),
+                ),
+              ],
+            ),
+          ),
+        ),
+      ),
+    );
+  }
+}
@@ -1,9 +1,21 @@
 import 'package:equatable/equatable.dart';
 
+import '../../../core/models/money.dart';
+
+/// The lifecycle of a booking, matching the statuses the API sends
 enum BookingStatus {
-  booked,
-  cancelled,
-  completed
+  pending('PENDING'),
+  confirmed('CONFIRMED'),
+  pickedUp('PICKED_UP'),
+  returned('RETURNED'),
+  completed('COMPLETED'),
+  cancelled('CANCELLED'),
+  noShow('NO_SHOW');
+
+  const BookingStatus(this.value);
+
+  /// The status as the API sends it, e.g. "PICKED_UP"
+  final String value;
 }
 
 class User extends Equatable {
@@ -97,7 +109,7 @@ class Booking extends Equatable {
   final String startTime;
   final String endTime;
   final BookingStatus status;
-  final double totalPrice;
+  final Money totalPrice;
 
   const Booking({
     required this.id,
@@ -121,7 +133,7 @@ class Booking extends Equatable {
       startTime: json['start_time'] as String,
       endTime: json['end_time'] as String,
       status: _parseBookingStatus(json['status'] as String),
-      totalPrice: (json['total_price'] as num).toDouble(),
+      totalPrice: Money.fromJson(json['total_price']),
     );
   }
 
@@ -150,17 +162,23 @@ class Booking extends Equatable {
       },
       'start_time': startTime,
       'end_time': endTime,
-      'status': status.toString().split('.').last.toUpperCase(),
-      'total_price': totalPrice,
+      'status': status.value,
+      'total_price': totalPrice.toJson(),
     };
   }
 
   static BookingStatus _parseBookingStatus(String value) {
     switch (value.toUpperCase()) {
-      case 'BOOKED': return BookingStatus.booked;
-      case 'CANCELLED': return BookingStatus.cancelled;
+      case 'PENDING': return BookingStatus.pending;
+      case 'CONFIRMED': return BookingStatus.confirmed;
+      // Older API versions called confirmed bookings booked
+      case 'BOOKED': return BookingStatus.confirmed;
+      case 'PICKED_UP': return BookingStatus.pickedUp;
+      case 'RETURNED': return BookingStatus.returned;
       case 'COMPLETED': return BookingStatus.completed;
-      default: return BookingStatus.booked;
+      case 'CANCELLED': return BookingStatus.cancelled;
+      case 'NO_SHOW': return BookingStatus.noShow;
+      default: throw FormatException('Unknown booking status: $value');
     }
   }
 
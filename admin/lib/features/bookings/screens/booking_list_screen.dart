@@ -121,11 +121,11 @@ class _BookingListScreenState extends State<BookingListScreen> {
                 Text('Car ID: ${booking.carId.substring(0, 8)}'),
                 Text('Start: ${_formatDateTime(booking.startTime)}'),
                 Text('End: ${_formatDateTime(booking.endTime)}'),
-                Text('Status: ${booking.status.toString().split('.').last.toUpperCase()}'),
+                Text('Status: ${booking.status.value}'),
               ],
             ),
             trailing: Text(
-              '₹${booking.totalPrice.toStringAsFixed(2)}',
+              booking.totalPrice.format(),
               style: const TextStyle(
                 fontWeight: FontWeight.bold,
                 fontSize: 16,
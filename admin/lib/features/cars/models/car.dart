@@ -1,3 +1,5 @@
+import '../../../core/models/money.dart';
+
 class Car {
   final String id;
   final String make;
@@ -13,13 +15,13 @@ class Car {
   final String ownerId;
   final String ownerName;
   final String ownerContact;
-  final double rentalPricePerDay;
-  final double rentalPricePerHour;
+  final Money rentalPricePerDay;
+  final Money rentalPricePerHour;
   final int minimumRentDuration;
-  final double securityDeposit;
-  final double lateFeePerHour;
-  final double rentalExtendFeePerDay;
-  final double rentalExtendFeePerHour;
+  final Money securityDeposit;
+  final Money lateFeePerHour;
+  final Money rentalExtendFeePerDay;
+  final Money rentalExtendFeePerHour;
   final List<String> images;
   final bool isAvailable;
   final int currentOdometerReading;
@@ -73,13 +75,13 @@ class Car {
       ownerId: json['owner_id'],
       ownerName: json['owner_name'],
       ownerContact: json['owner_contact'],
-      rentalPricePerDay: json['rental_price_per_day'].toDouble(),
-      rentalPricePerHour: json['rental_price_per_hour'].toDouble(),
+      rentalPricePerDay: Money.fromJson(json['rental_price_per_day']),
+      rentalPricePerHour: Money.fromJson(json['rental_price_per_hour']),
       minimumRentDuration: json['minimum_rent_duration'],
-      securityDeposit: json['security_deposit'].toDouble(),
-      lateFeePerHour: json['late_fee_per_hour'].toDouble(),
-      rentalExtendFeePerDay: json['rental_extend_fee_per_day'].toDouble(),
-      rentalExtendFeePerHour: json['rental_extend_fee_per_hour'].toDouble(),
+      securityDeposit: Money.fromJson(json['security_deposit']),
+      lateFeePerHour: Money.fromJson(json['late_fee_per_hour']),
+      rentalExtendFeePerDay: Money.fromJson(json['rental_extend_fee_per_day']),
+      rentalExtendFeePerHour: Money.fromJson(json['rental_extend_fee_per_hour']),
       images: List<String>.from(json['images']),
       isAvailable: json['is_available'],
       currentOdometerReading: json['current_odometer_reading'],
@@ -107,13 +109,13 @@ class Car {
       'owner_id': ownerId,
       'owner_name': ownerName,
       'owner_contact': ownerContact,
-      'rental_price_per_day': rentalPricePerDay,
-      'rental_price_per_hour': rentalPricePerHour,
+      'rental_price_per_day': rentalPricePerDay.toJson(),
+      'rental_price_per_hour': rentalPricePerHour.toJson(),
       'minimum_rent_duration': minimumRentDuration,
-      'security_deposit': securityDeposit,
-      'late_fee_per_hour': lateFeePerHour,
-      'rental_extend_fee_per_day': rentalExtendFeePerDay,
-      'rental_extend_fee_per_hour': rentalExtendFeePerHour,
+      'security_deposit': securityDeposit.toJson(),
+      'late_fee_per_hour': lateFeePerHour.toJson(),
+      'rental_extend_fee_per_day': rentalExtendFeePerDay.toJson(),
+      'rental_extend_fee_per_hour': rentalExtendFeePerHour.toJson(),
       'images': images,
       'is_available': isAvailable,
       'current_odometer_reading': currentOdometerReading,
@@ -138,7 +138,7 @@ class _CarDetailsScreenState extends State<CarDetailsScreen> {
                           const Divider(),
                           _buildInfoRow(
                             'Hourly Rate',
-                            '\$${car..rentalPricePerHour.toStringAsFixed(2)}/hr',
+                            '${car.rentalPricePerHour.format()}/hr',
                           ),
                           const Divider(),
                           _buildInfoRow(
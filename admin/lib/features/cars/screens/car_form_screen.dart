@@ -2,6 +2,7 @@ import 'package:car_rental_admin/features/cars/models/car.dart';
 import 'package:flutter/material.dart';
 import 'package:provider/provider.dart';
 
+import '../../../core/models/money.dart';
 import '../../../core/services/api_service.dart';
 
 class CarFormScreen extends StatefulWidget {
@@ -22,6 +23,7 @@ class _CarFormScreenState extends State<CarFormScreen> {
   bool _isLoading = false;
   bool _isEditMode = false;
   String? _carId;
+  String _currency = Money.defaultCurrency;
 
   @override
   void initState() {
@@ -35,7 +37,8 @@ class _CarFormScreenState extends State<CarFormScreen> {
         _modelController.text = car.model;
         _licensePlateController.text = car.vehicleNumber;
         _locationController.text = car.ownerContact;
-        _hourlyRateController.text = car.rentalPricePerHour.toString();
+        _hourlyRateController.text = car.rentalPricePerHour.toDecimalString();
+        _currency = car.rentalPricePerHour.currency;
         setState(() {
           _isAvailable = car.isAvailable;
         });
@@ -63,7 +66,10 @@ class _CarFormScreenState extends State<CarFormScreen> {
       'model': _modelController.text,
       'license_plate': _licensePlateController.text,
       'location': _locationController.text,
-      'hourly_rate': double.parse(_hourlyRateController.text),
+      'hourly_rate': Money.fromDecimal(
+        double.parse(_hourlyRateController.text),
+        currency: _currency,
+      ).toJson(),
       'is_available': _isAvailable,
     };
 
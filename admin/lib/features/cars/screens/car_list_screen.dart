@@ -336,7 +336,7 @@ class CarCard extends StatelessWidget {
                       ),
                       const SizedBox(height: 4),
                       Text(
-                        '\$${car.rentalPricePerDay}/day',
+                        '${car.rentalPricePerDay.format()}/day',
                         style: const TextStyle(
                           fontSize: 16,
                           fontWeight: FontWeight.bold,
@@ -6,6 +6,7 @@ import 'features/auth/bloc/auth_bloc.dart';
 import 'features/auth/bloc/auth_state.dart';
 import 'features/auth/bloc/auth_event.dart';
 import 'features/auth/screens/login_screen.dart';
+import 'features/auth/screens/two_factor_screen.dart';
 import 'features/cars/bloc/car_bloc.dart';
 import 'features/bookings/bloc/booking_bloc.dart';
 import 'features/owners/bloc/owner_bloc.dart';
@@ -80,6 +81,10 @@ class _AuthenticationWrapperState extends State<AuthenticationWrapper> {
         if (state is Authenticated) {
           return const DashboardScreen();
         }
+
+        if (state is TwoFactorRequired) {
+          return TwoFactorScreen(challengeToken: state.challengeToken);
+        }
         
         return const LoginScreen();
       },
@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"car-rental-backend/config"
+	"car-rental-backend/money"
 
 	_ "github.com/lib/pq"
 )
@@ -34,6 +35,14 @@ func main() {
 		log.Fatalf("Error loading config: %v", err)
 	}
 
+	// Migrations that convert amounts need the minor unit of the configured
+	// currency, e.g. 100 for USD, 1 for JPY and 1000 for KWD
+	currency := strings.ToUpper(strings.TrimSpace(cfg.Currency))
+	if !money.ValidCurrency(currency) {
+		log.Fatalf("Invalid CURRENCY %q", cfg.Currency)
+	}
+	minorUnitScale := "1" + strings.Repeat("0", money.MinorUnitDigits(currency))
+
 	// Create database connection string
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		cfg.DBHost,
@@ -117,17 +126,18 @@ func main() {
 
 	// Run migrations
 	if *up {
-		runMigrationsUp(db, migrationsDir, migrationFiles, appliedMigrations)
+		runMigrationsUp(db, migrationsDir, migrationFiles, appliedMigrations, minorUnitScale)
 	} else if *down {
-		runMigrationsDown(db, migrationsDir, migrationFiles, appliedMigrations)
+		runMigrationsDown(db, migrationsDir, migrationFiles, appliedMigrations, minorUnitScale)
 	}
 }
 
-func runMigrationsUp(db *sql.DB, migrationsDir string, migrationFiles []string, appliedMigrations map[string]bool) {
+func runMigrationsUp(db *sql.DB, migrationsDir string, migrationFiles []string, appliedMigrations map[string]bool, minorUnitScale string) {
 	successCount := 0
 	skippedCount := 0
 	failedCount := 0
 
+	// Later migrations build on earlier ones, so the run stops at the first failure
 	for _, file := range migrationFiles {
 		if !strings.HasSuffix(file, ".up.sql") {
 			continue
@@ -146,7 +156,7 @@ func runMigrationsUp(db *sql.DB, migrationsDir string, migrationFiles []string,
 		if err != nil {
 			log.Printf("Error reading migration file %s: %v", file, err)
 			failedCount++
-			continue
+			break
 		}
 		sql := string(sqlBytes)
 
@@ -155,7 +165,16 @@ func runMigrationsUp(db *sql.DB, migrationsDir string, migrationFiles []string,
 		if err != nil {
 			log.Printf("Error starting transaction: %v", err)
 			failedCount++
-			continue
+			break
+		}
+
+		// Make the currency's minor unit available to the migration
+		_, err = tx.Exec("SELECT set_config('app.minor_unit_scale', $1, true)", minorUnitScale)
+		if err != nil {
+			tx.Rollback()
+			log.Printf("Error configuring migration %s: %v", file, err)
+			failedCount++
+			break
 		}
 
 		// Execute the migration
@@ -164,7 +183,7 @@ func runMigrationsUp(db *sql.DB, migrationsDir string, migrationFiles []string,
 			tx.Rollback()
 			log.Printf("Error executing migration %s: %v", file, err)
 			failedCount++
-			continue
+			break
 		}
 
 		// Record the migration
@@ -173,7 +192,7 @@ func runMigrationsUp(db *sql.DB, migrationsDir string, migrationFiles []string,
 			tx.Rollback()
 			log.Printf("Error recording migration %s: %v", migrationName, err)
 			failedCount++
-			continue
+			break
 		}
 
 		// Commit the transaction
@@ -181,7 +200,7 @@ func runMigrationsUp(db *sql.DB, migrationsDir string, migrationFiles []string,
 		if err != nil {
 			log.Printf("Error committing transaction: %v", err)
 			failedCount++
-			continue
+			break
 		}
 
 		fmt.Printf("Migration %s applied successfully\n", migrationName)
@@ -190,9 +209,12 @@ func runMigrationsUp(db *sql.DB, migrationsDir string, migrationFiles []string,
 
 	fmt.Printf("\nMigration summary: %d successful, %d skipped, %d failed\n",
 		successCount, skippedCount, failedCount)
+	if failedCount > 0 {
+		os.Exit(1)
+	}
 }
 
-func runMigrationsDown(db *sql.DB, migrationsDir string, migrationFiles []string, appliedMigrations map[string]bool) {
+func runMigrationsDown(db *sql.DB, migrationsDir string, migrationFiles []string, appliedMigrations map[string]bool, minorUnitScale string) {
 	// Reverse the order of migrations for down migration
 	for i, j := 0, len(migrationFiles)-1; i < j; i, j = i+1, j-1 {
 		migrationFiles[i], migrationFiles[j] = migrationFiles[j], migrationFiles[i]
@@ -202,6 +224,8 @@ func runMigrationsDown(db *sql.DB, migrationsDir string, migrationFiles []string
 	skippedCount := 0
 	failedCount := 0
 
+	// Earlier migrations are only reverted once later ones are, so the run
+	// stops at the first failure
 	for _, file := range migrationFiles {
 		if !strings.HasSuffix(file, ".down.sql") {
 			continue
@@ -220,7 +244,7 @@ func runMigrationsDown(db *sql.DB, migrationsDir string, migrationFiles []string
 		if err != nil {
 			log.Printf("Error reading migration file %s: %v", file, err)
 			failedCount++
-			continue
+			break
 		}
 		sql := string(sqlBytes)
 
@@ -229,7 +253,16 @@ func runMigrationsDown(db *sql.DB, migrationsDir string, migrationFiles []string
 		if err != nil {
 			log.Printf("Error starting transaction: %v", err)
 			failedCount++
-			continue
+			break
+		}
+
+		// Make the currency's minor unit available to the migration
+		_, err = tx.Exec("SELECT set_config('app.minor_unit_scale', $1, true)", minorUnitScale)
+		if err != nil {
+			tx.Rollback()
+			log.Printf("Error configuring migration %s: %v", file, err)
+			failedCount++
+			break
 		}
 
 		// Execute the migration
@@ -238,7 +271,7 @@ func runMigrationsDown(db *sql.DB, migrationsDir string, migrationFiles []string
 			tx.Rollback()
 			log.Printf("Error executing migration %s: %v", file, err)
 			failedCount++
-			continue
+			break
 		}
 
 		// Remove the migration record
@@ -247,7 +280,7 @@ func runMigrationsDown(db *sql.DB, migrationsDir string, migrationFiles []string
 			tx.Rollback()
 			log.Printf("Error removing migration record %s: %v", migrationName, err)
 			failedCount++
-			continue
+			break
 		}
 
 		// Commit the transaction
@@ -255,7 +288,7 @@ func runMigrationsDown(db *sql.DB, migrationsDir string, migrationFiles []string
 		if err != nil {
 			log.Printf("Error committing transaction: %v", err)
 			failedCount++
-			continue
+			break
 		}
 
 		fmt.Printf("Migration %s reverted successfully\n", migrationName)
@@ -264,4 +297,7 @@ func runMigrationsDown(db *sql.DB, migrationsDir string, migrationFiles []string
 
 	fmt.Printf("\nMigration summary: %d successful, %d skipped, %d failed\n",
 		successCount, skippedCount, failedCount)
+	if failedCount > 0 {
+		os.Exit(1)
+	}
 }
@@ -3,12 +3,24 @@ package main
 import (
 	"car-rental-backend/config"
 	"car-rental-backend/database"
+	"car-rental-backend/lockout"
+	"car-rental-backend/mailer"
+	"car-rental-backend/models"
+	"car-rental-backend/money"
+	"car-rental-backend/payments"
+	"car-rental-backend/pricing"
 	"car-rental-backend/routes"
+	"car-rental-backend/services"
+	"car-rental-backend/tokens"
+	"car-rental-backend/utils"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
 	"strings"
+	"time"
+	// Embeds the timezone database so BUSINESS_TIMEZONE works on hosts without one
+	_ "time/tzdata"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -82,6 +94,11 @@ func startServer() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// Amounts are stored in minor units of a single currency
+	if err := money.SetDefaultCurrency(cfg.Currency); err != nil {
+		log.Fatalf("Failed to set currency: %v", err)
+	}
+
 	// Initialize database
 	if err := database.InitDB(cfg); err != nil {
 		if strings.Contains(err.Error(), "constraint \"uni_cars_vehicle_number\" of relation \"cars\" does not exist") {
@@ -91,8 +108,105 @@ func startServer() {
 		}
 	}
 
+	// Load the public holiday calendar used for holiday rate rules
+	if err := pricing.LoadHolidayCalendar(cfg.HolidayCalendarFile); err != nil {
+		log.Printf("Warning: %v; holiday rates will not apply", err)
+	}
+
+	// Rate rules and holidays are matched on calendar days in the business timezone
+	if err := pricing.SetBusinessTimezone(cfg.BusinessTimezone); err != nil {
+		log.Fatalf("Failed to set business timezone: %v", err)
+	}
+
+	// Late returns are only charged once the grace period has passed
+	services.SetLateReturnGrace(time.Duration(cfg.LateReturnGraceMinutes) * time.Minute)
+
+	// Access tokens are short-lived and renewed with rotating refresh tokens
+	accessTokenLifetime := time.Duration(cfg.AccessTokenMinutes) * time.Minute
+	services.SetTokenLifetimes(accessTokenLifetime, time.Duration(cfg.RefreshTokenDays)*24*time.Hour)
+
+	// Access tokens are signed with rotating asymmetric keys. A replaced key keeps
+	// verifying for the grace period, which must outlast the tokens it signed.
+	gracePeriod := time.Duration(cfg.JWTKeyGraceHours) * time.Hour
+	if gracePeriod < accessTokenLifetime {
+		gracePeriod = accessTokenLifetime
+	}
+	keyManager, err := tokens.InitKeyManager(database.GetDB(), tokens.Options{
+		Algorithm:      cfg.JWTAlgorithm,
+		RotationPeriod: time.Duration(cfg.JWTKeyRotationDays) * 24 * time.Hour,
+		GracePeriod:    gracePeriod,
+		Secret:         cfg.JWTKeySecret,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize token signing keys: %v", err)
+	}
+	keyManager.Start(time.Hour, nil)
+
+	// Initialize the payment gateway. The fake gateway only runs in development and test.
+	if err := payments.InitGateway(cfg.PaymentGateway, cfg.PaymentWebhookSecret, cfg.Environment); err != nil {
+		log.Fatalf("Failed to initialize payment gateway: %v", err)
+	}
+
+	// New passwords must satisfy the password policy and may not be recent ones
+	utils.SetPasswordPolicy(utils.PasswordPolicy{
+		MinLength:      cfg.PasswordMinLength,
+		RequireUpper:   cfg.PasswordRequireUpper,
+		RequireLower:   cfg.PasswordRequireLower,
+		RequireDigit:   cfg.PasswordRequireDigit,
+		RequireSpecial: cfg.PasswordRequireSpecial,
+	})
+	if err := utils.LoadBreachedPasswords(cfg.BreachedPasswordsFile); err != nil {
+		log.Printf("Warning: %v; passwords will not be checked against it", err)
+	}
+	services.SetPasswordHistorySize(cfg.PasswordHistorySize)
+
+	// TOTP secrets are encrypted at rest. Admin permissions only apply to
+	// sessions that passed two-factor authentication when it is required.
+	services.SetTwoFactorOptions(cfg.TwoFactorIssuer, cfg.TwoFactorSecretKey)
+	if cfg.TwoFactorRequiredForAdmin {
+		services.SetTwoFactorRequiredRoles(models.UserRoleAdmin)
+	}
+
+	// Failed logins slow down further attempts and lock out after a limit
+	if err := lockout.InitGuard(cfg.LoginAttemptStore, lockout.Options{
+		MaxFailures:     cfg.LoginMaxFailures,
+		MaxIPFailures:   cfg.LoginMaxIPFailures,
+		LockoutDuration: time.Duration(cfg.LoginLockoutMinutes) * time.Minute,
+	}); err != nil {
+		log.Fatalf("Failed to initialize login protection: %v", err)
+	}
+
+	// Account emails carry verification and password reset links to the frontend
+	if err := mailer.InitMailer(mailer.Options{
+		Provider:     cfg.Mailer,
+		From:         cfg.MailFrom,
+		Dir:          cfg.MailDir,
+		SMTPHost:     cfg.SMTPHost,
+		SMTPPort:     cfg.SMTPPort,
+		SMTPUsername: cfg.SMTPUsername,
+		SMTPPassword: cfg.SMTPPassword,
+	}); err != nil {
+		log.Fatalf("Failed to initialize mailer: %v", err)
+	}
+	services.SetAppBaseURL(cfg.AppBaseURL)
+	services.SetAccountTokenLifetimes(
+		time.Duration(cfg.EmailVerificationHours)*time.Hour,
+		time.Duration(cfg.PasswordResetMinutes)*time.Minute,
+	)
+
+	// Client IP addresses, used to throttle logins, are only read from the
+	// proxy header when the request comes from a trusted proxy. Otherwise
+	// every client behind a load balancer would share its address.
+	if cfg.ProxyHeader != "" && len(cfg.TrustedProxies) == 0 {
+		log.Fatalf("PROXY_HEADER is set but TRUSTED_PROXIES is empty")
+	}
+
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
+		ProxyHeader:             cfg.ProxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          cfg.TrustedProxies,
+		EnableIPValidation:      true,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
 			var message string
@@ -125,7 +239,7 @@ func startServer() {
 	app.Use(logger.New())
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
+		AllowHeaders: "Origin, Content-Type, Accept, Authorization, Accept-Currency",
 		AllowMethods: "GET, POST, PUT, DELETE",
 	}))
 
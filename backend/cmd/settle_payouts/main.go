@@ -0,0 +1,65 @@
+// Command settle_payouts creates owner payout statements for the bookings
+// invoiced in a period. Run it once a period has closed, e.g. from cron on the
+// first of each month:
+//
+//	go run cmd/settle_payouts/main.go
+//	go run cmd/settle_payouts/main.go -from 2024-01-01 -to 2024-02-01
+//
+// Bookings already in a payout are skipped, so re-running a period is safe.
+package main
+
+import (
+	"car-rental-backend/config"
+	"car-rental-backend/database"
+	"car-rental-backend/money"
+	"car-rental-backend/services"
+	"flag"
+	"log"
+	"time"
+)
+
+func main() {
+	// Default to the previous calendar month
+	now := time.Now()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	fromFlag := flag.String("from", thisMonth.AddDate(0, -1, 0).Format("2006-01-02"), "start of the period (YYYY-MM-DD, inclusive)")
+	toFlag := flag.String("to", thisMonth.Format("2006-01-02"), "end of the period (YYYY-MM-DD, exclusive)")
+	flag.Parse()
+
+	from, err := time.ParseInLocation("2006-01-02", *fromFlag, now.Location())
+	if err != nil {
+		log.Fatalf("Invalid -from date: %v", err)
+	}
+	to, err := time.ParseInLocation("2006-01-02", *toFlag, now.Location())
+	if err != nil {
+		log.Fatalf("Invalid -to date: %v", err)
+	}
+	if !to.After(from) {
+		log.Fatalf("-to must be after -from")
+	}
+
+	// Load configuration
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	if err := money.SetDefaultCurrency(cfg.Currency); err != nil {
+		log.Fatalf("Failed to set currency: %v", err)
+	}
+
+	if err := database.InitDB(cfg); err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	payoutService := services.NewPayoutService()
+	payouts, err := payoutService.SettlePayouts(from, to)
+	if err != nil {
+		log.Fatalf("Failed to settle payouts: %v", err)
+	}
+
+	for _, payout := range payouts {
+		log.Printf("Owner %s: %d booking(s), revenue %s, payout %s", payout.OwnerID, payout.Bookings, payout.Revenue, payout.Amount)
+	}
+	log.Printf("Settled %d payout(s) for %s to %s", len(payouts), from.Format("2006-01-02"), to.Format("2006-01-02"))
+}
@@ -4,21 +4,60 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Port               string
-	Environment        string
-	DBHost             string
-	DBPort             string
-	DBUser             string
-	DBPassword         string
-	DBName             string
-	DBSSLMode          string
-	JWTSecret          string
-	JWTExpirationHours int
+	Port                      string
+	Environment               string
+	ProxyHeader               string
+	TrustedProxies            []string
+	DBHost                    string
+	DBPort                    string
+	DBUser                    string
+	DBPassword                string
+	DBName                    string
+	DBSSLMode                 string
+	JWTAlgorithm              string
+	JWTIssuer                 string
+	JWTKeySecret              string
+	JWTKeyRotationDays        int
+	JWTKeyGraceHours          int
+	AccessTokenMinutes        int
+	RefreshTokenDays          int
+	HolidayCalendarFile       string
+	BusinessTimezone          string
+	LateReturnGraceMinutes    int
+	PaymentGateway            string
+	PaymentWebhookSecret      string
+	Currency                  string
+	AppBaseURL                string
+	EmailVerificationHours    int
+	PasswordResetMinutes      int
+	Mailer                    string
+	MailFrom                  string
+	MailDir                   string
+	SMTPHost                  string
+	SMTPPort                  int
+	SMTPUsername              string
+	SMTPPassword              string
+	LoginAttemptStore         string
+	LoginMaxFailures          int
+	LoginMaxIPFailures        int
+	LoginLockoutMinutes       int
+	PasswordMinLength         int
+	PasswordRequireUpper      bool
+	PasswordRequireLower      bool
+	PasswordRequireDigit      bool
+	PasswordRequireSpecial    bool
+	BreachedPasswordsFile     string
+	PasswordHistorySize       int
+	TwoFactorIssuer           string
+	TwoFactorSecretKey        string
+	TwoFactorChallengeMinutes int
+	TwoFactorRequiredForAdmin bool
 }
 
 func LoadConfig() (*Config, error) {
@@ -27,16 +66,54 @@ func LoadConfig() (*Config, error) {
 	}
 
 	config := &Config{
-		Port:               getEnv("PORT", "8080"),
-		Environment:        getEnv("ENV", "development"),
-		DBHost:             getEnv("DB_HOST", "localhost"),
-		DBPort:             getEnv("DB_PORT", "5432"),
-		DBUser:             getEnv("DB_USER", "postgres"),
-		DBPassword:         getEnv("DB_PASSWORD", "postgres"),
-		DBName:             getEnv("DB_NAME", "car_rental"),
-		DBSSLMode:          getEnv("DB_SSL_MODE", "disable"),
-		JWTSecret:          getEnv("JWT_SECRET", "your-secret-key-here"),
-		JWTExpirationHours: getEnvAsInt("JWT_EXPIRATION_HOURS", 24),
+		Port:                      getEnv("PORT", "8080"),
+		Environment:               getEnv("ENV", "development"),
+		ProxyHeader:               getEnv("PROXY_HEADER", ""),
+		TrustedProxies:            getEnvAsList("TRUSTED_PROXIES"),
+		DBHost:                    getEnv("DB_HOST", "localhost"),
+		DBPort:                    getEnv("DB_PORT", "5432"),
+		DBUser:                    getEnv("DB_USER", "postgres"),
+		DBPassword:                getEnv("DB_PASSWORD", "postgres"),
+		DBName:                    getEnv("DB_NAME", "car_rental"),
+		DBSSLMode:                 getEnv("DB_SSL_MODE", "disable"),
+		JWTAlgorithm:              getEnv("JWT_ALGORITHM", "RS256"),
+		JWTIssuer:                 getEnv("JWT_ISSUER", "car-rental-backend"),
+		JWTKeySecret:              getEnv("JWT_KEY_SECRET", "your-key-secret-here"),
+		JWTKeyRotationDays:        getEnvAsInt("JWT_KEY_ROTATION_DAYS", 30),
+		JWTKeyGraceHours:          getEnvAsInt("JWT_KEY_GRACE_HOURS", 24),
+		AccessTokenMinutes:        getEnvAsInt("ACCESS_TOKEN_MINUTES", 15),
+		RefreshTokenDays:          getEnvAsInt("REFRESH_TOKEN_DAYS", 30),
+		HolidayCalendarFile:       getEnv("HOLIDAY_CALENDAR_FILE", "data/holidays.json"),
+		BusinessTimezone:          getEnv("BUSINESS_TIMEZONE", "UTC"),
+		LateReturnGraceMinutes:    getEnvAsInt("LATE_RETURN_GRACE_MINUTES", 30),
+		PaymentGateway:            getEnv("PAYMENT_GATEWAY", ""),
+		PaymentWebhookSecret:      getEnv("PAYMENT_WEBHOOK_SECRET", "your-webhook-secret-here"),
+		Currency:                  getEnv("CURRENCY", "USD"),
+		AppBaseURL:                getEnv("APP_BASE_URL", "http://localhost:3000"),
+		EmailVerificationHours:    getEnvAsInt("EMAIL_VERIFICATION_HOURS", 48),
+		PasswordResetMinutes:      getEnvAsInt("PASSWORD_RESET_MINUTES", 60),
+		Mailer:                    getEnv("MAILER", "file"),
+		MailFrom:                  getEnv("MAIL_FROM", "Car Rental <no-reply@example.com>"),
+		MailDir:                   getEnv("MAIL_DIR", "data/mail"),
+		SMTPHost:                  getEnv("SMTP_HOST", ""),
+		SMTPPort:                  getEnvAsInt("SMTP_PORT", 587),
+		SMTPUsername:              getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:              getEnv("SMTP_PASSWORD", ""),
+		LoginAttemptStore:         getEnv("LOGIN_ATTEMPT_STORE", "memory"),
+		LoginMaxFailures:          getEnvAsInt("LOGIN_MAX_FAILURES", 5),
+		LoginMaxIPFailures:        getEnvAsInt("LOGIN_MAX_IP_FAILURES", 20),
+		LoginLockoutMinutes:       getEnvAsInt("LOGIN_LOCKOUT_MINUTES", 15),
+		PasswordMinLength:         getEnvAsInt("PASSWORD_MIN_LENGTH", 8),
+		PasswordRequireUpper:      getEnvAsBool("PASSWORD_REQUIRE_UPPER", true),
+		PasswordRequireLower:      getEnvAsBool("PASSWORD_REQUIRE_LOWER", true),
+		PasswordRequireDigit:      getEnvAsBool("PASSWORD_REQUIRE_DIGIT", true),
+		PasswordRequireSpecial:    getEnvAsBool("PASSWORD_REQUIRE_SPECIAL", true),
+		BreachedPasswordsFile:     getEnv("BREACHED_PASSWORDS_FILE", "data/breached_passwords.txt"),
+		PasswordHistorySize:       getEnvAsInt("PASSWORD_HISTORY_SIZE", 5),
+		TwoFactorIssuer:           getEnv("TWO_FACTOR_ISSUER", "Car Rental"),
+		TwoFactorSecretKey:        getEnv("TWO_FACTOR_SECRET_KEY", "your-two-factor-secret-here"),
+		TwoFactorChallengeMinutes: getEnvAsInt("TWO_FACTOR_CHALLENGE_MINUTES", 5),
+		TwoFactorRequiredForAdmin: getEnvAsBool("TWO_FACTOR_REQUIRED_FOR_ADMIN", true),
 	}
 
 	return config, nil
@@ -57,3 +134,23 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsList reads a comma-separated list, skipping empty entries
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -3,17 +3,25 @@ package controllers
 import (
 	"car-rental-backend/config"
 	"car-rental-backend/database"
+	"car-rental-backend/lockout"
 	"car-rental-backend/middlewares"
 	"car-rental-backend/models"
+	"car-rental-backend/services"
 	"car-rental-backend/utils"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 type RegisterRequest struct {
 	Name     string `json:"name" validate:"required"`
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
+	Password string `json:"password" validate:"required,strongPassword"`
 }
 
 type LoginRequest struct {
@@ -21,12 +29,46 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+// EmailRequest represents a request body holding just an email address
+type EmailRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// VerifyEmailRequest represents the request body for verifying an email address
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ResetPasswordRequest represents the request body for choosing a new password
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,strongPassword"`
+}
+
+// TwoFactorLoginRequest represents the request body for the second step of a
+// login with two-factor authentication. Either code or recovery_code is needed.
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required_without=RecoveryCode"`
+	RecoveryCode   string `json:"recovery_code" validate:"required_without=Code"`
+}
+
+// RefreshRequest represents the request body for exchanging a refresh token
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
 func Register(c *fiber.Ctx) error {
 	var req RegisterRequest
 	if err := c.BodyParser(&req); err != nil {
 		return utils.ValidationErrorResponse(c, "Invalid request body", []string{"Failed to parse request body"})
 	}
 
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return utils.ValidationErrorResponse(c, "Validation failed", validationErrors)
+	}
+
 	// Check if user already exists
 	var existingUser models.User
 	if result := database.DB.Where("email = ?", req.Email).First(&existingUser); result.Error == nil {
@@ -38,33 +80,25 @@ func Register(c *fiber.Ctx) error {
 		Email: req.Email,
 	}
 
-	if err := user.SetPassword(req.Password); err != nil {
-		return utils.ServerErrorResponse(c, "Failed to process password")
-	}
-
-	if result := database.DB.Create(&user); result.Error != nil {
+	if err := services.NewPasswordService().CreateUser(&user, req.Password); err != nil {
 		return utils.ServerErrorResponse(c, "Failed to create user")
 	}
 
-	// Generate JWT token
-	cfg := c.Locals("config").(*config.Config)
-	token, err := middlewares.GenerateToken(user.ID.String(), user.Role, cfg)
-	if err != nil {
-		return utils.ServerErrorResponse(c, "Failed to generate token")
+	// The account cannot be used until the email address is verified
+	message := "User registered successfully. Check your email to verify your address"
+	if err := services.NewAccountService().SendVerificationEmail(&user); err != nil {
+		message = "User registered successfully, but the verification email could not be sent. Request a new one to verify your address"
 	}
 
-	// Create response data
-	responseData := fiber.Map{
-		"token": token,
+	return utils.SuccessResponse(c, fiber.Map{
 		"user": models.UserResponse{
 			ID:        user.ID.String(),
 			Name:      user.Name,
 			Email:     user.Email,
+			Role:      user.Role,
 			CreatedAt: user.CreatedAt,
 		},
-	}
-
-	return utils.SuccessResponse(c, responseData, "User registered successfully")
+	}, message)
 }
 
 func Login(c *fiber.Ctx) error {
@@ -73,32 +107,342 @@ func Login(c *fiber.Ctx) error {
 		return utils.ValidationErrorResponse(c, "Invalid request body", []string{"Failed to parse request body"})
 	}
 
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return utils.ValidationErrorResponse(c, "Validation failed", validationErrors)
+	}
+
+	// Password guessing is slowed down per email and locked out after too
+	// many failures per email or IP address. The attempt is reserved before
+	// the password is checked, so parallel guesses cannot get around the limits.
+	attempts := services.NewLoginAttemptService()
+	attempt, err := attempts.Begin(req.Email, c.IP())
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Failed to check login attempts")
+	}
+	if !attempt.Allowed {
+		return tooManyLoginAttempts(c, attempt.Decision)
+	}
+
 	var user models.User
-	if result := database.DB.Where("email = ?", req.Email).First(&user); result.Error != nil {
+	if result := database.DB.Where("email = ?", req.Email).First(&user); result.Error != nil || !user.CheckPassword(req.Password) {
+		if err := attempts.RecordFailure(attempt, req.Email, c.IP()); err != nil {
+			return utils.ServerErrorResponse(c, "Failed to record login attempt")
+		}
 		return utils.UnauthorizedResponse(c, "Invalid credentials")
 	}
+	if err := attempts.Release(attempt); err != nil {
+		return utils.ServerErrorResponse(c, "Failed to record login attempt")
+	}
 
-	if !user.CheckPassword(req.Password) {
-		return utils.UnauthorizedResponse(c, "Invalid credentials")
+	if !user.IsEmailVerified() {
+		return utils.ForbiddenResponse(c, "Email address has not been verified")
+	}
+
+	// Users with two-factor authentication finish logging in at /auth/login/two-factor
+	enabled, err := services.NewTwoFactorService().IsEnabled(user.ID)
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Failed to check two-factor authentication")
+	}
+	if enabled {
+		cfg := c.Locals("config").(*config.Config)
+		challenge, expiresAt, err := middlewares.GenerateChallengeToken(user.ID.String(), cfg)
+		if err != nil {
+			return utils.ServerErrorResponse(c, "Failed to generate token")
+		}
+		return utils.SuccessResponse(c, fiber.Map{
+			"two_factor_required":  true,
+			"challenge_token":      challenge,
+			"challenge_expires_at": expiresAt,
+		}, "Enter the code from your authenticator app to finish logging in")
+	}
+
+	return completeLogin(c, &user, false)
+}
+
+// LoginTwoFactor finishes a login of a user with two-factor authentication,
+// exchanging the challenge token from Login and a TOTP code or recovery code
+// for an access and a refresh token
+func LoginTwoFactor(c *fiber.Ctx) error {
+	var req TwoFactorLoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request body", []string{"Failed to parse request body"})
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return utils.ValidationErrorResponse(c, "Validation failed", validationErrors)
 	}
 
-	// Generate JWT token
 	cfg := c.Locals("config").(*config.Config)
-	token, err := middlewares.GenerateToken(user.ID.String(), user.Role, cfg)
+	userID, challengeID, expiresAt, err := middlewares.ParseChallengeToken(req.ChallengeToken, cfg)
+	if err != nil {
+		return utils.UnauthorizedResponse(c, "Invalid or expired challenge token")
+	}
+
+	var user models.User
+	if result := database.DB.First(&user, "id = ?", userID); result.Error != nil {
+		return utils.UnauthorizedResponse(c, "Invalid or expired challenge token")
+	}
+
+	// Wrong codes count as failed logins, so code guessing is throttled too
+	attempts := services.NewLoginAttemptService()
+	attempt, err := attempts.Begin(user.Email, c.IP())
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Failed to check login attempts")
+	}
+	if !attempt.Allowed {
+		return tooManyLoginAttempts(c, attempt.Decision)
+	}
+
+	// Each challenge can complete one login. It is claimed before the code is
+	// checked, so concurrent requests with the same challenge cannot all pass;
+	// after a wrong code the user logs in again for a new challenge.
+	if err := services.NewTokenService().UseChallenge(challengeID, expiresAt); err != nil {
+		if errors.Is(err, services.ErrChallengeUsed) {
+			return utils.UnauthorizedResponse(c, "Invalid or expired challenge token")
+		}
+		return utils.ServerErrorResponse(c, "Failed to check challenge token")
+	}
+
+	if err := services.NewTwoFactorService().Verify(user.ID, req.Code, req.RecoveryCode); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidTwoFactorCode):
+			if err := attempts.RecordFailure(attempt, user.Email, c.IP()); err != nil {
+				return utils.ServerErrorResponse(c, "Failed to record login attempt")
+			}
+			return utils.UnauthorizedResponse(c, "Invalid two-factor code")
+		case errors.Is(err, services.ErrTwoFactorNotEnabled):
+			return utils.UnauthorizedResponse(c, "Invalid or expired challenge token")
+		default:
+			return utils.ServerErrorResponse(c, "Failed to verify two-factor code")
+		}
+	}
+	if err := attempts.Release(attempt); err != nil {
+		return utils.ServerErrorResponse(c, "Failed to record login attempt")
+	}
+
+	return completeLogin(c, &user, true)
+}
+
+// completeLogin starts a new session for the user and responds with its
+// tokens. Only a login that gets this far clears the failed attempts, so
+// knowing the password alone does not reset a lockout.
+func completeLogin(c *fiber.Ctx, user *models.User, twoFactor bool) error {
+	// Start a new session with an access and a refresh token
+	responseData, err := issueTokens(c, user, twoFactor)
 	if err != nil {
 		return utils.ServerErrorResponse(c, "Failed to generate token")
 	}
 
-	// Create response data
-	responseData := fiber.Map{
-		"token": token,
-		"user": models.UserResponse{
-			ID:        user.ID.String(),
-			Name:      user.Name,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt,
-		},
+	if err := services.NewLoginAttemptService().RecordSuccess(user.Email); err != nil {
+		return utils.ServerErrorResponse(c, "Failed to record login attempt")
+	}
+	responseData["user"] = models.UserResponse{
+		ID:              user.ID.String(),
+		Name:            user.Name,
+		Email:           user.Email,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		Role:            user.Role,
+		OwnerID:         user.OwnerID,
+		CreatedAt:       user.CreatedAt,
+	}
+
+	// Roles that must use two-factor authentication can log in without it to
+	// enroll, but their permissions do not apply until they do
+	if !twoFactor && services.IsTwoFactorRequired(user.Role) {
+		responseData["two_factor_setup_required"] = true
 	}
 
 	return utils.SuccessResponse(c, responseData, "Login successful")
 }
+
+// VerifyEmail verifies the user's email address with the token from their
+// verification email, after which they can log in
+func VerifyEmail(c *fiber.Ctx) error {
+	var req VerifyEmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request body", []string{"Failed to parse request body"})
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return utils.ValidationErrorResponse(c, "Validation failed", validationErrors)
+	}
+
+	user, err := services.NewAccountService().VerifyEmail(req.Token)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAccountToken) {
+			return utils.ValidationErrorResponse(c, "Invalid verification token", []string{err.Error()})
+		}
+		return utils.ServerErrorResponse(c, "Failed to verify email")
+	}
+
+	return utils.SuccessResponse(c, models.UserResponse{
+		ID:              user.ID.String(),
+		Name:            user.Name,
+		Email:           user.Email,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		Role:            user.Role,
+		OwnerID:         user.OwnerID,
+		CreatedAt:       user.CreatedAt,
+	}, "Email verified successfully")
+}
+
+// ResendVerification sends a new verification email. The response is the same
+// whether or not the email belongs to an unverified user.
+func ResendVerification(c *fiber.Ctx) error {
+	var req EmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request body", []string{"Failed to parse request body"})
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return utils.ValidationErrorResponse(c, "Validation failed", validationErrors)
+	}
+
+	if err := services.NewAccountService().ResendVerificationEmail(req.Email); err != nil {
+		return utils.ServerErrorResponse(c, "Failed to send verification email")
+	}
+
+	return utils.SuccessResponse(c, nil, "If the account exists and is not verified yet, a verification email has been sent")
+}
+
+// ForgotPassword emails a password reset link. The response is the same
+// whether or not the email is registered.
+func ForgotPassword(c *fiber.Ctx) error {
+	var req EmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request body", []string{"Failed to parse request body"})
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return utils.ValidationErrorResponse(c, "Validation failed", validationErrors)
+	}
+
+	if err := services.NewAccountService().RequestPasswordReset(req.Email); err != nil {
+		return utils.ServerErrorResponse(c, "Failed to send password reset email")
+	}
+
+	return utils.SuccessResponse(c, nil, "If the account exists, a password reset email has been sent")
+}
+
+// ResetPassword sets a new password with the token from a password reset email
+// and logs the user out of every session
+func ResetPassword(c *fiber.Ctx) error {
+	var req ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request body", []string{"Failed to parse request body"})
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return utils.ValidationErrorResponse(c, "Validation failed", validationErrors)
+	}
+
+	if err := services.NewAccountService().ResetPassword(req.Token, req.Password); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAccountToken):
+			return utils.ValidationErrorResponse(c, "Invalid password reset token", []string{err.Error()})
+		case errors.Is(err, services.ErrPasswordReused):
+			return utils.ValidationErrorResponse(c, "Validation failed", []string{passwordReusedError("password")})
+		default:
+			return utils.ServerErrorResponse(c, "Failed to reset password")
+		}
+	}
+
+	return utils.SuccessResponse(c, nil, "Password reset successfully. Log in with your new password")
+}
+
+// Refresh exchanges a refresh token for a new access token and the next refresh
+// token of the session. Each refresh token works once; presenting a used one
+// again logs the whole session out.
+func Refresh(c *fiber.Ctx) error {
+	var req RefreshRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request body", []string{"Failed to parse request body"})
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return utils.ValidationErrorResponse(c, "Validation failed", validationErrors)
+	}
+
+	tokenService := services.NewTokenService()
+	refreshToken, record, user, err := tokenService.RotateRefreshToken(req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrRefreshTokenReused):
+			return utils.UnauthorizedResponse(c, "Refresh token has already been used; the session has been revoked")
+		case errors.Is(err, services.ErrInvalidRefreshToken):
+			return utils.UnauthorizedResponse(c, "Invalid refresh token")
+		default:
+			return utils.ServerErrorResponse(c, "Failed to refresh token")
+		}
+	}
+
+	cfg := c.Locals("config").(*config.Config)
+	token, err := middlewares.GenerateToken(user.ID.String(), user.Role, record.FamilyID, record.TwoFactor, cfg)
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Failed to generate token")
+	}
+
+	return utils.SuccessResponse(c, tokenResponse(token, refreshToken, record), "Token refreshed successfully")
+}
+
+// Logout revokes the access token it is called with and the refresh tokens of its session
+func Logout(c *fiber.Ctx) error {
+	tokenID, _ := c.Locals("token_id").(uuid.UUID)
+	familyID, _ := c.Locals("token_family_id").(uuid.UUID)
+	expiresAt, _ := c.Locals("token_expires_at").(time.Time)
+
+	tokenService := services.NewTokenService()
+	if err := tokenService.Logout(tokenID, familyID, expiresAt); err != nil {
+		return utils.ServerErrorResponse(c, "Failed to log out")
+	}
+
+	return utils.SuccessResponse(c, nil, "Logged out successfully")
+}
+
+// tooManyLoginAttempts rejects a login attempt made during a delay or lockout,
+// telling the client when to try again
+func tooManyLoginAttempts(c *fiber.Ctx, decision lockout.Decision) error {
+	seconds := int(math.Ceil(decision.RetryAfter.Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+
+	message := fmt.Sprintf("Too many failed login attempts. Try again in %d seconds", seconds)
+	if decision.Locked {
+		message = "Too many failed login attempts. Login is temporarily locked"
+	}
+	return utils.ErrorResponse(c, message, nil, fiber.StatusTooManyRequests)
+}
+
+// issueTokens starts a new session for the user, returning its access token
+// and first refresh token. twoFactor records whether the login passed
+// two-factor authentication.
+func issueTokens(c *fiber.Ctx, user *models.User, twoFactor bool) (fiber.Map, error) {
+	tokenService := services.NewTokenService()
+	refreshToken, record, err := tokenService.IssueRefreshToken(user.ID, twoFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := c.Locals("config").(*config.Config)
+	token, err := middlewares.GenerateToken(user.ID.String(), user.Role, record.FamilyID, record.TwoFactor, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return tokenResponse(token, refreshToken, record), nil
+}
+
+// tokenResponse builds the token part of a login or refresh response
+func tokenResponse(token, refreshToken string, record *models.RefreshToken) fiber.Map {
+	return fiber.Map{
+		"token":                    token,
+		"refresh_token":            refreshToken,
+		"refresh_token_expires_at": record.ExpiresAt,
+	}
+}
@@ -2,9 +2,12 @@ package controllers
 
 import (
 	"car-rental-backend/database"
+	"car-rental-backend/middlewares"
 	"car-rental-backend/models"
+	"car-rental-backend/pricing"
 	"car-rental-backend/services"
 	"car-rental-backend/utils"
+	"errors"
 	"fmt"
 	"time"
 
@@ -16,6 +19,7 @@ type CreateBookingRequest struct {
 	CarID     string    `json:"car_id" validate:"required"`
 	StartTime time.Time `json:"start_time" validate:"required"`
 	EndTime   time.Time `json:"end_time" validate:"required"`
+	PromoCode string    `json:"promo_code"`
 }
 
 func CreateBooking(c *fiber.Ctx) error {
@@ -44,62 +48,17 @@ func CreateBooking(c *fiber.Ctx) error {
 		return utils.ServerErrorResponse(c, "Invalid user ID")
 	}
 
-	// Check if car exists and is available
-	var car models.Car
-	if result := database.DB.Preload("CurrentStatus").Preload("RentalInfo").First(&car, "id = ?", carID); result.Error != nil {
-		return utils.NotFoundResponse(c, "Car not found")
-	}
-
-	if car.CurrentStatus == nil || !car.CurrentStatus.IsAvailable {
-		return utils.ConflictResponse(c, "Car is not available", nil)
-	}
-
-	// Check for booking conflicts
-	var conflictCount int64
-	database.DB.Model(&models.Booking{}).
-		Where("car_id = ? AND status = ? AND start_time < ? AND end_time > ?",
-			carID, models.BookingStatusBooked, req.EndTime, req.StartTime).
-		Count(&conflictCount)
-
-	if conflictCount > 0 {
-		return utils.ConflictResponse(c, "Car is already booked for this time period", nil)
-	}
-
-	// Calculate total price
-	hours := req.EndTime.Sub(req.StartTime).Hours()
-	var totalPrice float64
-
-	if car.RentalInfo == nil {
-		return utils.ServerErrorResponse(c, "Car rental information is missing")
-	}
-
-	if hours <= 24 {
-		// Use hourly rate for bookings less than 24 hours
-		totalPrice = hours * car.RentalInfo.RentalPricePerHour
-	} else {
-		// Use daily rate for bookings more than 24 hours
-		days := hours / 24
-		totalPrice = days * car.RentalInfo.RentalPricePerDay
-	}
-
-	// Create booking
-	booking := models.Booking{
-		UserID:     userID,
-		CarID:      carID,
-		StartTime:  req.StartTime,
-		EndTime:    req.EndTime,
-		Status:     models.BookingStatusBooked,
-		TotalPrice: totalPrice,
-	}
-
-	if result := database.DB.Create(&booking); result.Error != nil {
-		return utils.ServerErrorResponse(c, "Failed to create booking")
-	}
-
-	// Update car status
-	carService := services.NewCarService()
-	if err := carService.UpdateCarAvailability(carID, false); err != nil {
-		return utils.ServerErrorResponse(c, "Failed to update car status")
+	bookingService := services.NewBookingService()
+	booking, err := bookingService.CreateBooking(services.BookingInput{
+		UserID:    userID,
+		CarID:     carID,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		PromoCode: req.PromoCode,
+		Currency:  requestedCurrency(c),
+	})
+	if err != nil {
+		return bookingErrorResponse(c, err, "Failed to create booking")
 	}
 
 	return utils.SuccessResponse(c, booking, "Booking created successfully")
@@ -113,13 +72,13 @@ func GetBooking(c *fiber.Ctx) error {
 	}
 
 	var booking models.Booking
-	if result := database.DB.Preload("User").Preload("Car").First(&booking, "id = ?", bookingID); result.Error != nil {
+	if result := database.DB.Preload("User").Preload("Car").Preload("Charges").Preload("Payments").First(&booking, "id = ?", bookingID); result.Error != nil {
 		return utils.NotFoundResponse(c, "Booking not found")
 	}
 
 	// Check if the user is authorized to view this booking
 	userID := c.Locals("user_id").(string)
-	if booking.UserID.String() != userID {
+	if booking.UserID.String() != userID && !hasPermission(c, middlewares.PermManageBookings) {
 		return utils.ForbiddenResponse(c, "Not authorized to view this booking")
 	}
 
@@ -138,12 +97,6 @@ func GetUserBookings(c *fiber.Ctx) error {
 }
 
 func GetAllBookings(c *fiber.Ctx) error {
-	// Check if user is admin
-	userRole := c.Locals("user_role").(string)
-	if userRole != "admin" {
-		return utils.ForbiddenResponse(c, "Only admin users can access this resource")
-	}
-
 	// Get pagination parameters
 	page, pageSize := utils.GetPaginationParams(c)
 	offset := (page - 1) * pageSize
@@ -208,40 +161,216 @@ func GetAllBookings(c *fiber.Ctx) error {
 	return utils.PaginatedSuccessResponse(c, bookings, pagination, "All bookings fetched successfully")
 }
 
+// BookingTransitionRequest carries an optional note recorded in the booking history
+type BookingTransitionRequest struct {
+	Note string `json:"note"`
+}
+
 func CancelBooking(c *fiber.Ctx) error {
+	return transitionBooking(c, models.BookingStatusCancelled, "Booking cancelled successfully")
+}
+
+// ConfirmBooking confirms a pending booking (admin only)
+func ConfirmBooking(c *fiber.Ctx) error {
+	return transitionBooking(c, models.BookingStatusConfirmed, "Booking confirmed successfully")
+}
+
+// PickupBooking records that the customer has collected the car (admin only)
+func PickupBooking(c *fiber.Ctx) error {
+	return transitionBooking(c, models.BookingStatusPickedUp, "Booking picked up successfully")
+}
+
+// ReturnBooking records that the customer has returned the car (admin only)
+func ReturnBooking(c *fiber.Ctx) error {
+	return transitionBooking(c, models.BookingStatusReturned, "Booking returned successfully")
+}
+
+// CompleteBooking closes a returned booking (admin only)
+func CompleteBooking(c *fiber.Ctx) error {
+	return transitionBooking(c, models.BookingStatusCompleted, "Booking completed successfully")
+}
+
+// MarkBookingNoShow records that the customer never collected the car (admin only)
+func MarkBookingNoShow(c *fiber.Ctx) error {
+	return transitionBooking(c, models.BookingStatusNoShow, "Booking marked as no-show successfully")
+}
+
+// ExtendBookingRequest represents the request body for extending a booking
+type ExtendBookingRequest struct {
+	EndTime time.Time `json:"end_time" validate:"required"`
+}
+
+// ExtendBooking moves the end time of an active booking later, charging the car's extend fees
+func ExtendBooking(c *fiber.Ctx) error {
 	id := c.Params("id")
 	bookingID, err := uuid.Parse(id)
 	if err != nil {
 		return utils.ValidationErrorResponse(c, "Invalid booking ID", []string{"Invalid UUID format"})
 	}
 
+	var req ExtendBookingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request body", []string{
+			"Failed to parse request body: " + err.Error(),
+		})
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return utils.ValidationErrorResponse(c, "Validation failed", validationErrors)
+	}
+
+	actorID, err := uuid.Parse(c.Locals("user_id").(string))
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Invalid user ID")
+	}
+
 	var booking models.Booking
 	if result := database.DB.First(&booking, "id = ?", bookingID); result.Error != nil {
 		return utils.NotFoundResponse(c, "Booking not found")
 	}
 
-	// Check if the user is authorized to cancel this booking
+	// Check if the user is authorized to extend this booking
+	if booking.UserID != actorID && !hasPermission(c, middlewares.PermManageBookings) {
+		return utils.ForbiddenResponse(c, "Not authorized to update this booking")
+	}
+
+	bookingService := services.NewBookingService()
+	extended, err := bookingService.ExtendBooking(bookingID, req.EndTime)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrBookingNotFound):
+			return utils.NotFoundResponse(c, "Booking not found")
+		case errors.Is(err, services.ErrBookingNotExtendable):
+			return utils.ConflictResponse(c, "Booking cannot be extended", []string{err.Error()})
+		case errors.Is(err, pricing.ErrInvalidWindow):
+			return utils.ValidationErrorResponse(c, "New end time must be after the current end time", []string{"Invalid time range"})
+		default:
+			return bookingErrorResponse(c, err, "Failed to extend booking")
+		}
+	}
+
+	return utils.SuccessResponse(c, extended, "Booking extended successfully")
+}
+
+// GetOverdueBookings lists picked-up bookings that are past their return time (admin only)
+func GetOverdueBookings(c *fiber.Ctx) error {
+	bookingService := services.NewBookingService()
+	overdue, err := bookingService.GetOverdueBookings()
+	if err != nil {
+		return bookingErrorResponse(c, err, "Failed to fetch overdue bookings")
+	}
+
+	return utils.SuccessResponse(c, overdue, "Overdue bookings fetched successfully")
+}
+
+// GetBookingHistory lists the status transitions of a booking
+func GetBookingHistory(c *fiber.Ctx) error {
+	id := c.Params("id")
+	bookingID, err := uuid.Parse(id)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid booking ID", []string{"Invalid UUID format"})
+	}
+
+	var booking models.Booking
+	if result := database.DB.First(&booking, "id = ?", bookingID); result.Error != nil {
+		return utils.NotFoundResponse(c, "Booking not found")
+	}
+
+	// Check if the user is authorized to view this booking
 	userID := c.Locals("user_id").(string)
-	if booking.UserID.String() != userID {
-		return utils.ForbiddenResponse(c, "Not authorized to cancel this booking")
+	if booking.UserID.String() != userID && !hasPermission(c, middlewares.PermManageBookings) {
+		return utils.ForbiddenResponse(c, "Not authorized to view this booking")
 	}
 
-	// Check if booking is already cancelled or completed
-	if booking.Status != models.BookingStatusBooked {
-		return utils.ValidationErrorResponse(c, "Booking is already "+string(booking.Status), []string{"Invalid booking status"})
+	bookingService := services.NewBookingService()
+	history, err := bookingService.GetBookingHistory(bookingID)
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Failed to fetch booking history")
 	}
 
-	// Update booking status
-	booking.Status = models.BookingStatusCancelled
-	if result := database.DB.Save(&booking); result.Error != nil {
-		return utils.ServerErrorResponse(c, "Failed to cancel booking")
+	return utils.SuccessResponse(c, history, "Booking history fetched successfully")
+}
+
+// transitionBooking moves the booking identified by the :id param to the given status.
+// Staff-only transitions are guarded by their routes; the rest are allowed for the
+// booking's own user as well as users who can manage bookings.
+func transitionBooking(c *fiber.Ctx, to models.BookingStatus, message string) error {
+	id := c.Params("id")
+	bookingID, err := uuid.Parse(id)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid booking ID", []string{"Invalid UUID format"})
+	}
+
+	var req BookingTransitionRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return utils.ValidationErrorResponse(c, "Invalid request body", []string{
+				"Failed to parse request body: " + err.Error(),
+			})
+		}
+	}
+
+	actorID, err := uuid.Parse(c.Locals("user_id").(string))
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Invalid user ID")
+	}
+
+	var booking models.Booking
+	if result := database.DB.First(&booking, "id = ?", bookingID); result.Error != nil {
+		return utils.NotFoundResponse(c, "Booking not found")
 	}
 
-	// Make the car available again
-	carService := services.NewCarService()
-	if err := carService.UpdateCarAvailability(booking.CarID, true); err != nil {
-		return utils.ServerErrorResponse(c, "Failed to update car availability")
+	// Check if the user is authorized to change this booking
+	if booking.UserID != actorID && !hasPermission(c, middlewares.PermManageBookings) {
+		return utils.ForbiddenResponse(c, "Not authorized to update this booking")
 	}
 
-	return utils.SuccessResponse(c, booking, "Booking cancelled successfully")
+	bookingService := services.NewBookingService()
+	updated, err := bookingService.TransitionBooking(bookingID, to, actorID, req.Note)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrBookingNotFound):
+			return utils.NotFoundResponse(c, "Booking not found")
+		case errors.Is(err, services.ErrInvalidTransition):
+			return utils.ConflictResponse(c, "Booking cannot be moved from "+string(booking.Status)+" to "+string(to), []string{err.Error()})
+		default:
+			return utils.ServerErrorResponse(c, "Failed to update booking")
+		}
+	}
+
+	return utils.SuccessResponse(c, updated, message)
+}
+
+// bookingErrorResponse maps booking and pricing errors to API responses
+func bookingErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrCarNotFound):
+		return utils.NotFoundResponse(c, "Car not found")
+	case errors.Is(err, services.ErrCarUnavailable):
+		return utils.ConflictResponse(c, "Car is not available", nil)
+	case errors.Is(err, services.ErrBookingConflict):
+		return utils.ConflictResponse(c, "Car is already booked for this time period", nil)
+	case errors.Is(err, services.ErrCarInMaintenance):
+		return utils.ConflictResponse(c, "Car is under maintenance for this time period", nil)
+	case errors.Is(err, services.ErrRentalInfoMissing), errors.Is(err, pricing.ErrRentalInfoMissing):
+		return utils.ServerErrorResponse(c, "Car rental information is missing")
+	case errors.Is(err, pricing.ErrInvalidWindow):
+		return utils.ValidationErrorResponse(c, "End time must be after start time", []string{"Invalid time range"})
+	case errors.Is(err, pricing.ErrBelowMinimumDuration):
+		return utils.ValidationErrorResponse(c, "Rental duration is too short", []string{err.Error()})
+	case errors.Is(err, services.ErrPromotionNotFound):
+		return utils.ValidationErrorResponse(c, "Invalid promo code", []string{err.Error()})
+	case errors.Is(err, services.ErrPromotionNotApplicable):
+		return utils.ValidationErrorResponse(c, "Promo code cannot be applied", []string{err.Error()})
+	case errors.Is(err, services.ErrExchangeRateNotFound):
+		return utils.ValidationErrorResponse(c, "Unsupported currency", []string{err.Error()})
+	default:
+		return utils.ServerErrorResponse(c, fallback)
+	}
+}
+
+// hasPermission reports whether the authenticated session may use the permission
+func hasPermission(c *fiber.Ctx, permission middlewares.Permission) bool {
+	return middlewares.SessionHasPermission(c, permission)
 }
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"car-rental-backend/models"
+	"car-rental-backend/services"
+	"car-rental-backend/utils"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// CancellationTierRequest represents a single refund tier of a cancellation policy
+type CancellationTierRequest struct {
+	HoursBeforeStart int     `json:"hours_before_start" validate:"min=0"`
+	RefundPercent    float64 `json:"refund_percent" validate:"min=0,max=100"`
+}
+
+// CancellationPolicyRequest represents the request body for creating or replacing a cancellation policy
+type CancellationPolicyRequest struct {
+	Name  string                    `json:"name" validate:"required"`
+	CarID string                    `json:"car_id" validate:"omitempty,validUUID"` // empty for the global policy
+	Tiers []CancellationTierRequest `json:"tiers" validate:"required,min=1,dive"`
+}
+
+// GetCancellationPolicies lists all cancellation policies (admin only)
+func GetCancellationPolicies(c *fiber.Ctx) error {
+	policyService := services.NewCancellationPolicyService()
+	policies, err := policyService.GetPolicies()
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Failed to fetch cancellation policies")
+	}
+
+	return utils.SuccessResponse(c, policies, "Cancellation policies fetched successfully")
+}
+
+// CreateCancellationPolicy creates a cancellation policy for a car or the global policy (admin only)
+func CreateCancellationPolicy(c *fiber.Ctx) error {
+	policy := &models.CancellationPolicy{}
+	if errs := parseCancellationPolicyRequest(c, policy); errs != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", errs)
+	}
+
+	policyService := services.NewCancellationPolicyService()
+	if err := policyService.CreatePolicy(policy); err != nil {
+		if errors.Is(err, services.ErrCancellationPolicyExists) {
+			return utils.ConflictResponse(c, "A cancellation policy already exists for this car", []string{err.Error()})
+		}
+		return utils.HandleDatabaseError(c, err)
+	}
+
+	return utils.SuccessResponse(c, policy, "Cancellation policy created successfully")
+}
+
+// UpdateCancellationPolicy replaces a cancellation policy (admin only)
+func UpdateCancellationPolicy(c *fiber.Ctx) error {
+	policyID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid cancellation policy ID", []string{"Invalid UUID format"})
+	}
+
+	policyService := services.NewCancellationPolicyService()
+	policy, err := policyService.GetPolicyByID(policyID)
+	if err != nil {
+		return utils.NotFoundResponse(c, "Cancellation policy not found")
+	}
+
+	if errs := parseCancellationPolicyRequest(c, policy); errs != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", errs)
+	}
+
+	if err := policyService.UpdatePolicy(policy); err != nil {
+		if errors.Is(err, services.ErrCancellationPolicyExists) {
+			return utils.ConflictResponse(c, "A cancellation policy already exists for this car", []string{err.Error()})
+		}
+		return utils.HandleDatabaseError(c, err)
+	}
+
+	return utils.SuccessResponse(c, policy, "Cancellation policy updated successfully")
+}
+
+// DeleteCancellationPolicy deletes a cancellation policy (admin only)
+func DeleteCancellationPolicy(c *fiber.Ctx) error {
+	policyID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid cancellation policy ID", []string{"Invalid UUID format"})
+	}
+
+	policyService := services.NewCancellationPolicyService()
+	policy, err := policyService.GetPolicyByID(policyID)
+	if err != nil {
+		return utils.NotFoundResponse(c, "Cancellation policy not found")
+	}
+
+	if err := policyService.DeletePolicy(policy); err != nil {
+		return utils.HandleDatabaseError(c, err)
+	}
+
+	return utils.SuccessResponse(c, nil, "Cancellation policy deleted successfully")
+}
+
+// parseCancellationPolicyRequest parses and validates the request body into policy,
+// returning the validation errors if any
+func parseCancellationPolicyRequest(c *fiber.Ctx, policy *models.CancellationPolicy) []string {
+	var req CancellationPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return []string{"Failed to parse request body: " + err.Error()}
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return validationErrors
+	}
+
+	policy.Name = req.Name
+	policy.CarID = nil
+	if req.CarID != "" {
+		carID, _ := uuid.Parse(req.CarID)
+		if _, err := services.NewCarService().GetCarByID(carID); err != nil {
+			return []string{"Car not found"}
+		}
+		policy.CarID = &carID
+	}
+
+	seen := make(map[int]bool, len(req.Tiers))
+	policy.Tiers = make([]models.CancellationTier, 0, len(req.Tiers))
+	for _, tier := range req.Tiers {
+		if seen[tier.HoursBeforeStart] {
+			return []string{"Each tier must have a different hours_before_start"}
+		}
+		seen[tier.HoursBeforeStart] = true
+		policy.Tiers = append(policy.Tiers, models.CancellationTier{
+			HoursBeforeStart: tier.HoursBeforeStart,
+			RefundPercent:    tier.RefundPercent,
+		})
+	}
+
+	return nil
+}
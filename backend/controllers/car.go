@@ -2,8 +2,10 @@ package controllers
 
 import (
 	"car-rental-backend/models"
+	"car-rental-backend/money"
 	"car-rental-backend/services"
 	"car-rental-backend/utils"
+	"errors"
 	"fmt"
 	"time"
 
@@ -24,16 +26,20 @@ type CreateCarRequest struct {
 	Color           string `json:"color" validate:"required"`
 	SeatingCapacity int    `json:"seating_capacity" validate:"required,min=1,max=50"`
 	VehicleNumber   string `json:"vehicle_number" validate:"required"`
+	TaxJurisdiction string `json:"tax_jurisdiction" validate:"max=50"`
+
+	// Share of rental revenue paid to the owner, defaults to the owner's share
+	RevenueSharePercent *float64 `json:"revenue_share_percent,omitempty" validate:"omitempty,min=0,max=100"`
 
 	// Rental Info
 	RentalInfo struct {
-		RentalPricePerDay      float64 `json:"rental_price_per_day" validate:"required,min=0"`
-		RentalPricePerHour     float64 `json:"rental_price_per_hour" validate:"required,min=0"`
-		MinimumRentDuration    int     `json:"minimum_rent_duration" validate:"required,min=1"`
-		SecurityDeposit        float64 `json:"security_deposit" validate:"required,min=0"`
-		LateFeePerHour         float64 `json:"late_fee_per_hour" validate:"required,min=0"`
-		RentalExtendFeePerDay  float64 `json:"rental_extend_fee_per_day" validate:"required,min=0"`
-		RentalExtendFeePerHour float64 `json:"rental_extend_fee_per_hour" validate:"required,min=0"`
+		RentalPricePerDay      money.Money `json:"rental_price_per_day" validate:"positiveMoney"`
+		RentalPricePerHour     money.Money `json:"rental_price_per_hour" validate:"positiveMoney"`
+		MinimumRentDuration    int         `json:"minimum_rent_duration" validate:"required,min=1"`
+		SecurityDeposit        money.Money `json:"security_deposit" validate:"positiveMoney"`
+		LateFeePerHour         money.Money `json:"late_fee_per_hour" validate:"positiveMoney"`
+		RentalExtendFeePerDay  money.Money `json:"rental_extend_fee_per_day" validate:"positiveMoney"`
+		RentalExtendFeePerHour money.Money `json:"rental_extend_fee_per_hour" validate:"positiveMoney"`
 	} `json:"rental_info" validate:"required"`
 
 	// Media
@@ -55,6 +61,44 @@ type CreateCarRequest struct {
 	} `json:"owner" validate:"required"`
 }
 
+// UpdateRentalInfoRequest represents the rental information fields of a car update
+type UpdateRentalInfoRequest struct {
+	RentalPricePerDay      *money.Money `json:"rental_price_per_day,omitempty" validate:"omitempty,nonNegativeMoney"`
+	RentalPricePerHour     *money.Money `json:"rental_price_per_hour,omitempty" validate:"omitempty,nonNegativeMoney"`
+	MinimumRentDuration    *int         `json:"minimum_rent_duration,omitempty" validate:"omitempty,min=1"`
+	SecurityDeposit        *money.Money `json:"security_deposit,omitempty" validate:"omitempty,nonNegativeMoney"`
+	LateFeePerHour         *money.Money `json:"late_fee_per_hour,omitempty" validate:"omitempty,nonNegativeMoney"`
+	RentalExtendFeePerDay  *money.Money `json:"rental_extend_fee_per_day,omitempty" validate:"omitempty,nonNegativeMoney"`
+	RentalExtendFeePerHour *money.Money `json:"rental_extend_fee_per_hour,omitempty" validate:"omitempty,nonNegativeMoney"`
+}
+
+// Updates returns the rental info columns to update
+func (r *UpdateRentalInfoRequest) Updates() map[string]interface{} {
+	updates := make(map[string]interface{})
+	if r.RentalPricePerDay != nil {
+		updates["rental_price_per_day"] = *r.RentalPricePerDay
+	}
+	if r.RentalPricePerHour != nil {
+		updates["rental_price_per_hour"] = *r.RentalPricePerHour
+	}
+	if r.MinimumRentDuration != nil {
+		updates["minimum_rent_duration"] = *r.MinimumRentDuration
+	}
+	if r.SecurityDeposit != nil {
+		updates["security_deposit"] = *r.SecurityDeposit
+	}
+	if r.LateFeePerHour != nil {
+		updates["late_fee_per_hour"] = *r.LateFeePerHour
+	}
+	if r.RentalExtendFeePerDay != nil {
+		updates["rental_extend_fee_per_day"] = *r.RentalExtendFeePerDay
+	}
+	if r.RentalExtendFeePerHour != nil {
+		updates["rental_extend_fee_per_hour"] = *r.RentalExtendFeePerHour
+	}
+	return updates
+}
+
 // UpdateCarRequest represents the request body for updating a car
 type UpdateCarRequest struct {
 	// Basic Car Details
@@ -68,17 +112,12 @@ type UpdateCarRequest struct {
 	Color           *string `json:"color,omitempty"`
 	SeatingCapacity *int    `json:"seating_capacity,omitempty"`
 	VehicleNumber   *string `json:"vehicle_number,omitempty"`
+	TaxJurisdiction *string `json:"tax_jurisdiction,omitempty" validate:"omitempty,max=50"`
+
+	RevenueSharePercent *float64 `json:"revenue_share_percent,omitempty" validate:"omitempty,min=0,max=100"`
 
 	// Rental Info
-	RentalInfo *struct {
-		RentalPricePerDay      *float64 `json:"rental_price_per_day,omitempty"`
-		RentalPricePerHour     *float64 `json:"rental_price_per_hour,omitempty"`
-		MinimumRentDuration    *int     `json:"minimum_rent_duration,omitempty"`
-		SecurityDeposit        *float64 `json:"security_deposit,omitempty"`
-		LateFeePerHour         *float64 `json:"late_fee_per_hour,omitempty"`
-		RentalExtendFeePerDay  *float64 `json:"rental_extend_fee_per_day,omitempty"`
-		RentalExtendFeePerHour *float64 `json:"rental_extend_fee_per_hour,omitempty"`
-	} `json:"rental_info,omitempty"`
+	RentalInfo *UpdateRentalInfoRequest `json:"rental_info,omitempty"`
 
 	// Media
 	Media *struct {
@@ -139,7 +178,10 @@ func CreateCar(c *fiber.Ctx) error {
 		Color:           req.Color,
 		SeatingCapacity: req.SeatingCapacity,
 		VehicleNumber:   req.VehicleNumber,
+		TaxJurisdiction: req.TaxJurisdiction,
 		OwnerID:         req.Owner.OwnerID,
+
+		RevenueSharePercent: req.RevenueSharePercent,
 	}
 
 	// Verify that the owner exists
@@ -237,6 +279,11 @@ func GetCars(c *fiber.Ctx) error {
 		"body_type":      utils.GetStringParam(c, "body_type"),
 	}
 
+	exchangeRate, err := requestedExchangeRate(c)
+	if err != nil {
+		return exchangeRateErrorResponse(c, err)
+	}
+
 	carService := services.NewCarService()
 
 	// Get paginated cars with filters
@@ -252,7 +299,7 @@ func GetCars(c *fiber.Ctx) error {
 	var responses []models.CarResponse
 	if cars != nil {
 		for _, car := range cars {
-			responses = append(responses, car.ToCarResponse())
+			responses = append(responses, toCarResponse(&car, exchangeRate))
 		}
 	}
 
@@ -266,6 +313,11 @@ func GetCar(c *fiber.Ctx) error {
 		return utils.ValidationErrorResponse(c, "Invalid car ID", []string{"Invalid UUID format"})
 	}
 
+	exchangeRate, err := requestedExchangeRate(c)
+	if err != nil {
+		return exchangeRateErrorResponse(c, err)
+	}
+
 	carService := services.NewCarService()
 	car, err := carService.GetCarByID(carID)
 	if err != nil {
@@ -273,7 +325,7 @@ func GetCar(c *fiber.Ctx) error {
 	}
 
 	// Convert to response format
-	response := car.ToCarResponse()
+	response := toCarResponse(car, exchangeRate)
 
 	return utils.SuccessResponse(c, response, "Car fetched successfully")
 }
@@ -307,7 +359,7 @@ func UpdateCar(c *fiber.Ctx) error {
 
 	// Prepare update maps for different entities
 	carUpdates := make(map[string]interface{})
-	rentalInfoUpdates := make(map[string]interface{})
+	var rentalInfoUpdates map[string]interface{}
 	statusUpdates := make(map[string]interface{})
 
 	// Basic car details
@@ -341,30 +393,16 @@ func UpdateCar(c *fiber.Ctx) error {
 	if req.VehicleNumber != nil {
 		carUpdates["vehicle_number"] = *req.VehicleNumber
 	}
+	if req.TaxJurisdiction != nil {
+		carUpdates["tax_jurisdiction"] = *req.TaxJurisdiction
+	}
+	if req.RevenueSharePercent != nil {
+		carUpdates["revenue_share_percent"] = *req.RevenueSharePercent
+	}
 
 	// Rental info
 	if req.RentalInfo != nil {
-		if req.RentalInfo.RentalPricePerDay != nil {
-			rentalInfoUpdates["rental_price_per_day"] = *req.RentalInfo.RentalPricePerDay
-		}
-		if req.RentalInfo.RentalPricePerHour != nil {
-			rentalInfoUpdates["rental_price_per_hour"] = *req.RentalInfo.RentalPricePerHour
-		}
-		if req.RentalInfo.MinimumRentDuration != nil {
-			rentalInfoUpdates["minimum_rent_duration"] = *req.RentalInfo.MinimumRentDuration
-		}
-		if req.RentalInfo.SecurityDeposit != nil {
-			rentalInfoUpdates["security_deposit"] = *req.RentalInfo.SecurityDeposit
-		}
-		if req.RentalInfo.LateFeePerHour != nil {
-			rentalInfoUpdates["late_fee_per_hour"] = *req.RentalInfo.LateFeePerHour
-		}
-		if req.RentalInfo.RentalExtendFeePerDay != nil {
-			rentalInfoUpdates["rental_extend_fee_per_day"] = *req.RentalInfo.RentalExtendFeePerDay
-		}
-		if req.RentalInfo.RentalExtendFeePerHour != nil {
-			rentalInfoUpdates["rental_extend_fee_per_hour"] = *req.RentalInfo.RentalExtendFeePerHour
-		}
+		rentalInfoUpdates = req.RentalInfo.Updates()
 	}
 
 	// Media handling
@@ -483,93 +521,169 @@ func GetAvailableCars(c *fiber.Ctx) error {
 		"body_type":      utils.GetStringParam(c, "body_type"),
 	}
 
-	carService := services.NewCarService()
+	if startTime != "" && endTime != "" {
+		// Parse time range for booking availability check
+		start, err := time.Parse(time.RFC3339, startTime)
+		if err != nil {
+			return utils.ValidationErrorResponse(c, "Invalid start time format", []string{"Start time must be in RFC3339 format"})
+		}
+
+		end, err := time.Parse(time.RFC3339, endTime)
+		if err != nil {
+			return utils.ValidationErrorResponse(c, "Invalid end time format", []string{"End time must be in RFC3339 format"})
+		}
 
-	if startTime == "" || endTime == "" {
-		// Add the isAvailable filter
+		if !end.After(start) {
+			return utils.ValidationErrorResponse(c, "End time must be after start time", []string{"Invalid time range"})
+		}
+
+		filters["available_from"] = start
+		filters["available_to"] = end
+	} else {
+		// Without a window, check availability right now
 		filters["is_available"] = true
+	}
 
-		// Get paginated cars with filters
-		cars, totalItems, err := carService.GetAllCarsPaginated(page, pageSize, filters)
-		if err != nil {
-			return utils.ServerErrorResponse(c, "Failed to fetch available cars: "+err.Error())
+	exchangeRate, err := requestedExchangeRate(c)
+	if err != nil {
+		return exchangeRateErrorResponse(c, err)
+	}
+
+	carService := services.NewCarService()
+
+	// Get paginated cars with filters
+	cars, totalItems, err := carService.GetAllCarsPaginated(page, pageSize, filters)
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Failed to fetch available cars: "+err.Error())
+	}
+
+	// Create pagination metadata
+	pagination := models.NewPagination(totalItems, page, pageSize)
+
+	// Convert cars to response format - handle possible nil for safety
+	var responses []models.CarResponse
+	if cars != nil {
+		for _, car := range cars {
+			responses = append(responses, toCarResponse(&car, exchangeRate))
 		}
+	}
 
-		// Create pagination metadata
-		pagination := models.NewPagination(totalItems, page, pageSize)
+	return utils.PaginatedSuccessResponse(c, responses, pagination, "Available cars fetched successfully")
+}
 
-		// Convert cars to response format - handle possible nil for safety
-		var responses []models.CarResponse
-		if cars != nil {
-			for _, car := range cars {
-				responses = append(responses, car.ToCarResponse())
-			}
+// MaintenanceBlockRequest represents the request body for blocking a car for maintenance
+type MaintenanceBlockRequest struct {
+	StartTime time.Time `json:"start_time" validate:"required"`
+	EndTime   time.Time `json:"end_time" validate:"required"`
+	Reason    string    `json:"reason" validate:"required"`
+}
+
+// GetCarCalendar lists the bookings and maintenance blocks of a car in a time window
+func GetCarCalendar(c *fiber.Ctx) error {
+	id := c.Params("id")
+	carID, err := uuid.Parse(id)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid car ID", []string{"Invalid UUID format"})
+	}
+
+	// Default to the next 30 days
+	from := time.Now()
+	to := from.AddDate(0, 0, 30)
+
+	if fromParam := c.Query("from"); fromParam != "" {
+		if from, err = time.Parse(time.RFC3339, fromParam); err != nil {
+			return utils.ValidationErrorResponse(c, "Invalid from time format", []string{"From time must be in RFC3339 format"})
 		}
+	}
+	if toParam := c.Query("to"); toParam != "" {
+		if to, err = time.Parse(time.RFC3339, toParam); err != nil {
+			return utils.ValidationErrorResponse(c, "Invalid to time format", []string{"To time must be in RFC3339 format"})
+		}
+	}
+	if !to.After(from) {
+		return utils.ValidationErrorResponse(c, "To time must be after from time", []string{"Invalid time range"})
+	}
 
-		return utils.PaginatedSuccessResponse(c, responses, pagination, "Available cars fetched successfully")
+	carService := services.NewCarService()
+	if _, err := carService.GetCarByID(carID); err != nil {
+		return utils.NotFoundResponse(c, "Car not found")
 	}
 
-	// Parse time range for booking availability check
-	start, err := time.Parse(time.RFC3339, startTime)
+	entries, err := carService.GetCarCalendar(carID, from, to)
 	if err != nil {
-		return utils.ValidationErrorResponse(c, "Invalid start time format", []string{"Start time must be in RFC3339 format"})
+		return utils.ServerErrorResponse(c, "Failed to fetch car calendar: "+err.Error())
 	}
 
-	end, err := time.Parse(time.RFC3339, endTime)
+	return utils.SuccessResponse(c, entries, "Car calendar fetched successfully")
+}
+
+// CreateMaintenanceBlock blocks a car for maintenance during a time window
+func CreateMaintenanceBlock(c *fiber.Ctx) error {
+	id := c.Params("id")
+	carID, err := uuid.Parse(id)
 	if err != nil {
-		return utils.ValidationErrorResponse(c, "Invalid end time format", []string{"End time must be in RFC3339 format"})
+		return utils.ValidationErrorResponse(c, "Invalid car ID", []string{"Invalid UUID format"})
 	}
 
-	if end.Before(start) {
-		return utils.ValidationErrorResponse(c, "End time must be after start time", []string{"Invalid time range"})
+	var req MaintenanceBlockRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request body", []string{
+			"Failed to parse request body: " + err.Error(),
+		})
 	}
 
-	// Add isAvailable filter and load bookings for time range check
-	filters["is_available"] = true
-	filters["load_bookings"] = true
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return utils.ValidationErrorResponse(c, "Validation failed", validationErrors)
+	}
 
-	// Get available cars with filtering and pagination
-	cars, _, err := carService.GetAllCarsPaginated(page, pageSize, filters)
-	if err != nil {
-		return utils.ServerErrorResponse(c, "Failed to fetch available cars: "+err.Error())
+	if !req.EndTime.After(req.StartTime) {
+		return utils.ValidationErrorResponse(c, "End time must be after start time", []string{"Invalid time range"})
 	}
 
-	// Safety check - ensure cars isn't nil before processing
-	if cars == nil {
-		cars = []models.Car{} // Use empty slice instead of nil
+	block := &models.CarMaintenanceBlock{
+		CarID:     carID,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Reason:    req.Reason,
 	}
 
-	// Filter out cars that have bookings in the requested time range
-	var availableCars []models.Car
-	for _, car := range cars {
-		carIsAvailable := true
-		// Safety check - ensure Bookings isn't nil
-		if car.Bookings != nil {
-			for _, booking := range car.Bookings {
-				// Check if booking overlaps with requested time range
-				if booking.Status == models.BookingStatusBooked &&
-					!(booking.EndTime.Before(start) || booking.StartTime.After(end)) {
-					carIsAvailable = false
-					break
-				}
-			}
-		}
-		if carIsAvailable {
-			availableCars = append(availableCars, car)
+	carService := services.NewCarService()
+	if err := carService.AddMaintenanceBlock(block); err != nil {
+		switch {
+		case errors.Is(err, services.ErrCarNotFound):
+			return utils.NotFoundResponse(c, "Car not found")
+		case errors.Is(err, services.ErrBookingConflict):
+			return utils.ConflictResponse(c, "Car has active bookings in this time period", []string{
+				"Cancel or move the overlapping bookings before blocking the car",
+			})
+		default:
+			return utils.ServerErrorResponse(c, "Failed to create maintenance block: "+err.Error())
 		}
 	}
 
-	// Adjust total items count after filtering
-	filteredTotalItems := int64(len(availableCars))
+	return utils.SuccessResponse(c, block, "Maintenance block created successfully")
+}
 
-	// Create pagination metadata based on filtered results
-	pagination := models.NewPagination(filteredTotalItems, page, pageSize)
+// DeleteMaintenanceBlock removes a maintenance block from a car
+func DeleteMaintenanceBlock(c *fiber.Ctx) error {
+	carID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid car ID", []string{"Invalid UUID format"})
+	}
 
-	// Convert cars to response format
-	var responses []models.CarResponse
-	for _, car := range availableCars {
-		responses = append(responses, car.ToCarResponse())
+	blockID, err := uuid.Parse(c.Params("blockId"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid maintenance block ID", []string{"Invalid UUID format"})
 	}
 
-	return utils.PaginatedSuccessResponse(c, responses, pagination, "Available cars fetched successfully")
+	carService := services.NewCarService()
+	if err := carService.RemoveMaintenanceBlock(carID, blockID); err != nil {
+		if errors.Is(err, services.ErrMaintenanceBlockNotFound) {
+			return utils.NotFoundResponse(c, "Maintenance block not found")
+		}
+		return utils.ServerErrorResponse(c, "Failed to delete maintenance block: "+err.Error())
+	}
+
+	return utils.SuccessResponse(c, nil, "Maintenance block deleted successfully")
 }
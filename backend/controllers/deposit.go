@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"car-rental-backend/database"
+	"car-rental-backend/middlewares"
+	"car-rental-backend/models"
+	"car-rental-backend/money"
+	"car-rental-backend/services"
+	"car-rental-backend/utils"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// CaptureDepositRequest represents the request body for capturing part of a deposit
+type CaptureDepositRequest struct {
+	Amount money.Money `json:"amount" validate:"positiveMoney"`
+	Reason string      `json:"reason" validate:"required"`
+}
+
+// ReleaseDepositRequest represents the request body for releasing a deposit
+type ReleaseDepositRequest struct {
+	Reason string `json:"reason"`
+}
+
+// GetDepositStatement shows what was held, captured and refunded for a booking
+func GetDepositStatement(c *fiber.Ctx) error {
+	id := c.Params("id")
+	bookingID, err := uuid.Parse(id)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid booking ID", []string{"Invalid UUID format"})
+	}
+
+	var booking models.Booking
+	if result := database.DB.First(&booking, "id = ?", bookingID); result.Error != nil {
+		return utils.NotFoundResponse(c, "Booking not found")
+	}
+
+	// Check if the user is authorized to view this booking
+	userID := c.Locals("user_id").(string)
+	if booking.UserID.String() != userID && !hasPermission(c, middlewares.PermManageBookings) {
+		return utils.ForbiddenResponse(c, "Not authorized to view this booking")
+	}
+
+	depositService := services.NewDepositService()
+	statement, err := depositService.GetStatement(bookingID)
+	if err != nil {
+		return depositErrorResponse(c, err, "Failed to fetch deposit statement")
+	}
+
+	return utils.SuccessResponse(c, statement, "Deposit statement fetched successfully")
+}
+
+// CaptureDeposit keeps part of a returned booking's deposit (admin only)
+func CaptureDeposit(c *fiber.Ctx) error {
+	id := c.Params("id")
+	bookingID, err := uuid.Parse(id)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid booking ID", []string{"Invalid UUID format"})
+	}
+
+	var req CaptureDepositRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request body", []string{
+			"Failed to parse request body: " + err.Error(),
+		})
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return utils.ValidationErrorResponse(c, "Validation failed", validationErrors)
+	}
+
+	actorID, err := uuid.Parse(c.Locals("user_id").(string))
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Invalid user ID")
+	}
+
+	depositService := services.NewDepositService()
+	deposit, err := depositService.CaptureDeposit(bookingID, req.Amount, req.Reason, actorID)
+	if err != nil {
+		return depositErrorResponse(c, err, "Failed to capture deposit")
+	}
+
+	return utils.SuccessResponse(c, deposit, "Deposit captured successfully")
+}
+
+// ReleaseDeposit refunds the remaining balance of a returned booking's deposit (admin only)
+func ReleaseDeposit(c *fiber.Ctx) error {
+	id := c.Params("id")
+	bookingID, err := uuid.Parse(id)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid booking ID", []string{"Invalid UUID format"})
+	}
+
+	var req ReleaseDepositRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return utils.ValidationErrorResponse(c, "Invalid request body", []string{
+				"Failed to parse request body: " + err.Error(),
+			})
+		}
+	}
+
+	actorID, err := uuid.Parse(c.Locals("user_id").(string))
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Invalid user ID")
+	}
+
+	depositService := services.NewDepositService()
+	deposit, err := depositService.ReleaseDeposit(bookingID, req.Reason, actorID)
+	if err != nil {
+		return depositErrorResponse(c, err, "Failed to release deposit")
+	}
+
+	return utils.SuccessResponse(c, deposit, "Deposit released successfully")
+}
+
+// depositErrorResponse maps deposit errors to API responses
+func depositErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrBookingNotFound):
+		return utils.NotFoundResponse(c, "Booking not found")
+	case errors.Is(err, services.ErrDepositNotFound):
+		return utils.NotFoundResponse(c, "No deposit is held for this booking")
+	case errors.Is(err, services.ErrDepositSettled):
+		return utils.ConflictResponse(c, "Deposit has already been settled", nil)
+	case errors.Is(err, services.ErrDepositNotSettleable):
+		return utils.ConflictResponse(c, "Deposit can only be settled after the car is returned and before the booking is completed", nil)
+	case errors.Is(err, money.ErrCurrencyMismatch):
+		return utils.ValidationErrorResponse(c, "Amount is in a different currency", []string{err.Error()})
+	case errors.Is(err, services.ErrCaptureExceedsBalance):
+		return utils.ValidationErrorResponse(c, "Capture amount exceeds the deposit balance", []string{err.Error()})
+	default:
+		return utils.ServerErrorResponse(c, fallback)
+	}
+}
@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"bytes"
+	"car-rental-backend/models"
+	"car-rental-backend/services"
+	"car-rental-backend/utils"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ExchangeRateRequest is one row of an uploaded exchange rate table
+type ExchangeRateRequest struct {
+	Currency string  `json:"currency" validate:"required,len=3"`
+	Rate     float64 `json:"rate" validate:"gt=0"`
+}
+
+// ExchangeRatesRequest represents the request body for uploading exchange rates as JSON
+type ExchangeRatesRequest struct {
+	Rates []ExchangeRateRequest `json:"rates" validate:"dive"`
+}
+
+// GetExchangeRates lists the exchange rates prices can be shown in
+func GetExchangeRates(c *fiber.Ctx) error {
+	exchangeRateService := services.NewExchangeRateService()
+	rates, err := exchangeRateService.GetExchangeRates()
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Failed to fetch exchange rates")
+	}
+
+	return utils.SuccessResponse(c, rates, "Exchange rates fetched successfully")
+}
+
+// UploadExchangeRates replaces the exchange rate table (admin only). The body
+// is either JSON or, with a text/csv content type, CSV rows of currency,rate.
+func UploadExchangeRates(c *fiber.Ctx) error {
+	var req ExchangeRatesRequest
+	if strings.HasPrefix(c.Get(fiber.HeaderContentType), "text/csv") {
+		rates, err := parseExchangeRatesCSV(c.Body())
+		if err != nil {
+			return utils.ValidationErrorResponse(c, "Invalid CSV", []string{err.Error()})
+		}
+		req.Rates = rates
+	} else if err := c.BodyParser(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request body", []string{
+			"Failed to parse request body: " + err.Error(),
+		})
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return utils.ValidationErrorResponse(c, "Validation failed", validationErrors)
+	}
+
+	rates := make([]models.ExchangeRate, 0, len(req.Rates))
+	for _, rate := range req.Rates {
+		rates = append(rates, models.ExchangeRate{Currency: rate.Currency, Rate: rate.Rate})
+	}
+
+	exchangeRateService := services.NewExchangeRateService()
+	saved, err := exchangeRateService.ReplaceExchangeRates(rates)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidExchangeRates) {
+			return utils.ValidationErrorResponse(c, "Invalid exchange rates", []string{err.Error()})
+		}
+		return utils.HandleDatabaseError(c, err)
+	}
+
+	return utils.SuccessResponse(c, saved, "Exchange rates uploaded successfully")
+}
+
+// parseExchangeRatesCSV reads rows of currency,rate, skipping an optional header row
+func parseExchangeRatesCSV(body []byte) ([]ExchangeRateRequest, error) {
+	reader := csv.NewReader(bytes.NewReader(body))
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	var rates []ExchangeRateRequest
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err != nil {
+			if line == 1 {
+				// Header row
+				continue
+			}
+			return nil, fmt.Errorf("line %d: invalid rate %q", line, record[1])
+		}
+		rates = append(rates, ExchangeRateRequest{Currency: strings.TrimSpace(record[0]), Rate: rate})
+	}
+
+	return rates, nil
+}
+
+// requestedCurrency returns the currency the client wants prices in, from the
+// currency query parameter or else the Accept-Currency header
+func requestedCurrency(c *fiber.Ctx) string {
+	if currency := c.Query("currency"); currency != "" {
+		return currency
+	}
+	return c.Get("Accept-Currency")
+}
+
+// requestedExchangeRate returns the exchange rate of the requested currency,
+// or nil if prices are to be shown in the stored currency
+func requestedExchangeRate(c *fiber.Ctx) (*models.ExchangeRate, error) {
+	exchangeRateService := services.NewExchangeRateService()
+	return exchangeRateService.GetExchangeRate(requestedCurrency(c))
+}
+
+// exchangeRateErrorResponse responds to a failure to look up the requested currency
+func exchangeRateErrorResponse(c *fiber.Ctx, err error) error {
+	if errors.Is(err, services.ErrExchangeRateNotFound) {
+		return utils.ValidationErrorResponse(c, "Unsupported currency", []string{err.Error()})
+	}
+	return utils.ServerErrorResponse(c, "Failed to load exchange rate")
+}
+
+// toCarResponse converts a car to its response format, with prices shown in
+// the currency of rate if one is given
+func toCarResponse(car *models.Car, rate *models.ExchangeRate) models.CarResponse {
+	response := car.ToCarResponse()
+	if rate != nil {
+		response.ConvertPrices(rate)
+	}
+	return response
+}
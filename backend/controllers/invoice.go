@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"car-rental-backend/database"
+	"car-rental-backend/invoicing"
+	"car-rental-backend/middlewares"
+	"car-rental-backend/models"
+	"car-rental-backend/services"
+	"car-rental-backend/utils"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetBookingInvoice returns the invoice of a completed booking as JSON, or as a
+// PDF when requested with ?format=pdf or an Accept: application/pdf header
+func GetBookingInvoice(c *fiber.Ctx) error {
+	id := c.Params("id")
+	bookingID, err := uuid.Parse(id)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid booking ID", []string{"Invalid UUID format"})
+	}
+
+	var booking models.Booking
+	if result := database.DB.First(&booking, "id = ?", bookingID); result.Error != nil {
+		return utils.NotFoundResponse(c, "Booking not found")
+	}
+
+	// Check if the user is authorized to view this booking
+	userID := c.Locals("user_id").(string)
+	if booking.UserID.String() != userID && !hasPermission(c, middlewares.PermManageBookings) {
+		return utils.ForbiddenResponse(c, "Not authorized to view this booking")
+	}
+
+	invoiceService := services.NewInvoiceService()
+	invoice, err := invoiceService.GetInvoice(bookingID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrBookingNotFound):
+			return utils.NotFoundResponse(c, "Booking not found")
+		case errors.Is(err, services.ErrInvoiceNotIssued):
+			return utils.NotFoundResponse(c, "Invoice is issued when the booking is completed")
+		default:
+			return utils.ServerErrorResponse(c, "Failed to fetch invoice")
+		}
+	}
+
+	if c.Query("format") == "pdf" || c.Accepts(fiber.MIMEApplicationJSON, "application/pdf") == "application/pdf" {
+		c.Set(fiber.HeaderContentType, "application/pdf")
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+invoicing.Filename(invoice)+`"`)
+		return c.Send(invoicing.RenderPDF(invoice))
+	}
+
+	return utils.SuccessResponse(c, invoice, "Invoice fetched successfully")
+}
@@ -0,0 +1,21 @@
+package controllers
+
+import (
+	"car-rental-backend/tokens"
+	"car-rental-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetJWKS publishes the public keys access tokens can be verified with, so other
+// services can validate our tokens. The body is a plain JWK Set (RFC 7517)
+// rather than the usual response envelope.
+func GetJWKS(c *fiber.Ctx) error {
+	keyManager := tokens.GetKeyManager()
+	if keyManager == nil {
+		return utils.ServerErrorResponse(c, "Token signing is not configured")
+	}
+
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(keyManager.JWKS())
+}
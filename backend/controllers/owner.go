@@ -13,6 +13,9 @@ import (
 type OwnerRequest struct {
 	Name        string `json:"name" validate:"required"`
 	ContactInfo string `json:"contact_info" validate:"required"`
+
+	// Share of rental revenue paid to the owner, defaults to 80 on creation
+	RevenueSharePercent *float64 `json:"revenue_share_percent,omitempty" validate:"omitempty,min=0,max=100"`
 }
 
 // GetOwners retrieves all owners
@@ -58,8 +61,12 @@ func CreateOwner(c *fiber.Ctx) error {
 
 	// Create owner
 	owner := models.Owner{
-		Name:        req.Name,
-		ContactInfo: req.ContactInfo,
+		Name:                req.Name,
+		ContactInfo:         req.ContactInfo,
+		RevenueSharePercent: models.DefaultRevenueSharePercent,
+	}
+	if req.RevenueSharePercent != nil {
+		owner.RevenueSharePercent = *req.RevenueSharePercent
 	}
 
 	if result := database.DB.Create(&owner); result.Error != nil {
@@ -98,6 +105,9 @@ func UpdateOwner(c *fiber.Ctx) error {
 	// Update owner
 	owner.Name = req.Name
 	owner.ContactInfo = req.ContactInfo
+	if req.RevenueSharePercent != nil {
+		owner.RevenueSharePercent = *req.RevenueSharePercent
+	}
 
 	if result := database.DB.Save(&owner); result.Error != nil {
 		return utils.HandleDatabaseError(c, result.Error)
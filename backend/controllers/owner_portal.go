@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"car-rental-backend/models"
+	"car-rental-backend/services"
+	"car-rental-backend/utils"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// The owner portal handlers run behind middlewares.OwnerMiddleware, which sets
+// the "owner_id" local, and the car handlers also behind
+// middlewares.OwnerCarMiddleware, which checks the car in the id param belongs
+// to that owner.
+
+// LinkOwnerUserRequest represents the request body for linking a login to an owner
+type LinkOwnerUserRequest struct {
+	UserID uuid.UUID `json:"user_id" validate:"required"`
+}
+
+// CarAvailabilityRequest represents the request body for taking a car in or out of service
+type CarAvailabilityRequest struct {
+	IsAvailable *bool `json:"is_available" validate:"required"`
+}
+
+// LinkOwnerUser gives a user the owner role for an owner (admin only)
+func LinkOwnerUser(c *fiber.Ctx) error {
+	ownerID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid owner ID", []string{"Invalid UUID format"})
+	}
+
+	var req LinkOwnerUserRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request body", []string{
+			"Failed to parse request body: " + err.Error(),
+		})
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return utils.ValidationErrorResponse(c, "Validation failed", validationErrors)
+	}
+
+	ownerService := services.NewOwnerService()
+	user, err := ownerService.LinkUser(ownerID, req.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrOwnerNotFound):
+			return utils.NotFoundResponse(c, "Owner not found")
+		case errors.Is(err, services.ErrUserNotFound):
+			return utils.NotFoundResponse(c, "User not found")
+		default:
+			return utils.ServerErrorResponse(c, "Failed to link user to owner")
+		}
+	}
+
+	response := models.UserResponse{
+		ID:        user.ID.String(),
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		OwnerID:   user.OwnerID,
+		CreatedAt: user.CreatedAt,
+	}
+
+	return utils.SuccessResponse(c, response, "User linked to owner successfully")
+}
+
+// GetMyCars lists the authenticated owner's cars
+func GetMyCars(c *fiber.Ctx) error {
+	ownerService := services.NewOwnerService()
+	cars, err := ownerService.GetOwnerCars(currentOwnerID(c))
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Failed to fetch cars")
+	}
+
+	responses := make([]models.CarResponse, 0, len(cars))
+	for _, car := range cars {
+		responses = append(responses, car.ToCarResponse())
+	}
+
+	return utils.SuccessResponse(c, responses, "Cars fetched successfully")
+}
+
+// UpdateMyCarRentalInfo updates the prices and rental terms of one of the owner's cars
+func UpdateMyCarRentalInfo(c *fiber.Ctx) error {
+	carID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid car ID", []string{"Invalid UUID format"})
+	}
+
+	var req UpdateRentalInfoRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request body", []string{
+			"Failed to parse request body: " + err.Error(),
+		})
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return utils.ValidationErrorResponse(c, "Validation failed", validationErrors)
+	}
+
+	carService := services.NewCarService()
+	car, err := carService.UpdateCar(carID, nil, req.Updates(), nil)
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Failed to update rental info: "+err.Error())
+	}
+
+	return utils.SuccessResponse(c, car.ToCarResponse(), "Rental info updated successfully")
+}
+
+// UpdateMyCarAvailability takes one of the owner's cars in or out of service
+func UpdateMyCarAvailability(c *fiber.Ctx) error {
+	carID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid car ID", []string{"Invalid UUID format"})
+	}
+
+	var req CarAvailabilityRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request body", []string{
+			"Failed to parse request body: " + err.Error(),
+		})
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return utils.ValidationErrorResponse(c, "Validation failed", validationErrors)
+	}
+
+	carService := services.NewCarService()
+	if err := carService.UpdateCarAvailability(carID, *req.IsAvailable); err != nil {
+		return utils.ServerErrorResponse(c, "Failed to update availability: "+err.Error())
+	}
+
+	car, err := carService.GetCarByID(carID)
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Failed to fetch car: "+err.Error())
+	}
+
+	return utils.SuccessResponse(c, car.ToCarResponse(), "Availability updated successfully")
+}
+
+// GetMyBookings lists the bookings of the owner's cars
+func GetMyBookings(c *fiber.Ctx) error {
+	page, pageSize := utils.GetPaginationParams(c)
+
+	filters := map[string]string{
+		"status": c.Query("status"),
+		"car_id": c.Query("car_id"),
+	}
+
+	ownerService := services.NewOwnerService()
+	bookings, total, err := ownerService.GetOwnerBookings(currentOwnerID(c), page, pageSize, filters)
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Failed to fetch bookings")
+	}
+
+	pagination := models.NewPagination(total, page, pageSize)
+
+	return utils.PaginatedSuccessResponse(c, bookings, pagination, "Bookings fetched successfully")
+}
+
+// GetMyEarnings summarises the owner's payouts and unsettled earnings
+func GetMyEarnings(c *fiber.Ctx) error {
+	payoutService := services.NewPayoutService()
+	earnings, err := payoutService.GetOwnerEarnings(currentOwnerID(c))
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Failed to fetch earnings")
+	}
+
+	return utils.SuccessResponse(c, earnings, "Earnings fetched successfully")
+}
+
+// currentOwnerID returns the owner set by middlewares.OwnerMiddleware
+func currentOwnerID(c *fiber.Ctx) uuid.UUID {
+	ownerID, _ := c.Locals("owner_id").(uuid.UUID)
+	return ownerID
+}
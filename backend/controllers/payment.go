@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"car-rental-backend/database"
+	"car-rental-backend/middlewares"
+	"car-rental-backend/models"
+	"car-rental-backend/money"
+	"car-rental-backend/payments"
+	"car-rental-backend/services"
+	"car-rental-backend/utils"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// PaymentSignatureHeader carries the provider's signature of a webhook payload
+const PaymentSignatureHeader = "X-Payment-Signature"
+
+// CreatePaymentRequest represents the request body for paying for a booking
+type CreatePaymentRequest struct {
+	PaymentMethod string `json:"payment_method" validate:"required"`
+}
+
+// PaymentAmountRequest represents the request body for capturing or refunding a payment.
+// An amount of zero captures or refunds everything available.
+type PaymentAmountRequest struct {
+	Amount money.Money `json:"amount" validate:"nonNegativeMoney"`
+}
+
+// CreateBookingPayment authorizes the outstanding balance of a booking
+func CreateBookingPayment(c *fiber.Ctx) error {
+	id := c.Params("id")
+	bookingID, err := uuid.Parse(id)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid booking ID", []string{"Invalid UUID format"})
+	}
+
+	var req CreatePaymentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request body", []string{
+			"Failed to parse request body: " + err.Error(),
+		})
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return utils.ValidationErrorResponse(c, "Validation failed", validationErrors)
+	}
+
+	userID, err := uuid.Parse(c.Locals("user_id").(string))
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Invalid user ID")
+	}
+
+	var booking models.Booking
+	if result := database.DB.First(&booking, "id = ?", bookingID); result.Error != nil {
+		return utils.NotFoundResponse(c, "Booking not found")
+	}
+
+	// Only the booking's own user can pay for it
+	if booking.UserID != userID {
+		return utils.ForbiddenResponse(c, "Not authorized to pay for this booking")
+	}
+
+	paymentService := services.NewPaymentService()
+	payment, err := paymentService.AuthorizeBookingPayment(bookingID, userID, req.PaymentMethod)
+	if err != nil {
+		if errors.Is(err, payments.ErrPaymentDeclined) {
+			return utils.ErrorResponse(c, "Payment was declined", []string{err.Error()}, fiber.StatusPaymentRequired)
+		}
+		return paymentErrorResponse(c, err, "Failed to authorize payment")
+	}
+
+	return utils.SuccessResponse(c, payment, "Payment authorized successfully")
+}
+
+// GetBookingPayments lists the payments made for a booking
+func GetBookingPayments(c *fiber.Ctx) error {
+	id := c.Params("id")
+	bookingID, err := uuid.Parse(id)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid booking ID", []string{"Invalid UUID format"})
+	}
+
+	var booking models.Booking
+	if result := database.DB.First(&booking, "id = ?", bookingID); result.Error != nil {
+		return utils.NotFoundResponse(c, "Booking not found")
+	}
+
+	// Check if the user is authorized to view this booking
+	userID := c.Locals("user_id").(string)
+	if booking.UserID.String() != userID && !hasPermission(c, middlewares.PermManageBookings) {
+		return utils.ForbiddenResponse(c, "Not authorized to view this booking")
+	}
+
+	paymentService := services.NewPaymentService()
+	bookingPayments, err := paymentService.GetBookingPayments(bookingID)
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Failed to fetch payments")
+	}
+
+	return utils.SuccessResponse(c, bookingPayments, "Payments fetched successfully")
+}
+
+// CapturePayment collects an authorized payment (admin only)
+func CapturePayment(c *fiber.Ctx) error {
+	return changePayment(c, "Payment captured successfully", "Failed to capture payment",
+		(*services.PaymentService).CapturePayment)
+}
+
+// RefundPayment refunds a captured payment (admin only)
+func RefundPayment(c *fiber.Ctx) error {
+	return changePayment(c, "Payment refunded successfully", "Failed to refund payment",
+		(*services.PaymentService).RefundPayment)
+}
+
+// HandlePaymentWebhook applies a signed notification from the payment provider
+func HandlePaymentWebhook(c *fiber.Ctx) error {
+	paymentService := services.NewPaymentService()
+	if err := paymentService.HandleWebhook(c.Body(), c.Get(PaymentSignatureHeader)); err != nil {
+		if errors.Is(err, payments.ErrInvalidSignature) {
+			return utils.UnauthorizedResponse(c, "Invalid webhook signature")
+		}
+		return paymentErrorResponse(c, err, "Failed to process webhook")
+	}
+
+	return utils.SuccessResponse(c, nil, "Webhook processed successfully")
+}
+
+// changePayment runs a capture or refund on the payment identified by the :id param
+func changePayment(c *fiber.Ctx, message, fallback string,
+	change func(*services.PaymentService, uuid.UUID, money.Money) (*models.Payment, error)) error {
+	paymentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid payment ID", []string{"Invalid UUID format"})
+	}
+
+	var req PaymentAmountRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return utils.ValidationErrorResponse(c, "Invalid request body", []string{
+				"Failed to parse request body: " + err.Error(),
+			})
+		}
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return utils.ValidationErrorResponse(c, "Validation failed", validationErrors)
+	}
+
+	payment, err := change(services.NewPaymentService(), paymentID, req.Amount)
+	if err != nil {
+		return paymentErrorResponse(c, err, fallback)
+	}
+
+	return utils.SuccessResponse(c, payment, message)
+}
+
+// paymentErrorResponse maps payment errors to API responses
+func paymentErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrBookingNotFound):
+		return utils.NotFoundResponse(c, "Booking not found")
+	case errors.Is(err, services.ErrPaymentNotFound), errors.Is(err, payments.ErrUnknownReference):
+		return utils.NotFoundResponse(c, "Payment not found")
+	case errors.Is(err, services.ErrBookingNotPayable):
+		return utils.ConflictResponse(c, "Booking cannot be paid for in its current status", nil)
+	case errors.Is(err, services.ErrNothingToPay):
+		return utils.ConflictResponse(c, "Booking has no outstanding balance", nil)
+	case errors.Is(err, services.ErrPaymentNotCapturable):
+		return utils.ConflictResponse(c, "Payment is not authorized", nil)
+	case errors.Is(err, services.ErrPaymentNotRefundable):
+		return utils.ConflictResponse(c, "Payment has nothing to refund", nil)
+	case errors.Is(err, money.ErrCurrencyMismatch):
+		return utils.ValidationErrorResponse(c, "Amount is in a different currency", []string{err.Error()})
+	case errors.Is(err, payments.ErrInvalidAmount):
+		return utils.ValidationErrorResponse(c, "Invalid payment amount", []string{err.Error()})
+	case errors.Is(err, payments.ErrGatewayNotConfigured):
+		return utils.ServerErrorResponse(c, "Payment gateway is not configured")
+	default:
+		return utils.ServerErrorResponse(c, fallback)
+	}
+}
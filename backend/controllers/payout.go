@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"car-rental-backend/services"
+	"car-rental-backend/utils"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// MarkPayoutPaidRequest represents the request body for marking a payout as paid
+type MarkPayoutPaidRequest struct {
+	Reference string `json:"reference" validate:"max=255"`
+}
+
+// GetOwnerPayouts lists an owner's payout statements (admin only)
+func GetOwnerPayouts(c *fiber.Ctx) error {
+	ownerID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid owner ID", []string{"Invalid UUID format"})
+	}
+
+	payoutService := services.NewPayoutService()
+	payouts, err := payoutService.GetOwnerPayouts(ownerID)
+	if err != nil {
+		if errors.Is(err, services.ErrOwnerNotFound) {
+			return utils.NotFoundResponse(c, "Owner not found")
+		}
+		return utils.ServerErrorResponse(c, "Failed to fetch payouts")
+	}
+
+	return utils.SuccessResponse(c, payouts, "Payouts fetched successfully")
+}
+
+// MarkPayoutPaid records that a payout has been paid to the owner (admin only)
+func MarkPayoutPaid(c *fiber.Ctx) error {
+	payoutID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid payout ID", []string{"Invalid UUID format"})
+	}
+
+	var req MarkPayoutPaidRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return utils.ValidationErrorResponse(c, "Invalid request body", []string{
+				"Failed to parse request body: " + err.Error(),
+			})
+		}
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return utils.ValidationErrorResponse(c, "Validation failed", validationErrors)
+	}
+
+	actorID, err := uuid.Parse(c.Locals("user_id").(string))
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Invalid user ID")
+	}
+
+	payoutService := services.NewPayoutService()
+	payout, err := payoutService.MarkPayoutPaid(payoutID, actorID, req.Reference)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPayoutNotFound):
+			return utils.NotFoundResponse(c, "Payout not found")
+		case errors.Is(err, services.ErrPayoutAlreadyPaid):
+			return utils.ConflictResponse(c, "Payout has already been paid", []string{err.Error()})
+		default:
+			return utils.ServerErrorResponse(c, "Failed to mark payout as paid")
+		}
+	}
+
+	return utils.SuccessResponse(c, payout, "Payout marked as paid")
+}
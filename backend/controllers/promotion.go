@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"car-rental-backend/models"
+	"car-rental-backend/money"
+	"car-rental-backend/services"
+	"car-rental-backend/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// PromotionRequest represents the request body for creating or replacing a promotion
+type PromotionRequest struct {
+	Code                  string      `json:"code" validate:"required,max=50"`
+	Description           string      `json:"description"`
+	DiscountType          string      `json:"discount_type" validate:"required,oneof=PERCENTAGE FIXED"`
+	DiscountPercent       float64     `json:"discount_percent" validate:"required_if=DiscountType PERCENTAGE,min=0,max=100"`
+	DiscountAmount        money.Money `json:"discount_amount" validate:"nonNegativeMoney"`
+	MaxDiscount           money.Money `json:"max_discount" validate:"nonNegativeMoney"`
+	ValidFrom             time.Time   `json:"valid_from" validate:"required"`
+	ValidUntil            time.Time   `json:"valid_until" validate:"required"`
+	MaxRedemptions        int         `json:"max_redemptions" validate:"min=0"`
+	MaxRedemptionsPerUser int         `json:"max_redemptions_per_user" validate:"min=0"`
+	EligibleCarIDs        []string    `json:"eligible_car_ids" validate:"omitempty,dive,validUUID"`
+	EligibleBodyTypes     []string    `json:"eligible_body_types" validate:"omitempty,dive,oneof=Sedan SUV Hatchback Coupe Van Truck"`
+	MinDurationHours      int         `json:"min_duration_hours" validate:"min=0"`
+	IsActive              *bool       `json:"is_active"`
+}
+
+// GetPromotions lists all promotions (admin only)
+func GetPromotions(c *fiber.Ctx) error {
+	promotionService := services.NewPromotionService()
+	promotions, err := promotionService.GetPromotions()
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Failed to fetch promotions")
+	}
+
+	return utils.SuccessResponse(c, promotions, "Promotions fetched successfully")
+}
+
+// GetPromotion fetches a single promotion (admin only)
+func GetPromotion(c *fiber.Ctx) error {
+	promotionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid promotion ID", []string{"Invalid UUID format"})
+	}
+
+	promotionService := services.NewPromotionService()
+	promotion, err := promotionService.GetPromotionByID(promotionID)
+	if err != nil {
+		return utils.NotFoundResponse(c, "Promotion not found")
+	}
+
+	return utils.SuccessResponse(c, promotion, "Promotion fetched successfully")
+}
+
+// CreatePromotion creates a new promo code (admin only)
+func CreatePromotion(c *fiber.Ctx) error {
+	promotion := &models.Promotion{IsActive: true}
+	if errs := parsePromotionRequest(c, promotion); errs != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", errs)
+	}
+
+	promotionService := services.NewPromotionService()
+	if err := promotionService.CreatePromotion(promotion); err != nil {
+		return utils.HandleDatabaseError(c, err)
+	}
+
+	return utils.SuccessResponse(c, promotion, "Promotion created successfully")
+}
+
+// UpdatePromotion replaces an existing promotion (admin only)
+func UpdatePromotion(c *fiber.Ctx) error {
+	promotionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid promotion ID", []string{"Invalid UUID format"})
+	}
+
+	promotionService := services.NewPromotionService()
+	promotion, err := promotionService.GetPromotionByID(promotionID)
+	if err != nil {
+		return utils.NotFoundResponse(c, "Promotion not found")
+	}
+
+	if errs := parsePromotionRequest(c, promotion); errs != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", errs)
+	}
+
+	if err := promotionService.UpdatePromotion(promotion); err != nil {
+		return utils.HandleDatabaseError(c, err)
+	}
+
+	return utils.SuccessResponse(c, promotion, "Promotion updated successfully")
+}
+
+// DeletePromotion deletes a promotion (admin only). Bookings that already used
+// it keep their discount.
+func DeletePromotion(c *fiber.Ctx) error {
+	promotionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid promotion ID", []string{"Invalid UUID format"})
+	}
+
+	promotionService := services.NewPromotionService()
+	promotion, err := promotionService.GetPromotionByID(promotionID)
+	if err != nil {
+		return utils.NotFoundResponse(c, "Promotion not found")
+	}
+
+	if err := promotionService.DeletePromotion(promotion); err != nil {
+		return utils.HandleDatabaseError(c, err)
+	}
+
+	return utils.SuccessResponse(c, nil, "Promotion deleted successfully")
+}
+
+// parsePromotionRequest parses and validates the request body into promotion,
+// returning the validation errors if any
+func parsePromotionRequest(c *fiber.Ctx, promotion *models.Promotion) []string {
+	var req PromotionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return []string{"Failed to parse request body: " + err.Error()}
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return validationErrors
+	}
+
+	if !req.ValidUntil.After(req.ValidFrom) {
+		return []string{"valid_until must be after valid_from"}
+	}
+	if req.DiscountType == string(models.DiscountTypeFixed) && !req.DiscountAmount.IsPositive() {
+		return []string{"Fixed discounts need a discount_amount above zero"}
+	}
+
+	bodyTypes := make([]models.BodyType, 0, len(req.EligibleBodyTypes))
+	for _, bodyType := range req.EligibleBodyTypes {
+		bodyTypes = append(bodyTypes, models.BodyType(bodyType))
+	}
+
+	promotion.Code = req.Code
+	promotion.Description = req.Description
+	promotion.DiscountType = models.DiscountType(req.DiscountType)
+	promotion.DiscountPercent = 0
+	promotion.DiscountAmount = money.Zero()
+	if promotion.DiscountType == models.DiscountTypePercentage {
+		promotion.DiscountPercent = req.DiscountPercent
+	} else {
+		promotion.DiscountAmount = req.DiscountAmount
+	}
+	promotion.MaxDiscount = req.MaxDiscount
+	promotion.ValidFrom = req.ValidFrom
+	promotion.ValidUntil = req.ValidUntil
+	promotion.MaxRedemptions = req.MaxRedemptions
+	promotion.MaxRedemptionsPerUser = req.MaxRedemptionsPerUser
+	promotion.EligibleCarIDs = req.EligibleCarIDs
+	promotion.EligibleBodyTypes = bodyTypes
+	promotion.MinDurationHours = req.MinDurationHours
+	if req.IsActive != nil {
+		promotion.IsActive = *req.IsActive
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"car-rental-backend/services"
+	"car-rental-backend/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// CreateQuoteRequest represents the request body for previewing a booking price
+type CreateQuoteRequest struct {
+	CarID     string    `json:"car_id" validate:"required"`
+	StartTime time.Time `json:"start_time" validate:"required"`
+	EndTime   time.Time `json:"end_time" validate:"required"`
+	PromoCode string    `json:"promo_code"`
+}
+
+// CreateQuote returns an itemised price for a rental window without booking it
+func CreateQuote(c *fiber.Ctx) error {
+	var req CreateQuoteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request body", []string{
+			"Failed to parse request body: " + err.Error(),
+		})
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return utils.ValidationErrorResponse(c, "Validation failed", validationErrors)
+	}
+
+	carID, err := uuid.Parse(req.CarID)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid car ID", []string{"Invalid UUID format"})
+	}
+
+	// Get user ID from context (set by auth middleware)
+	userID, err := uuid.Parse(c.Locals("user_id").(string))
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Invalid user ID")
+	}
+
+	bookingService := services.NewBookingService()
+	quote, err := bookingService.QuoteBooking(services.BookingInput{
+		UserID:    userID,
+		CarID:     carID,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		PromoCode: req.PromoCode,
+		Currency:  requestedCurrency(c),
+	})
+	if err != nil {
+		return bookingErrorResponse(c, err, "Failed to calculate quote")
+	}
+
+	return utils.SuccessResponse(c, quote, "Quote calculated successfully")
+}
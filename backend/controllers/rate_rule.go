@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"car-rental-backend/models"
+	"car-rental-backend/money"
+	"car-rental-backend/services"
+	"car-rental-backend/utils"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RateRuleRequest represents the request body for creating or replacing a rate rule
+type RateRuleRequest struct {
+	Name               string      `json:"name" validate:"required"`
+	Kind               string      `json:"kind" validate:"required,oneof=SEASON WEEKEND HOLIDAY"`
+	StartDate          string      `json:"start_date" validate:"omitempty,validDate"`
+	EndDate            string      `json:"end_date" validate:"omitempty,validDate"`
+	Priority           int         `json:"priority"`
+	RentalPricePerDay  money.Money `json:"rental_price_per_day" validate:"positiveMoney"`
+	RentalPricePerHour money.Money `json:"rental_price_per_hour" validate:"positiveMoney"`
+}
+
+// GetCarRates lists the rate rules that apply to a car, including those of its body type
+func GetCarRates(c *fiber.Ctx) error {
+	carID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid car ID", []string{"Invalid UUID format"})
+	}
+
+	rateRuleService := services.NewRateRuleService()
+	rules, err := rateRuleService.RateRulesForCar(carID)
+	if err != nil {
+		if errors.Is(err, services.ErrCarNotFound) {
+			return utils.NotFoundResponse(c, "Car not found")
+		}
+		log.Printf("Failed to fetch rate rules for car %s: %v", carID, err)
+		return utils.ServerErrorResponse(c, "Failed to fetch rate rules")
+	}
+
+	return utils.SuccessResponse(c, rules, "Rate rules fetched successfully")
+}
+
+// CreateCarRate attaches a new rate rule to a car (admin only)
+func CreateCarRate(c *fiber.Ctx) error {
+	carID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid car ID", []string{"Invalid UUID format"})
+	}
+
+	carService := services.NewCarService()
+	car, err := carService.GetCarByID(carID)
+	if err != nil {
+		return utils.NotFoundResponse(c, "Car not found")
+	}
+
+	rule := &models.RateRule{CarID: &car.ID}
+	if errs := parseRateRuleRequest(c, rule); errs != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", errs)
+	}
+
+	rateRuleService := services.NewRateRuleService()
+	if err := rateRuleService.CreateRateRule(rule); err != nil {
+		return utils.HandleDatabaseError(c, err)
+	}
+
+	return utils.SuccessResponse(c, rule, "Rate rule created successfully")
+}
+
+// UpdateCarRate replaces a rate rule attached to a car (admin only). Body-type
+// rules are changed through their own endpoint, as they apply to other cars too.
+func UpdateCarRate(c *fiber.Ctx) error {
+	carID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid car ID", []string{"Invalid UUID format"})
+	}
+
+	ruleID, err := uuid.Parse(c.Params("rateId"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid rate rule ID", []string{"Invalid UUID format"})
+	}
+
+	rateRuleService := services.NewRateRuleService()
+	rule, err := rateRuleService.GetCarRateRule(carID, ruleID)
+	if err != nil {
+		return utils.NotFoundResponse(c, "Rate rule not found")
+	}
+
+	return updateRateRule(c, rateRuleService, rule)
+}
+
+// DeleteCarRate deletes a rate rule attached to a car (admin only)
+func DeleteCarRate(c *fiber.Ctx) error {
+	carID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid car ID", []string{"Invalid UUID format"})
+	}
+
+	ruleID, err := uuid.Parse(c.Params("rateId"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid rate rule ID", []string{"Invalid UUID format"})
+	}
+
+	rateRuleService := services.NewRateRuleService()
+	rule, err := rateRuleService.GetCarRateRule(carID, ruleID)
+	if err != nil {
+		return utils.NotFoundResponse(c, "Rate rule not found")
+	}
+
+	return deleteRateRule(c, rateRuleService, rule)
+}
+
+// GetBodyTypeRates lists the rate rules attached to a body type
+func GetBodyTypeRates(c *fiber.Ctx) error {
+	bodyType, ok := parseBodyType(c)
+	if !ok {
+		return utils.ValidationErrorResponse(c, "Invalid body type", invalidBodyTypeErrors)
+	}
+
+	rateRuleService := services.NewRateRuleService()
+	rules, err := rateRuleService.RateRulesForBodyType(bodyType)
+	if err != nil {
+		log.Printf("Failed to fetch rate rules for body type %s: %v", bodyType, err)
+		return utils.ServerErrorResponse(c, "Failed to fetch rate rules")
+	}
+
+	return utils.SuccessResponse(c, rules, "Rate rules fetched successfully")
+}
+
+// CreateBodyTypeRate attaches a new rate rule to every car of a body type (admin only)
+func CreateBodyTypeRate(c *fiber.Ctx) error {
+	bodyType, ok := parseBodyType(c)
+	if !ok {
+		return utils.ValidationErrorResponse(c, "Invalid body type", invalidBodyTypeErrors)
+	}
+
+	rule := &models.RateRule{BodyType: &bodyType}
+	if errs := parseRateRuleRequest(c, rule); errs != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", errs)
+	}
+
+	rateRuleService := services.NewRateRuleService()
+	if err := rateRuleService.CreateRateRule(rule); err != nil {
+		return utils.HandleDatabaseError(c, err)
+	}
+
+	return utils.SuccessResponse(c, rule, "Rate rule created successfully")
+}
+
+// UpdateBodyTypeRate replaces a rate rule attached to a body type (admin only)
+func UpdateBodyTypeRate(c *fiber.Ctx) error {
+	bodyType, ok := parseBodyType(c)
+	if !ok {
+		return utils.ValidationErrorResponse(c, "Invalid body type", invalidBodyTypeErrors)
+	}
+
+	ruleID, err := uuid.Parse(c.Params("rateId"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid rate rule ID", []string{"Invalid UUID format"})
+	}
+
+	rateRuleService := services.NewRateRuleService()
+	rule, err := rateRuleService.GetBodyTypeRateRule(bodyType, ruleID)
+	if err != nil {
+		return utils.NotFoundResponse(c, "Rate rule not found")
+	}
+
+	return updateRateRule(c, rateRuleService, rule)
+}
+
+// DeleteBodyTypeRate deletes a rate rule attached to a body type (admin only)
+func DeleteBodyTypeRate(c *fiber.Ctx) error {
+	bodyType, ok := parseBodyType(c)
+	if !ok {
+		return utils.ValidationErrorResponse(c, "Invalid body type", invalidBodyTypeErrors)
+	}
+
+	ruleID, err := uuid.Parse(c.Params("rateId"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid rate rule ID", []string{"Invalid UUID format"})
+	}
+
+	rateRuleService := services.NewRateRuleService()
+	rule, err := rateRuleService.GetBodyTypeRateRule(bodyType, ruleID)
+	if err != nil {
+		return utils.NotFoundResponse(c, "Rate rule not found")
+	}
+
+	return deleteRateRule(c, rateRuleService, rule)
+}
+
+// updateRateRule replaces an existing rule with the request body. The rule
+// keeps the car or body type it is attached to.
+func updateRateRule(c *fiber.Ctx, rateRuleService *services.RateRuleService, rule *models.RateRule) error {
+	if errs := parseRateRuleRequest(c, rule); errs != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", errs)
+	}
+
+	if err := rateRuleService.UpdateRateRule(rule); err != nil {
+		return utils.HandleDatabaseError(c, err)
+	}
+
+	return utils.SuccessResponse(c, rule, "Rate rule updated successfully")
+}
+
+func deleteRateRule(c *fiber.Ctx, rateRuleService *services.RateRuleService, rule *models.RateRule) error {
+	if err := rateRuleService.DeleteRateRule(rule); err != nil {
+		return utils.HandleDatabaseError(c, err)
+	}
+
+	return utils.SuccessResponse(c, nil, "Rate rule deleted successfully")
+}
+
+var invalidBodyTypeErrors = []string{"Body type must be one of Sedan, SUV, Hatchback, Coupe, Van, Truck"}
+
+// parseBodyType reads the :bodyType route parameter
+func parseBodyType(c *fiber.Ctx) (models.BodyType, bool) {
+	bodyType := models.BodyType(c.Params("bodyType"))
+	switch bodyType {
+	case models.BodyTypeSedan, models.BodyTypeSUV, models.BodyTypeHatchback,
+		models.BodyTypeCoupe, models.BodyTypeVan, models.BodyTypeTruck:
+		return bodyType, true
+	}
+	return "", false
+}
+
+// parseRateRuleRequest parses and validates the request body into rule,
+// returning the validation errors if any
+func parseRateRuleRequest(c *fiber.Ctx, rule *models.RateRule) []string {
+	var req RateRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return []string{"Failed to parse request body: " + err.Error()}
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return validationErrors
+	}
+
+	rule.Name = req.Name
+	rule.Kind = models.RateRuleKind(req.Kind)
+	rule.Priority = req.Priority
+	rule.RentalPricePerDay = req.RentalPricePerDay
+	rule.RentalPricePerHour = req.RentalPricePerHour
+	rule.StartDate = nil
+	rule.EndDate = nil
+
+	if rule.Kind == models.RateRuleSeason {
+		if req.StartDate == "" || req.EndDate == "" {
+			return []string{"Season rules require start_date and end_date"}
+		}
+		startDate, _ := time.Parse("2006-01-02", req.StartDate)
+		endDate, _ := time.Parse("2006-01-02", req.EndDate)
+		if endDate.Before(startDate) {
+			return []string{"end_date must not be before start_date"}
+		}
+		rule.StartDate = &startDate
+		rule.EndDate = &endDate
+	}
+
+	return nil
+}
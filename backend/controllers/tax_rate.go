@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"car-rental-backend/models"
+	"car-rental-backend/services"
+	"car-rental-backend/utils"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// TaxRateRequest represents the request body for creating or replacing a tax rate
+type TaxRateRequest struct {
+	Jurisdiction string  `json:"jurisdiction" validate:"required,max=50"`
+	Name         string  `json:"name" validate:"required,max=100"`
+	Rate         float64 `json:"rate" validate:"min=0,max=100"`
+	Inclusive    bool    `json:"inclusive"`
+	TaxFees      bool    `json:"tax_fees"`
+	TaxDeposits  bool    `json:"tax_deposits"`
+}
+
+// GetTaxRates lists all tax rates (admin only)
+func GetTaxRates(c *fiber.Ctx) error {
+	taxRateService := services.NewTaxRateService()
+	rates, err := taxRateService.GetTaxRates()
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Failed to fetch tax rates")
+	}
+
+	return utils.SuccessResponse(c, rates, "Tax rates fetched successfully")
+}
+
+// CreateTaxRate creates the tax rate of a jurisdiction (admin only)
+func CreateTaxRate(c *fiber.Ctx) error {
+	rate := &models.TaxRate{}
+	if errs := parseTaxRateRequest(c, rate); errs != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", errs)
+	}
+
+	taxRateService := services.NewTaxRateService()
+	if err := taxRateService.CreateTaxRate(rate); err != nil {
+		if errors.Is(err, services.ErrTaxRateExists) {
+			return utils.ConflictResponse(c, "A tax rate already exists for this jurisdiction", []string{err.Error()})
+		}
+		return utils.HandleDatabaseError(c, err)
+	}
+
+	return utils.SuccessResponse(c, rate, "Tax rate created successfully")
+}
+
+// UpdateTaxRate replaces a tax rate (admin only)
+func UpdateTaxRate(c *fiber.Ctx) error {
+	rateID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid tax rate ID", []string{"Invalid UUID format"})
+	}
+
+	taxRateService := services.NewTaxRateService()
+	rate, err := taxRateService.GetTaxRateByID(rateID)
+	if err != nil {
+		return utils.NotFoundResponse(c, "Tax rate not found")
+	}
+
+	if errs := parseTaxRateRequest(c, rate); errs != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", errs)
+	}
+
+	if err := taxRateService.UpdateTaxRate(rate); err != nil {
+		if errors.Is(err, services.ErrTaxRateExists) {
+			return utils.ConflictResponse(c, "A tax rate already exists for this jurisdiction", []string{err.Error()})
+		}
+		return utils.HandleDatabaseError(c, err)
+	}
+
+	return utils.SuccessResponse(c, rate, "Tax rate updated successfully")
+}
+
+// DeleteTaxRate deletes a tax rate (admin only)
+func DeleteTaxRate(c *fiber.Ctx) error {
+	rateID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid tax rate ID", []string{"Invalid UUID format"})
+	}
+
+	taxRateService := services.NewTaxRateService()
+	rate, err := taxRateService.GetTaxRateByID(rateID)
+	if err != nil {
+		return utils.NotFoundResponse(c, "Tax rate not found")
+	}
+
+	if err := taxRateService.DeleteTaxRate(rate); err != nil {
+		return utils.HandleDatabaseError(c, err)
+	}
+
+	return utils.SuccessResponse(c, nil, "Tax rate deleted successfully")
+}
+
+// GetTaxReport totals invoiced taxes by jurisdiction over a period (admin only).
+// The period defaults to the current calendar month.
+func GetTaxReport(c *fiber.Ctx) error {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	to := from.AddDate(0, 1, 0)
+
+	var err error
+	if fromParam := c.Query("from"); fromParam != "" {
+		if from, err = time.Parse(time.RFC3339, fromParam); err != nil {
+			return utils.ValidationErrorResponse(c, "Invalid from time format", []string{"From time must be in RFC3339 format"})
+		}
+	}
+	if toParam := c.Query("to"); toParam != "" {
+		if to, err = time.Parse(time.RFC3339, toParam); err != nil {
+			return utils.ValidationErrorResponse(c, "Invalid to time format", []string{"To time must be in RFC3339 format"})
+		}
+	}
+	if !to.After(from) {
+		return utils.ValidationErrorResponse(c, "To time must be after from time", []string{"Invalid time range"})
+	}
+
+	taxRateService := services.NewTaxRateService()
+	report, err := taxRateService.GetTaxReport(from, to)
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Failed to build tax report")
+	}
+
+	return utils.SuccessResponse(c, report, "Tax report fetched successfully")
+}
+
+// parseTaxRateRequest parses and validates the request body into rate,
+// returning the validation errors if any
+func parseTaxRateRequest(c *fiber.Ctx, rate *models.TaxRate) []string {
+	var req TaxRateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return []string{"Failed to parse request body: " + err.Error()}
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return validationErrors
+	}
+
+	rate.Jurisdiction = req.Jurisdiction
+	rate.Name = req.Name
+	rate.Rate = req.Rate
+	rate.Inclusive = req.Inclusive
+	rate.TaxFees = req.TaxFees
+	rate.TaxDeposits = req.TaxDeposits
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"car-rental-backend/database"
+	"car-rental-backend/models"
+	"car-rental-backend/services"
+	"car-rental-backend/utils"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// TwoFactorCodeRequest represents a request confirmed with a TOTP code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// TwoFactorVerifyRequest represents a request confirmed with a TOTP code or,
+// if the authenticator app is not at hand, a recovery code
+type TwoFactorVerifyRequest struct {
+	Code         string `json:"code" validate:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recovery_code" validate:"required_without=Code"`
+}
+
+// GetTwoFactorStatus returns the current user's two-factor setup
+func GetTwoFactorStatus(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return utils.UnauthorizedResponse(c, "Invalid user ID")
+	}
+
+	status, err := services.NewTwoFactorService().Status(user)
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Failed to fetch two-factor status")
+	}
+
+	return utils.SuccessResponse(c, status, "Two-factor status retrieved successfully")
+}
+
+// EnrollTwoFactor starts two-factor enrollment, returning the secret to add
+// to an authenticator app
+func EnrollTwoFactor(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return utils.UnauthorizedResponse(c, "Invalid user ID")
+	}
+
+	enrollment, err := services.NewTwoFactorService().Enroll(user)
+	if err != nil {
+		if errors.Is(err, services.ErrTwoFactorAlreadyEnabled) {
+			return utils.ConflictResponse(c, "Two-factor authentication is already enabled", []string{err.Error()})
+		}
+		return utils.ServerErrorResponse(c, "Failed to start two-factor enrollment")
+	}
+
+	return utils.SuccessResponse(c, enrollment, "Add the secret to your authenticator app and confirm with a code")
+}
+
+// ConfirmTwoFactor enables two-factor authentication with a code from the
+// authenticator app and returns the recovery codes
+func ConfirmTwoFactor(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("user_id").(string))
+	if err != nil {
+		return utils.UnauthorizedResponse(c, "Invalid user ID")
+	}
+
+	var req TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request body", []string{"Failed to parse request body"})
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return utils.ValidationErrorResponse(c, "Validation failed", validationErrors)
+	}
+
+	codes, err := services.NewTwoFactorService().Confirm(userID, req.Code)
+	if err != nil {
+		return twoFactorErrorResponse(c, err, "Failed to enable two-factor authentication")
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"recovery_codes": codes,
+	}, "Two-factor authentication enabled. Log in again to use it in this session")
+}
+
+// RegenerateRecoveryCodes replaces the current user's recovery codes
+func RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("user_id").(string))
+	if err != nil {
+		return utils.UnauthorizedResponse(c, "Invalid user ID")
+	}
+
+	var req TwoFactorVerifyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request body", []string{"Failed to parse request body"})
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return utils.ValidationErrorResponse(c, "Validation failed", validationErrors)
+	}
+
+	codes, err := services.NewTwoFactorService().RegenerateRecoveryCodes(userID, req.Code, req.RecoveryCode)
+	if err != nil {
+		return twoFactorErrorResponse(c, err, "Failed to regenerate recovery codes")
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"recovery_codes": codes,
+	}, "Recovery codes regenerated successfully")
+}
+
+// DisableTwoFactor turns two-factor authentication off for the current user
+// and logs out all of their sessions
+func DisableTwoFactor(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("user_id").(string))
+	if err != nil {
+		return utils.UnauthorizedResponse(c, "Invalid user ID")
+	}
+
+	var req TwoFactorVerifyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request body", []string{"Failed to parse request body"})
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return utils.ValidationErrorResponse(c, "Validation failed", validationErrors)
+	}
+
+	if err := services.NewTwoFactorService().Disable(userID, req.Code, req.RecoveryCode); err != nil {
+		return twoFactorErrorResponse(c, err, "Failed to disable two-factor authentication")
+	}
+
+	return utils.SuccessResponse(c, nil, "Two-factor authentication disabled. Please log in again")
+}
+
+// currentUser loads the authenticated user
+func currentUser(c *fiber.Ctx) (*models.User, error) {
+	userID, err := uuid.Parse(c.Locals("user_id").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// twoFactorErrorResponse maps two-factor service errors to responses
+func twoFactorErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		return utils.ValidationErrorResponse(c, "Validation failed", []string{
+			utils.FieldValidationError("code", "twoFactorCode", "code is invalid or has already been used"),
+		})
+	case errors.Is(err, services.ErrTwoFactorNotEnrolled):
+		return utils.ValidationErrorResponse(c, "Start two-factor enrollment first", []string{err.Error()})
+	case errors.Is(err, services.ErrTwoFactorNotEnabled):
+		return utils.ValidationErrorResponse(c, "Two-factor authentication is not enabled", []string{err.Error()})
+	case errors.Is(err, services.ErrTwoFactorAlreadyEnabled):
+		return utils.ConflictResponse(c, "Two-factor authentication is already enabled", []string{err.Error()})
+	default:
+		return utils.ServerErrorResponse(c, fallback)
+	}
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"car-rental-backend/services"
+	"car-rental-backend/utils"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// UnlockUser lifts a login lockout on a user's account and clears their failed attempts
+func UnlockUser(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid user ID", []string{"Invalid UUID format"})
+	}
+
+	actorID, err := uuid.Parse(c.Locals("user_id").(string))
+	if err != nil {
+		return utils.UnauthorizedResponse(c, "Invalid user ID")
+	}
+
+	attempts := services.NewLoginAttemptService()
+	wasLocked, err := attempts.UnlockUser(userID, actorID, c.IP())
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			return utils.NotFoundResponse(c, "User not found")
+		}
+		return utils.ServerErrorResponse(c, "Failed to unlock user")
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"user_id":    userID,
+		"was_locked": wasLocked,
+	}, "User unlocked successfully")
+}
+
+// ChangePasswordRequest represents the request body for changing the current user's password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,strongPassword"`
+}
+
+// ChangePassword changes the current user's password. The user's other
+// sessions are logged out; the one making the request stays logged in.
+func ChangePassword(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("user_id").(string))
+	if err != nil {
+		return utils.UnauthorizedResponse(c, "Invalid user ID")
+	}
+
+	var req ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request body", []string{"Failed to parse request body"})
+	}
+
+	// Validate request
+	if validationErrors := utils.ValidateStruct(req); len(validationErrors) > 0 {
+		return utils.ValidationErrorResponse(c, "Validation failed", validationErrors)
+	}
+
+	familyID, _ := c.Locals("token_family_id").(uuid.UUID)
+	passwordService := services.NewPasswordService()
+	if err := passwordService.ChangePassword(userID, req.CurrentPassword, req.NewPassword, familyID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrIncorrectPassword):
+			return utils.ValidationErrorResponse(c, "Validation failed", []string{
+				utils.FieldValidationError("current_password", "currentPassword", "current password is incorrect"),
+			})
+		case errors.Is(err, services.ErrPasswordReused):
+			return utils.ValidationErrorResponse(c, "Validation failed", []string{passwordReusedError("new_password")})
+		case errors.Is(err, services.ErrUserNotFound):
+			return utils.NotFoundResponse(c, "User not found")
+		default:
+			return utils.ServerErrorResponse(c, "Failed to change password")
+		}
+	}
+
+	return utils.SuccessResponse(c, nil, "Password changed successfully")
+}
+
+// passwordReusedError describes a new password rejected by the password history
+func passwordReusedError(field string) string {
+	return utils.FieldValidationError(field, "passwordHistory", "password was used recently; choose one you have not used before")
+}
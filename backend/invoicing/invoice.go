@@ -0,0 +1,136 @@
+// Package invoicing renders booking invoices
+package invoicing
+
+import (
+	"car-rental-backend/models"
+	"car-rental-backend/money"
+	"fmt"
+	"time"
+)
+
+// Layout of the rendered invoice, in points
+const (
+	marginLeft   = 50.0
+	marginRight  = pageWidth - 50.0
+	marginBottom = 60.0
+	lineHeight   = 16.0
+
+	colQuantity = 360.0
+	colUnit     = 450.0
+)
+
+// FormatNumber formats a value of the invoice number sequence as an invoice number
+func FormatNumber(sequence int64) string {
+	return fmt.Sprintf("INV-%06d", sequence)
+}
+
+// Filename returns the file name a rendered invoice is served under
+func Filename(invoice *models.Invoice) string {
+	return invoice.Number + ".pdf"
+}
+
+// RenderPDF renders an invoice as a PDF document
+func RenderPDF(invoice *models.Invoice) []byte {
+	doc := &pdfDocument{}
+	doc.addPage()
+	y := pageHeight - 70
+
+	// newLine moves down one line, starting a new page when the current one is full
+	newLine := func(lines float64) {
+		y -= lines * lineHeight
+		if y < marginBottom {
+			doc.addPage()
+			y = pageHeight - 70
+		}
+	}
+
+	doc.text(marginLeft, y, fontBold, 22, "INVOICE")
+	doc.textRight(marginRight, y, fontBold, 12, invoice.Number)
+	newLine(1)
+	doc.textRight(marginRight, y, fontRegular, 10, "Issued "+invoice.IssuedAt.Format("2 Jan 2006"))
+	newLine(2)
+
+	// Parties
+	doc.text(marginLeft, y, fontBold, 10, "From")
+	doc.text(300, y, fontBold, 10, "Bill to")
+	newLine(1)
+	doc.text(marginLeft, y, fontRegular, 10, invoice.Owner.Name)
+	doc.text(300, y, fontRegular, 10, invoice.Customer.Name)
+	newLine(1)
+	doc.text(marginLeft, y, fontRegular, 10, invoice.Owner.Contact)
+	doc.text(300, y, fontRegular, 10, invoice.Customer.Contact)
+	newLine(2)
+
+	// Rental
+	doc.text(marginLeft, y, fontBold, 10, "Vehicle")
+	doc.text(150, y, fontRegular, 10, fmt.Sprintf("%d %s %s (%s)",
+		invoice.Car.Year, invoice.Car.Make, invoice.Car.Model, invoice.Car.VehicleNumber))
+	newLine(1)
+	doc.text(marginLeft, y, fontBold, 10, "Rental period")
+	doc.text(150, y, fontRegular, 10, formatTime(invoice.RentalStart)+"  to  "+formatTime(invoice.RentalEnd))
+	newLine(2)
+
+	// Lines
+	doc.text(marginLeft, y, fontBold, 10, "Description")
+	doc.textRight(colQuantity, y, fontBold, 10, "Qty")
+	doc.textRight(colUnit, y, fontBold, 10, "Unit price")
+	doc.textRight(marginRight, y, fontBold, 10, "Amount")
+	newLine(1.25)
+	for _, line := range invoice.Lines {
+		doc.text(marginLeft, y, fontRegular, 10, line.Description)
+		doc.textRight(colQuantity, y, fontRegular, 10, formatQuantity(line.Quantity))
+		doc.textRight(colUnit, y, fontRegular, 10, formatAmount(line.UnitPrice))
+		doc.textRight(marginRight, y, fontRegular, 10, formatAmount(line.Amount))
+		newLine(1)
+	}
+	newLine(0.5)
+
+	// Totals
+	taxLabel := "Taxes"
+	if invoice.TaxInclusive {
+		taxLabel = "Taxes (included)"
+	}
+	totals := []struct {
+		label  string
+		amount money.Money
+	}{
+		{"Subtotal", invoice.Subtotal},
+		{"Discount", invoice.Discount.Neg()},
+		{"Fees", invoice.Fees},
+		{taxLabel, invoice.Taxes},
+	}
+	for _, total := range totals {
+		doc.text(colUnit-80, y, fontRegular, 10, total.label)
+		doc.textRight(marginRight, y, fontRegular, 10, formatAmount(total.amount))
+		newLine(1)
+	}
+	doc.text(colUnit-80, y, fontBold, 11, "Total")
+	doc.textRight(marginRight, y, fontBold, 11, invoice.Total.String())
+	newLine(2)
+
+	// Deposit
+	if invoice.DepositHeld.IsPositive() {
+		doc.text(marginLeft, y, fontBold, 10, "Security deposit")
+		newLine(1)
+		doc.text(marginLeft, y, fontRegular, 10, fmt.Sprintf("Held %s, captured %s, refunded %s",
+			formatAmount(invoice.DepositHeld), formatAmount(invoice.DepositCaptured), formatAmount(invoice.DepositRefunded)))
+		newLine(1)
+	}
+
+	return doc.bytes()
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2 Jan 2006 15:04 MST")
+}
+
+func formatAmount(amount money.Money) string {
+	return amount.Decimal()
+}
+
+func formatQuantity(quantity float64) string {
+	if quantity == float64(int64(quantity)) {
+		return fmt.Sprintf("%d", int64(quantity))
+	}
+	return fmt.Sprintf("%.2f", quantity)
+}
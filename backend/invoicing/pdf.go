@@ -0,0 +1,139 @@
+package invoicing
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A4 page size in points
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+)
+
+// Fonts available to a document. Both are PDF standard fonts, so nothing has to be embedded.
+const (
+	fontRegular = "F1"
+	fontBold    = "F2"
+)
+
+// pdfText is a run of text placed on a page
+type pdfText struct {
+	x, y float64
+	font string
+	size float64
+	text string
+}
+
+// pdfDocument is a minimal text-only PDF writer
+type pdfDocument struct {
+	pages [][]pdfText
+}
+
+// addPage starts a new page; subsequent text is drawn on it
+func (d *pdfDocument) addPage() {
+	d.pages = append(d.pages, nil)
+}
+
+// text draws s with its baseline starting at (x, y), measured from the bottom-left corner
+func (d *pdfDocument) text(x, y float64, font string, size float64, s string) {
+	if len(d.pages) == 0 {
+		d.addPage()
+	}
+	last := len(d.pages) - 1
+	d.pages[last] = append(d.pages[last], pdfText{x: x, y: y, font: font, size: size, text: s})
+}
+
+// textRight draws s so that it ends at x
+func (d *pdfDocument) textRight(x, y float64, font string, size float64, s string) {
+	d.text(x-textWidth(s, size), y, font, size, s)
+}
+
+// bytes serialises the document
+func (d *pdfDocument) bytes() []byte {
+	if len(d.pages) == 0 {
+		d.addPage()
+	}
+
+	var buf bytes.Buffer
+	var offsets []int
+
+	// Objects 1-4 are the catalog, page tree and fonts; each page then takes
+	// two objects, the page itself followed by its content stream
+	writeObject := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+
+	writeObject("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObject(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range d.pages {
+		writeObject(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, fontRegular, fontBold, 6+i*2))
+
+		var content bytes.Buffer
+		for _, t := range page {
+			fmt.Fprintf(&content, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", t.font, t.size, t.x, t.y, escapeText(t.text))
+		}
+		writeObject(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.Bytes()
+}
+
+// escapeText escapes a string for use in a PDF literal string. Characters
+// outside printable ASCII are replaced, since the standard fonts are used
+// without an embedded encoding for them.
+func escapeText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 126:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// textWidth approximates the width of s in Helvetica at the given size. Digits
+// and common punctuation use their exact widths so right-aligned amounts line up.
+func textWidth(s string, size float64) float64 {
+	var units float64
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			units += 556
+		case r == '.' || r == ',' || r == ' ':
+			units += 278
+		case r == '-':
+			units += 333
+		default:
+			units += 556
+		}
+	}
+	return units * size / 1000
+}
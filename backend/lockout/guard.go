@@ -0,0 +1,228 @@
+package lockout
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrGuardNotConfigured is returned when no login guard has been initialised
+var ErrGuardNotConfigured = errors.New("login guard is not configured")
+
+// Options configures a Guard
+type Options struct {
+	MaxFailures     int           // failures per email before it is locked
+	MaxIPFailures   int           // failures per IP address, across emails, before it is locked
+	LockoutDuration time.Duration // how long a lock lasts; failures older than this are forgotten
+	BaseDelay       time.Duration // wait after an email's first failure, doubled after each further one
+	MaxDelay        time.Duration // longest wait between attempts for an email
+}
+
+// Decision is the outcome of checking whether a login attempt may proceed
+type Decision struct {
+	Allowed    bool
+	Locked     bool          // the email or IP is locked, not just waiting out a delay
+	RetryAfter time.Duration // when the next attempt will be allowed
+}
+
+// Failure describes what a failed login led to
+type Failure struct {
+	Failures    int       // failures counted against the email
+	EmailLocked bool      // this failure locked the email
+	IPLocked    bool      // this failure locked the IP address
+	LockedUntil time.Time // when the new lock ends
+}
+
+// Guard limits login attempts per email and per IP address. Each failure makes
+// the next attempt for the email wait longer, and too many failures lock the
+// email or IP address for a while.
+type Guard struct {
+	store Store
+	opts  Options
+}
+
+// NewGuard creates a guard that keeps its records in store
+func NewGuard(store Store, opts Options) *Guard {
+	if opts.MaxFailures <= 0 {
+		opts.MaxFailures = 5
+	}
+	if opts.MaxIPFailures <= 0 {
+		opts.MaxIPFailures = 4 * opts.MaxFailures
+	}
+	if opts.LockoutDuration <= 0 {
+		opts.LockoutDuration = 15 * time.Minute
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = time.Second
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = 30 * time.Second
+	}
+	if opts.MaxDelay < opts.BaseDelay {
+		opts.MaxDelay = opts.BaseDelay
+	}
+	return &Guard{store: store, opts: opts}
+}
+
+// Attempt is a login attempt reserved by Begin. It is counted as a failure
+// until it is released, so an attempt that is never settled stays counted.
+type Attempt struct {
+	Decision
+	email   string
+	ip      string
+	byEmail Reservation
+	byIP    Reservation
+}
+
+// Begin reserves a login attempt for the email from the IP address if it may
+// be made now. The attempt is counted before the password is checked, so a
+// burst of parallel guesses cannot all get in before the first failure is
+// recorded. Settle it with Fail or Release.
+func (g *Guard) Begin(email, ip string) (*Attempt, error) {
+	now := time.Now()
+	attempt := &Attempt{email: email, ip: ip}
+
+	byEmail, err := g.store.Reserve(EmailKey(email), now, g.emailLimits())
+	if err != nil {
+		return nil, err
+	}
+	if !byEmail.Allowed {
+		attempt.Decision = Decision{Locked: byEmail.Locked, RetryAfter: byEmail.RetryAfter}
+		return attempt, nil
+	}
+
+	byIP, err := g.store.Reserve(IPKey(ip), now, g.ipLimits())
+	if err != nil {
+		return nil, err
+	}
+	if !byIP.Allowed {
+		if err := g.store.Release(EmailKey(email), byEmail); err != nil {
+			return nil, err
+		}
+		attempt.Decision = Decision{Locked: byIP.Locked, RetryAfter: byIP.RetryAfter}
+		return attempt, nil
+	}
+
+	attempt.Decision = Decision{Allowed: true}
+	attempt.byEmail = byEmail
+	attempt.byIP = byIP
+	return attempt, nil
+}
+
+// Fail settles an attempt as a failed login, locking the email or the IP
+// address once it reaches its limit
+func (g *Guard) Fail(attempt *Attempt) (Failure, error) {
+	now := time.Now()
+	failure := Failure{Failures: attempt.byEmail.Record.Failures}
+
+	if attempt.byEmail.Record.Failures >= g.opts.MaxFailures {
+		failure.LockedUntil = now.Add(g.opts.LockoutDuration)
+		if err := g.store.Lock(EmailKey(attempt.email), failure.LockedUntil); err != nil {
+			return Failure{}, err
+		}
+		failure.EmailLocked = true
+	}
+
+	if attempt.byIP.Record.Failures >= g.opts.MaxIPFailures {
+		failure.LockedUntil = now.Add(g.opts.LockoutDuration)
+		if err := g.store.Lock(IPKey(attempt.ip), failure.LockedUntil); err != nil {
+			return Failure{}, err
+		}
+		failure.IPLocked = true
+	}
+
+	return failure, nil
+}
+
+// Release settles an attempt that did not fail, so it no longer counts
+// against the email or the IP address
+func (g *Guard) Release(attempt *Attempt) error {
+	if err := g.store.Release(EmailKey(attempt.email), attempt.byEmail); err != nil {
+		return err
+	}
+	return g.store.Release(IPKey(attempt.ip), attempt.byIP)
+}
+
+// Succeed forgets the email's failures after a successful login. The IP
+// address keeps its count, so one good account does not cover guessing at others.
+func (g *Guard) Succeed(email string) error {
+	return g.store.Reset(EmailKey(email))
+}
+
+// Unlock lifts the email's lock and forgets its failures, reporting whether it was locked
+func (g *Guard) Unlock(email string) (bool, error) {
+	record, err := g.store.Get(EmailKey(email))
+	if err != nil {
+		return false, err
+	}
+	if err := g.store.Reset(EmailKey(email)); err != nil {
+		return false, err
+	}
+	return record.IsLocked(time.Now()), nil
+}
+
+// emailLimits are the limits for attempts per email address
+func (g *Guard) emailLimits() Limits {
+	return Limits{
+		MaxFailures: g.opts.MaxFailures,
+		Window:      g.opts.LockoutDuration,
+		BaseDelay:   g.opts.BaseDelay,
+		MaxDelay:    g.opts.MaxDelay,
+	}
+}
+
+// ipLimits are the limits for attempts per IP address. IP addresses are only
+// locked, not delayed, so concurrent logins by different users behind one
+// address do not hold each other up.
+func (g *Guard) ipLimits() Limits {
+	return Limits{
+		MaxFailures: g.opts.MaxIPFailures,
+		Window:      g.opts.LockoutDuration,
+	}
+}
+
+// EmailKey is the store key for an email address
+func EmailKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// IPKey is the store key for an IP address
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+var (
+	guard   *Guard
+	guardMu sync.RWMutex
+)
+
+// InitGuard configures the login guard used by the application
+func InitGuard(storeName string, opts Options) error {
+	var store Store
+
+	switch strings.ToLower(storeName) {
+	case "", "memory":
+		store = NewMemoryStore()
+	default:
+		return fmt.Errorf("unsupported login attempt store %q", storeName)
+	}
+
+	SetGuard(NewGuard(store, opts))
+	return nil
+}
+
+// SetGuard replaces the login guard used by the application
+func SetGuard(g *Guard) {
+	guardMu.Lock()
+	defer guardMu.Unlock()
+	guard = g
+}
+
+// GetGuard returns the configured login guard, or nil if none is configured
+func GetGuard() *Guard {
+	guardMu.RLock()
+	defer guardMu.RUnlock()
+	return guard
+}
@@ -0,0 +1,131 @@
+package lockout
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBeginAdmitsOneOfParallelAttemptsForAnEmail(t *testing.T) {
+	guard := NewGuard(NewMemoryStore(), Options{MaxFailures: 5, MaxIPFailures: 100})
+
+	// The first attempt holds the email until it is settled
+	first, err := guard.Begin("user@example.com", "10.0.0.1")
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if !first.Allowed {
+		t.Fatalf("first attempt was not allowed")
+	}
+
+	allowed := countAllowed(t, 20, func(i int) (string, string) {
+		return "user@example.com", fmt.Sprintf("10.0.1.%d", i)
+	}, guard)
+	if allowed != 0 {
+		t.Errorf("%d parallel attempts got in while the first was in progress, want 0", allowed)
+	}
+
+	if _, err := guard.Fail(first); err != nil {
+		t.Fatalf("Fail: %v", err)
+	}
+	next, err := guard.Begin("user@example.com", "10.0.0.1")
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if next.Allowed || next.Locked || next.RetryAfter <= 0 {
+		t.Errorf("got %+v after a failure, want a delay", next.Decision)
+	}
+}
+
+func TestBeginCapsParallelAttemptsFromAnIP(t *testing.T) {
+	guard := NewGuard(NewMemoryStore(), Options{MaxFailures: 5, MaxIPFailures: 10})
+
+	allowed := countAllowed(t, 50, func(i int) (string, string) {
+		return fmt.Sprintf("user%d@example.com", i), "10.0.0.1"
+	}, guard)
+	if allowed != 10 {
+		t.Errorf("%d parallel attempts from one IP got in, want 10", allowed)
+	}
+}
+
+func TestReleaseDoesNotCountTheAttempt(t *testing.T) {
+	guard := NewGuard(NewMemoryStore(), Options{MaxFailures: 2, MaxIPFailures: 2})
+
+	for i := 0; i < 5; i++ {
+		attempt, err := guard.Begin("user@example.com", "10.0.0.1")
+		if err != nil {
+			t.Fatalf("Begin: %v", err)
+		}
+		if !attempt.Allowed {
+			t.Fatalf("attempt %d was not allowed after releasing the earlier ones", i+1)
+		}
+		if err := guard.Release(attempt); err != nil {
+			t.Fatalf("Release: %v", err)
+		}
+	}
+}
+
+func TestFailLocksAtTheLimit(t *testing.T) {
+	store := NewMemoryStore()
+	guard := NewGuard(store, Options{MaxFailures: 3, MaxIPFailures: 100, BaseDelay: time.Nanosecond, MaxDelay: time.Nanosecond})
+
+	var failure Failure
+	for i := 0; i < 3; i++ {
+		time.Sleep(time.Millisecond)
+		attempt, err := guard.Begin("user@example.com", "10.0.0.1")
+		if err != nil {
+			t.Fatalf("Begin: %v", err)
+		}
+		if !attempt.Allowed {
+			t.Fatalf("attempt %d was not allowed", i+1)
+		}
+		if failure, err = guard.Fail(attempt); err != nil {
+			t.Fatalf("Fail: %v", err)
+		}
+	}
+
+	if !failure.EmailLocked || failure.Failures != 3 {
+		t.Errorf("got %+v after 3 failures, want the email locked", failure)
+	}
+	attempt, err := guard.Begin("user@example.com", "10.0.0.2")
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if attempt.Allowed || !attempt.Locked {
+		t.Errorf("got %+v for a locked email, want it locked", attempt.Decision)
+	}
+}
+
+// countAllowed starts n attempts at once and counts those that got in
+func countAllowed(t *testing.T, n int, login func(i int) (email, ip string), guard *Guard) int {
+	t.Helper()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	start := make(chan struct{})
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			attempt, err := guard.Begin(login(i))
+			if err != nil {
+				t.Errorf("Begin: %v", err)
+				return
+			}
+			if attempt.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	return allowed
+}
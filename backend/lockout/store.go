@@ -0,0 +1,197 @@
+package lockout
+
+import (
+	"sync"
+	"time"
+)
+
+// Record is the failed login history of one email address or IP address
+type Record struct {
+	Failures    int       // consecutive failures within the window
+	LastFailure time.Time // zero if there were none
+	LockedUntil time.Time // zero if not locked
+}
+
+// IsLocked reports whether the record is locked at the given time
+func (r Record) IsLocked(now time.Time) bool {
+	return now.Before(r.LockedUntil)
+}
+
+// Limits are the rules a Store applies to one key when reserving an attempt
+type Limits struct {
+	MaxFailures int           // failures, counting attempts still in progress, before the key is locked
+	Window      time.Duration // failures older than this are forgotten, and locks last this long
+	BaseDelay   time.Duration // wait after the first failure, doubled after each further one; zero for none
+	MaxDelay    time.Duration // longest wait between attempts
+}
+
+// Wait returns how long the key must wait before its next attempt at the
+// given time, and whether that is because it is locked
+func (l Limits) Wait(record Record, now time.Time) (time.Duration, bool) {
+	switch {
+	case record.IsLocked(now):
+		return record.LockedUntil.Sub(now), true
+	case expired(record, now, l.Window):
+		return 0, false
+	case record.Failures >= l.MaxFailures:
+		// Attempts still in progress have reached the limit; it is lifted if
+		// they turn out not to fail
+		return record.LastFailure.Add(l.Window).Sub(now), true
+	case record.Failures > 0 && l.BaseDelay > 0:
+		return record.LastFailure.Add(l.delay(record.Failures)).Sub(now), false
+	default:
+		return 0, false
+	}
+}
+
+// delay is how long to wait after the given number of consecutive failures
+func (l Limits) delay(failures int) time.Duration {
+	delay := l.BaseDelay
+	for i := 1; i < failures && delay < l.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > l.MaxDelay {
+		delay = l.MaxDelay
+	}
+	return delay
+}
+
+// Reservation is the outcome of reserving an attempt for a key
+type Reservation struct {
+	Allowed     bool
+	Locked      bool          // the key is locked, not just waiting out a delay
+	RetryAfter  time.Duration // when the next attempt will be allowed, if this one was not
+	Record      Record        // the record with the attempt counted, if it was allowed
+	PrevFailure time.Time     // the last failure before the attempt, restored if it is released
+}
+
+// Store keeps failed login records by key. Implementations must be safe for
+// concurrent use; a shared store lets several instances enforce one limit.
+type Store interface {
+	// Get returns the record for the key, or an empty record if there is none
+	Get(key string) (Record, error)
+	// Reserve counts an attempt at the given time as a failure if the limits
+	// allow one now. Checking and counting are one atomic step, so concurrent
+	// attempts cannot all pass before any of them is counted. A record whose
+	// failures are older than the window, or whose lock has expired, starts over.
+	Reserve(key string, at time.Time, limits Limits) (Reservation, error)
+	// Release takes back an attempt counted by Reserve that did not fail
+	Release(key string, reservation Reservation) error
+	// Lock locks the key until the given time
+	Lock(key string, until time.Time) error
+	// Reset forgets the key's failures and lock
+	Reset(key string) error
+}
+
+// pruneInterval is how often the memory store drops records it no longer needs
+const pruneInterval = time.Minute
+
+// MemoryStore is an in-process Store. Each instance counts its own failures,
+// so limits are per instance when the API runs on several.
+type MemoryStore struct {
+	mu        sync.Mutex
+	records   map[string]Record
+	window    time.Duration
+	lastPrune time.Time
+}
+
+// NewMemoryStore creates an empty in-process store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]Record)}
+}
+
+// Get implements Store
+func (s *MemoryStore) Get(key string) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.records[key], nil
+}
+
+// Reserve implements Store
+func (s *MemoryStore) Reserve(key string, at time.Time, limits Limits) (Reservation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record := s.records[key]
+	if wait, locked := limits.Wait(record, at); wait > 0 {
+		return Reservation{Locked: locked, RetryAfter: wait, Record: record}, nil
+	}
+
+	if expired(record, at, limits.Window) {
+		record = Record{}
+	}
+	reservation := Reservation{Allowed: true, PrevFailure: record.LastFailure}
+	record.Failures++
+	record.LastFailure = at
+	s.records[key] = record
+	reservation.Record = record
+
+	if limits.Window > s.window {
+		s.window = limits.Window
+	}
+	s.prune(at)
+
+	return reservation, nil
+}
+
+// Release implements Store
+func (s *MemoryStore) Release(key string, reservation Reservation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[key]
+	if !ok || record.Failures == 0 {
+		return nil
+	}
+	record.Failures--
+	if record.LastFailure.Equal(reservation.Record.LastFailure) {
+		record.LastFailure = reservation.PrevFailure
+	}
+	if record.Failures == 0 && record.LockedUntil.IsZero() {
+		delete(s.records, key)
+		return nil
+	}
+	s.records[key] = record
+	return nil
+}
+
+// Lock implements Store
+func (s *MemoryStore) Lock(key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record := s.records[key]
+	record.LockedUntil = until
+	s.records[key] = record
+	return nil
+}
+
+// Reset implements Store
+func (s *MemoryStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// prune drops records that would start over anyway. The caller holds s.mu.
+func (s *MemoryStore) prune(now time.Time) {
+	if now.Sub(s.lastPrune) < pruneInterval {
+		return
+	}
+	s.lastPrune = now
+
+	for key, record := range s.records {
+		if expired(record, now, s.window) {
+			delete(s.records, key)
+		}
+	}
+}
+
+// expired reports whether a record's failures no longer count
+func expired(record Record, now time.Time, window time.Duration) bool {
+	if !record.LockedUntil.IsZero() {
+		return !record.IsLocked(now)
+	}
+	return now.Sub(record.LastFailure) >= window
+}
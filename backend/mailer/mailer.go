@@ -0,0 +1,109 @@
+package mailer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidRecipient is returned for messages without a usable recipient address
+	ErrInvalidRecipient = errors.New("invalid recipient")
+	// ErrMailerNotConfigured is returned when no mailer has been initialised
+	ErrMailerNotConfigured = errors.New("mailer is not configured")
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers transactional email such as verification and password reset links
+type Mailer interface {
+	// Send delivers the message, from the mailer's configured sender
+	Send(msg Message) error
+}
+
+// Options configures the mailer created by InitMailer
+type Options struct {
+	Provider     string // smtp, file or memory
+	From         string // sender address
+	Dir          string // where the file mailer writes messages
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// validate rejects messages that cannot be delivered or would inject headers
+func (m Message) validate() error {
+	if m.To == "" || strings.ContainsAny(m.To, "\r\n") {
+		return ErrInvalidRecipient
+	}
+	if strings.ContainsAny(m.Subject, "\r\n") {
+		return errors.New("subject must be a single line")
+	}
+	return nil
+}
+
+// bytes renders the message in RFC 5322 format
+func (m Message) bytes(from string, sentAt time.Time) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", m.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", sentAt.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(m.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return buf.Bytes()
+}
+
+var (
+	mailer   Mailer
+	mailerMu sync.RWMutex
+)
+
+// InitMailer configures the mailer used by the application
+func InitMailer(opts Options) error {
+	var m Mailer
+
+	switch strings.ToLower(opts.Provider) {
+	case "", "file":
+		m = NewFileMailer(opts.Dir, opts.From)
+	case "memory":
+		m = NewMemoryMailer()
+	case "smtp":
+		if opts.SMTPHost == "" {
+			return errors.New("SMTP_HOST is not set")
+		}
+		m = NewSMTPMailer(opts.SMTPHost, opts.SMTPPort, opts.SMTPUsername, opts.SMTPPassword, opts.From)
+	default:
+		return fmt.Errorf("unsupported mailer %q", opts.Provider)
+	}
+
+	SetMailer(m)
+	return nil
+}
+
+// SetMailer replaces the mailer used by the application
+func SetMailer(m Mailer) {
+	mailerMu.Lock()
+	defer mailerMu.Unlock()
+	mailer = m
+}
+
+// GetMailer returns the configured mailer, or nil if none is configured
+func GetMailer() Mailer {
+	mailerMu.RLock()
+	defer mailerMu.RUnlock()
+	return mailer
+}
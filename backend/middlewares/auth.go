@@ -3,22 +3,101 @@ package middlewares
 import (
 	"car-rental-backend/config"
 	"car-rental-backend/models"
+	"car-rental-backend/services"
+	"car-rental-backend/tokens"
+	"errors"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-func GenerateToken(userID string, role models.UserRole, cfg *config.Config) (string, error) {
+// tokenTypeChallenge marks two-factor login challenges, which are not access tokens
+const tokenTypeChallenge = "2fa_challenge"
+
+// ErrInvalidChallenge is returned for two-factor challenge tokens that are malformed, expired or not challenges
+var ErrInvalidChallenge = errors.New("invalid two-factor challenge")
+
+// GenerateToken issues a short-lived access token signed with the current key
+// of the token key manager. The token carries its own ID (jti) and the ID of
+// the refresh token family it was issued to (fid), so either can be revoked,
+// and whether the login passed two-factor authentication (mfa).
+func GenerateToken(userID string, role models.UserRole, familyID uuid.UUID, twoFactor bool, cfg *config.Config) (string, error) {
+	keyManager := tokens.GetKeyManager()
+	if keyManager == nil {
+		return "", tokens.ErrKeyManagerNotConfigured
+	}
+
+	now := time.Now()
 	claims := jwt.MapClaims{
+		"iss":       cfg.JWTIssuer,
+		"sub":       userID,
 		"user_id":   userID,
 		"user_role": string(role),
-		"exp":       time.Now().Add(time.Hour * time.Duration(cfg.JWTExpirationHours)).Unix(),
+		"jti":       uuid.New().String(),
+		"fid":       familyID.String(),
+		"mfa":       twoFactor,
+		"iat":       now.Unix(),
+		"exp":       now.Add(time.Minute * time.Duration(cfg.AccessTokenMinutes)).Unix(),
+	}
+
+	return keyManager.Sign(claims)
+}
+
+// GenerateChallengeToken issues the token a user whose password was accepted
+// exchanges, together with a two-factor code, for an access token. It cannot
+// be used as an access token.
+func GenerateChallengeToken(userID string, cfg *config.Config) (string, time.Time, error) {
+	keyManager := tokens.GetKeyManager()
+	if keyManager == nil {
+		return "", time.Time{}, tokens.ErrKeyManagerNotConfigured
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(time.Minute * time.Duration(cfg.TwoFactorChallengeMinutes))
+	claims := jwt.MapClaims{
+		"iss": cfg.JWTIssuer,
+		"sub": userID,
+		"typ": tokenTypeChallenge,
+		"jti": uuid.New().String(),
+		"iat": now.Unix(),
+		"exp": expiresAt.Unix(),
+	}
+
+	token, err := keyManager.Sign(claims)
+	return token, expiresAt, err
+}
+
+// ParseChallengeToken verifies a two-factor challenge token and returns the
+// user it was issued to, its ID and when it expires
+func ParseChallengeToken(tokenString string, cfg *config.Config) (uuid.UUID, uuid.UUID, time.Time, error) {
+	keyManager := tokens.GetKeyManager()
+	if keyManager == nil {
+		return uuid.Nil, uuid.Nil, time.Time{}, tokens.ErrKeyManagerNotConfigured
+	}
+
+	token, err := jwt.Parse(tokenString, keyManager.Keyfunc,
+		jwt.WithValidMethods(keyManager.ValidMethods()),
+		jwt.WithIssuer(cfg.JWTIssuer),
+	)
+	if err != nil || !token.Valid {
+		return uuid.Nil, uuid.Nil, time.Time{}, ErrInvalidChallenge
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || stringClaim(claims, "typ") != tokenTypeChallenge {
+		return uuid.Nil, uuid.Nil, time.Time{}, ErrInvalidChallenge
+	}
+	userID, userErr := uuid.Parse(stringClaim(claims, "sub"))
+	challengeID, idErr := uuid.Parse(stringClaim(claims, "jti"))
+	expiresAt, expErr := claims.GetExpirationTime()
+	if userErr != nil || idErr != nil || expErr != nil || expiresAt == nil {
+		return uuid.Nil, uuid.Nil, time.Time{}, ErrInvalidChallenge
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(cfg.JWTSecret))
+	return userID, challengeID, expiresAt.Time, nil
 }
 
 func AuthMiddleware(cfg *config.Config) fiber.Handler {
@@ -30,13 +109,19 @@ func AuthMiddleware(cfg *config.Config) fiber.Handler {
 			})
 		}
 
+		keyManager := tokens.GetKeyManager()
+		if keyManager == nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Token verification is not configured",
+			})
+		}
+
+		// Only tokens signed by one of our current or recently rotated keys are accepted
 		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid token signing method")
-			}
-			return []byte(cfg.JWTSecret), nil
-		})
+		token, err := jwt.Parse(tokenString, keyManager.Keyfunc,
+			jwt.WithValidMethods(keyManager.ValidMethods()),
+			jwt.WithIssuer(cfg.JWTIssuer),
+		)
 
 		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
@@ -45,8 +130,36 @@ func AuthMiddleware(cfg *config.Config) fiber.Handler {
 		}
 
 		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			userID := claims["user_id"].(string)
+			userID, _ := claims["user_id"].(string)
+			tokenID, tokenErr := uuid.Parse(stringClaim(claims, "jti"))
+			familyID, familyErr := uuid.Parse(stringClaim(claims, "fid"))
+			expiresAt, expErr := claims.GetExpirationTime()
+			if userID == "" || tokenErr != nil || familyErr != nil || expErr != nil || expiresAt == nil ||
+				stringClaim(claims, "typ") == tokenTypeChallenge {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error": "Invalid token claims",
+				})
+			}
+
+			// Reject tokens revoked by logout, refresh token reuse or a password reset
+			revoked, err := services.NewTokenService().IsRevoked(tokenID, familyID)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to check token revocation",
+				})
+			}
+			if revoked {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error": "Token has been revoked",
+				})
+			}
+
 			c.Locals("user_id", userID)
+			c.Locals("token_id", tokenID)
+			c.Locals("token_family_id", familyID)
+			c.Locals("token_expires_at", expiresAt.Time)
+			twoFactor, _ := claims["mfa"].(bool)
+			c.Locals("two_factor", twoFactor)
 
 			// Extract and set user role
 			if userRole, ok := claims["user_role"].(string); ok {
@@ -63,3 +176,9 @@ func AuthMiddleware(cfg *config.Config) fiber.Handler {
 		})
 	}
 }
+
+// stringClaim returns a string claim, or "" if it is missing
+func stringClaim(claims jwt.MapClaims, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
@@ -0,0 +1,81 @@
+package middlewares
+
+import (
+	"car-rental-backend/models"
+	"car-rental-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Permission names an action a role may perform
+type Permission string
+
+const (
+	PermManageCars     Permission = "cars:manage"     // create, update and delete cars, rate rules and maintenance blocks
+	PermManageBookings Permission = "bookings:manage" // see every booking and move bookings through their lifecycle
+	PermManagePayments Permission = "payments:manage" // capture and refund payments, settle deposits
+	PermManagePricing  Permission = "pricing:manage"  // promotions, tax rates, exchange rates and cancellation policies
+	PermManageOwners   Permission = "owners:manage"   // owner records and their user links
+	PermManagePayouts  Permission = "payouts:manage"  // owner payout statements
+	PermManageUsers    Permission = "users:manage"    // unlock accounts locked out after failed logins
+	PermOwnerPortal    Permission = "owner:portal"    // the owner self-service portal
+)
+
+// rolePermissions lists what each role may do. Users have no extra
+// permissions; what they can do with their own bookings is checked by the
+// handlers.
+var rolePermissions = map[models.UserRole][]Permission{
+	models.UserRoleAdmin: {
+		PermManageCars,
+		PermManageBookings,
+		PermManagePayments,
+		PermManagePricing,
+		PermManageOwners,
+		PermManagePayouts,
+		PermManageUsers,
+	},
+	models.UserRoleOwner: {
+		PermOwnerPortal,
+	},
+}
+
+// HasPermission reports whether the role has the permission
+func HasPermission(role models.UserRole, permission Permission) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// CurrentRole returns the role set by AuthMiddleware
+func CurrentRole(c *fiber.Ctx) models.UserRole {
+	role, _ := c.Locals("user_role").(string)
+	return models.UserRole(role)
+}
+
+// RequirePermission restricts a route to users whose role has all the
+// permissions. Roles that must use two-factor authentication also need a
+// session that passed it.
+func RequirePermission(permissions ...Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role := CurrentRole(c)
+		for _, permission := range permissions {
+			if !HasPermission(role, permission) {
+				return utils.ForbiddenResponse(c, "Not authorized to access this resource")
+			}
+		}
+		if !TwoFactorSatisfied(c) {
+			return twoFactorRequiredResponse(c)
+		}
+		return c.Next()
+	}
+}
+
+// SessionHasPermission reports whether the current session may use the
+// permission: the role has it, and the session passed two-factor
+// authentication if the role requires it
+func SessionHasPermission(c *fiber.Ctx, permission Permission) bool {
+	return HasPermission(CurrentRole(c), permission) && TwoFactorSatisfied(c)
+}
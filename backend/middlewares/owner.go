@@ -0,0 +1,55 @@
+package middlewares
+
+import (
+	"car-rental-backend/services"
+	"car-rental-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// OwnerMiddleware stores the owner an owner-role user acts for in the
+// "owner_id" local. The link is read from the database rather than the token,
+// so unlinking a user takes effect immediately.
+func OwnerMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := uuid.Parse(c.Locals("user_id").(string))
+		if err != nil {
+			return utils.UnauthorizedResponse(c, "Invalid user ID")
+		}
+
+		ownerID, err := services.NewOwnerService().GetOwnerIDForUser(userID)
+		if err != nil {
+			return utils.ForbiddenResponse(c, "User is not linked to an owner")
+		}
+
+		c.Locals("owner_id", ownerID)
+		return c.Next()
+	}
+}
+
+// OwnerCarMiddleware restricts a route to the cars of the owner set by
+// OwnerMiddleware. Other owners' cars are reported as not found.
+func OwnerCarMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ownerID, ok := c.Locals("owner_id").(uuid.UUID)
+		if !ok {
+			return utils.ForbiddenResponse(c, "Only owner users can access this resource")
+		}
+
+		carID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return utils.ValidationErrorResponse(c, "Invalid car ID", []string{"Invalid UUID format"})
+		}
+
+		owns, err := services.NewOwnerService().OwnsCar(ownerID, carID)
+		if err != nil {
+			return utils.ServerErrorResponse(c, "Failed to check car ownership")
+		}
+		if !owns {
+			return utils.NotFoundResponse(c, "Car not found")
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,25 @@
+package middlewares
+
+import (
+	"car-rental-backend/services"
+	"car-rental-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TwoFactorSatisfied reports whether the session meets the two-factor
+// requirement of its role. Sessions of roles that must use two-factor
+// authentication only satisfy it if their login passed it; the flag is set
+// by AuthMiddleware from the access token.
+func TwoFactorSatisfied(c *fiber.Ctx) bool {
+	if !services.IsTwoFactorRequired(CurrentRole(c)) {
+		return true
+	}
+	twoFactor, _ := c.Locals("two_factor").(bool)
+	return twoFactor
+}
+
+// twoFactorRequiredResponse tells the client to log in again with two-factor authentication
+func twoFactorRequiredResponse(c *fiber.Ctx) error {
+	return utils.ForbiddenResponse(c, "Two-factor authentication is required for this resource. Enable it and log in again")
+}
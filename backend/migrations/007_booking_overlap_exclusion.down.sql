@@ -0,0 +1,4 @@
+-- Migration: booking_overlap_exclusion (rollback)
+-- Description: Drop the booking overlap exclusion constraint
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_no_overlap;
@@ -0,0 +1,40 @@
+-- Migration: booking_overlap_exclusion
+-- Description: Prevent overlapping active bookings for the same car at the database level
+
+-- btree_gist lets the exclusion constraint combine equality on car_id with range overlap
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- Bookings that already overlap would keep the constraint from being created.
+-- Which one to keep is a business decision, so the migration lists them and
+-- stops. Cancel or reschedule one booking of each pair, then run it again.
+DO $$
+DECLARE
+    conflicts TEXT;
+BEGIN
+    SELECT string_agg(format('car %s: booking %s overlaps booking %s', a.car_id, a.id, b.id), '; ' ORDER BY a.car_id, a.start_time)
+    INTO conflicts
+    FROM bookings a
+    JOIN bookings b
+        ON b.car_id = a.car_id
+        AND b.id > a.id
+        AND tstzrange(b.start_time, b.end_time, '[)') && tstzrange(a.start_time, a.end_time, '[)')
+    WHERE a.status = 'BOOKED' AND a.deleted_at IS NULL
+        AND b.status = 'BOOKED' AND b.deleted_at IS NULL;
+
+    IF conflicts IS NOT NULL THEN
+        RAISE EXCEPTION 'Overlapping active bookings must be resolved before adding bookings_no_overlap: %', conflicts;
+    END IF;
+END$$;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'bookings_no_overlap') THEN
+        ALTER TABLE bookings
+            ADD CONSTRAINT bookings_no_overlap
+            EXCLUDE USING gist (
+                car_id WITH =,
+                tstzrange(start_time, end_time, '[)') WITH &&
+            )
+            WHERE (status = 'BOOKED' AND deleted_at IS NULL);
+    END IF;
+END$$;
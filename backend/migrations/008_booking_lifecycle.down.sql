@@ -0,0 +1,26 @@
+-- Migration: booking_lifecycle (rollback)
+-- Description: Collapse lifecycle statuses back to BOOKED/CANCELLED/COMPLETED
+
+DROP TABLE IF EXISTS booking_status_history;
+
+ALTER TABLE bookings DROP COLUMN IF EXISTS returned_at;
+ALTER TABLE bookings DROP COLUMN IF EXISTS picked_up_at;
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_no_overlap;
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS check_booking_status;
+
+UPDATE bookings SET status = 'BOOKED' WHERE status IN ('PENDING', 'CONFIRMED', 'PICKED_UP');
+UPDATE bookings SET status = 'COMPLETED' WHERE status = 'RETURNED';
+UPDATE bookings SET status = 'CANCELLED' WHERE status = 'NO_SHOW';
+
+ALTER TABLE bookings
+    ADD CONSTRAINT check_booking_status
+    CHECK (status IN ('BOOKED', 'CANCELLED', 'COMPLETED'));
+
+ALTER TABLE bookings
+    ADD CONSTRAINT bookings_no_overlap
+    EXCLUDE USING gist (
+        car_id WITH =,
+        tstzrange(start_time, end_time, '[)') WITH &&
+    )
+    WHERE (status = 'BOOKED' AND deleted_at IS NULL);
@@ -0,0 +1,50 @@
+-- Migration: booking_lifecycle
+-- Description: Expand booking statuses into a lifecycle state machine and record status history
+
+-- Drop constraints that reference the old status values
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_no_overlap;
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS check_booking_status;
+
+-- Existing active bookings become confirmed bookings
+UPDATE bookings SET status = 'CONFIRMED' WHERE status = 'BOOKED';
+
+ALTER TABLE bookings
+    ADD CONSTRAINT check_booking_status
+    CHECK (status IN ('PENDING', 'CONFIRMED', 'PICKED_UP', 'RETURNED', 'COMPLETED', 'CANCELLED', 'NO_SHOW'));
+
+ALTER TABLE bookings
+    ADD CONSTRAINT bookings_no_overlap
+    EXCLUDE USING gist (
+        car_id WITH =,
+        tstzrange(start_time, end_time, '[)') WITH &&
+    )
+    WHERE (status IN ('PENDING', 'CONFIRMED', 'PICKED_UP') AND deleted_at IS NULL);
+
+-- Track when the car actually left and came back
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS picked_up_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS returned_at TIMESTAMP WITH TIME ZONE;
+
+-- Create booking_status_history table
+CREATE TABLE IF NOT EXISTS booking_status_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    booking_id UUID NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    from_status VARCHAR(20) NOT NULL DEFAULT '',
+    to_status VARCHAR(20) NOT NULL,
+    changed_by UUID NOT NULL REFERENCES users(id),
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_booking_status_history_booking_id ON booking_status_history(booking_id);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'update_booking_status_history_updated_at') THEN
+        CREATE TRIGGER update_booking_status_history_updated_at
+        BEFORE UPDATE ON booking_status_history
+        FOR EACH ROW
+        EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+END$$;
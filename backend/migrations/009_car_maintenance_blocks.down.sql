@@ -0,0 +1,5 @@
+-- Migration: car_maintenance_blocks (rollback)
+-- Description: Drop maintenance blocks
+
+DROP INDEX IF EXISTS idx_bookings_car_id_times;
+DROP TABLE IF EXISTS car_maintenance_blocks;
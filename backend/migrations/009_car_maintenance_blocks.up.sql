@@ -0,0 +1,39 @@
+-- Migration: car_maintenance_blocks
+-- Description: Add maintenance blocks and derive availability from the booking calendar
+
+-- Create car_maintenance_blocks table
+CREATE TABLE IF NOT EXISTS car_maintenance_blocks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    car_id UUID NOT NULL REFERENCES cars(id) ON DELETE CASCADE,
+    start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    reason VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT check_maintenance_times CHECK (end_time > start_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_car_maintenance_blocks_car_id ON car_maintenance_blocks(car_id);
+
+-- Speeds up the overlap checks used by availability queries
+CREATE INDEX IF NOT EXISTS idx_bookings_car_id_times ON bookings(car_id, start_time, end_time);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'update_car_maintenance_blocks_updated_at') THEN
+        CREATE TRIGGER update_car_maintenance_blocks_updated_at
+        BEFORE UPDATE ON car_maintenance_blocks
+        FOR EACH ROW
+        EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+END$$;
+
+-- is_available now only means "in service". Bookings used to clear it, so put
+-- cars that were only marked unavailable because of an active booking back in service.
+UPDATE car_statuses SET is_available = true
+WHERE is_available = false
+AND car_id IN (
+    SELECT car_id FROM bookings
+    WHERE status IN ('PENDING', 'CONFIRMED', 'PICKED_UP') AND deleted_at IS NULL
+);
@@ -0,0 +1,4 @@
+-- Migration: rate_rules (rollback)
+-- Description: Drop rate rules
+
+DROP TABLE IF EXISTS rate_rules;
@@ -0,0 +1,37 @@
+-- Migration: rate_rules
+-- Description: Add date-ranged, weekend and holiday rate rules per car or body type
+
+CREATE TABLE IF NOT EXISTS rate_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    car_id UUID REFERENCES cars(id) ON DELETE CASCADE,
+    body_type VARCHAR(20),
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    start_date DATE,
+    end_date DATE,
+    priority INTEGER NOT NULL DEFAULT 0,
+    rental_price_per_day DECIMAL(10,2) NOT NULL CHECK (rental_price_per_day >= 0),
+    rental_price_per_hour DECIMAL(10,2) NOT NULL CHECK (rental_price_per_hour >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT check_rate_rule_kind CHECK (kind IN ('SEASON', 'WEEKEND', 'HOLIDAY')),
+    CONSTRAINT check_rate_rule_target CHECK ((car_id IS NULL) <> (body_type IS NULL)),
+    CONSTRAINT check_rate_rule_body_type CHECK (body_type IS NULL OR body_type IN ('Sedan', 'SUV', 'Hatchback', 'Coupe', 'Van', 'Truck')),
+    CONSTRAINT check_rate_rule_season CHECK (
+        kind <> 'SEASON' OR (start_date IS NOT NULL AND end_date IS NOT NULL AND end_date >= start_date)
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_rules_car_id ON rate_rules(car_id);
+CREATE INDEX IF NOT EXISTS idx_rate_rules_body_type ON rate_rules(body_type);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'update_rate_rules_updated_at') THEN
+        CREATE TRIGGER update_rate_rules_updated_at
+        BEFORE UPDATE ON rate_rules
+        FOR EACH ROW
+        EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+END$$;
@@ -0,0 +1,9 @@
+-- Migration: promotions (rollback)
+-- Description: Drop promotions and the booking discount columns
+
+ALTER TABLE bookings DROP COLUMN IF EXISTS discount_amount;
+ALTER TABLE bookings DROP COLUMN IF EXISTS promo_code;
+ALTER TABLE bookings DROP COLUMN IF EXISTS promotion_id;
+
+DROP TABLE IF EXISTS promotion_redemptions;
+DROP TABLE IF EXISTS promotions;
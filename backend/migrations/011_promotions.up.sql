@@ -0,0 +1,64 @@
+-- Migration: promotions
+-- Description: Add promo codes, their redemptions and the discount applied to a booking
+
+CREATE TABLE IF NOT EXISTS promotions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(50) NOT NULL,
+    description TEXT,
+    discount_type VARCHAR(20) NOT NULL,
+    discount_value DECIMAL(10,2) NOT NULL CHECK (discount_value > 0),
+    max_discount DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (max_discount >= 0),
+    valid_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    valid_until TIMESTAMP WITH TIME ZONE NOT NULL,
+    max_redemptions INTEGER NOT NULL DEFAULT 0 CHECK (max_redemptions >= 0),
+    max_redemptions_per_user INTEGER NOT NULL DEFAULT 0 CHECK (max_redemptions_per_user >= 0),
+    redemption_count INTEGER NOT NULL DEFAULT 0 CHECK (redemption_count >= 0),
+    eligible_car_ids JSONB,
+    eligible_body_types JSONB,
+    min_duration_hours INTEGER NOT NULL DEFAULT 0 CHECK (min_duration_hours >= 0),
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT check_promotion_discount_type CHECK (discount_type IN ('PERCENTAGE', 'FIXED')),
+    CONSTRAINT check_promotion_percentage CHECK (discount_type <> 'PERCENTAGE' OR discount_value <= 100),
+    CONSTRAINT check_promotion_validity CHECK (valid_until > valid_from)
+);
+
+-- Codes are unique among promotions that have not been deleted
+CREATE UNIQUE INDEX IF NOT EXISTS idx_promotions_code ON promotions(code) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS promotion_redemptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    promotion_id UUID NOT NULL REFERENCES promotions(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+    booking_id UUID NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    amount DECIMAL(10,2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_promotion_redemptions_promotion_user ON promotion_redemptions(promotion_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_promotion_redemptions_booking_id ON promotion_redemptions(booking_id);
+
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS promotion_id UUID REFERENCES promotions(id);
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS promo_code VARCHAR(50);
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS discount_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'update_promotions_updated_at') THEN
+        CREATE TRIGGER update_promotions_updated_at
+        BEFORE UPDATE ON promotions
+        FOR EACH ROW
+        EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'update_promotion_redemptions_updated_at') THEN
+        CREATE TRIGGER update_promotion_redemptions_updated_at
+        BEFORE UPDATE ON promotion_redemptions
+        FOR EACH ROW
+        EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+END$$;
@@ -0,0 +1,5 @@
+-- Migration: booking_charges (rollback)
+-- Description: Drop booking charges
+
+DROP INDEX IF EXISTS idx_bookings_status_end_time;
+DROP TABLE IF EXISTS booking_charges;
@@ -0,0 +1,30 @@
+-- Migration: booking_charges
+-- Description: Add extra charges, such as late fees, recorded against a booking
+
+CREATE TABLE IF NOT EXISTS booking_charges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    booking_id UUID NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL,
+    description VARCHAR(255) NOT NULL,
+    quantity DECIMAL(10,2) NOT NULL DEFAULT 1,
+    unit_price DECIMAL(10,2) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL CHECK (amount >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_booking_charges_booking_id ON booking_charges(booking_id);
+
+-- Speeds up the overdue bookings report
+CREATE INDEX IF NOT EXISTS idx_bookings_status_end_time ON bookings(status, end_time);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'update_booking_charges_updated_at') THEN
+        CREATE TRIGGER update_booking_charges_updated_at
+        BEFORE UPDATE ON booking_charges
+        FOR EACH ROW
+        EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+END$$;
@@ -0,0 +1,5 @@
+-- Migration: deposits (rollback)
+-- Description: Drop the security deposit ledger
+
+DROP TABLE IF EXISTS deposit_entries;
+DROP TABLE IF EXISTS deposits;
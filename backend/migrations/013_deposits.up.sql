@@ -0,0 +1,50 @@
+-- Migration: deposits
+-- Description: Add the security deposit ledger held against each booking
+
+CREATE TABLE IF NOT EXISTS deposits (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    booking_id UUID NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    amount DECIMAL(10,2) NOT NULL CHECK (amount >= 0),
+    captured_amount DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (captured_amount >= 0),
+    refunded_amount DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (refunded_amount >= 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'HELD',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT check_deposit_status CHECK (status IN ('HELD', 'PARTIALLY_CAPTURED', 'CAPTURED', 'RELEASED')),
+    CONSTRAINT check_deposit_balance CHECK (captured_amount + refunded_amount <= amount)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_deposits_booking_id ON deposits(booking_id);
+
+CREATE TABLE IF NOT EXISTS deposit_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    deposit_id UUID NOT NULL REFERENCES deposits(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL CHECK (amount >= 0),
+    reason TEXT NOT NULL DEFAULT '',
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT check_deposit_entry_type CHECK (type IN ('HOLD', 'CAPTURE', 'RELEASE'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_deposit_entries_deposit_id ON deposit_entries(deposit_id);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'update_deposits_updated_at') THEN
+        CREATE TRIGGER update_deposits_updated_at
+        BEFORE UPDATE ON deposits
+        FOR EACH ROW
+        EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'update_deposit_entries_updated_at') THEN
+        CREATE TRIGGER update_deposit_entries_updated_at
+        BEFORE UPDATE ON deposit_entries
+        FOR EACH ROW
+        EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+END$$;
@@ -0,0 +1,5 @@
+-- Migration: payments (rollback)
+-- Description: Drop payments and payment webhook events
+
+DROP TABLE IF EXISTS payment_webhook_events;
+DROP TABLE IF EXISTS payments;
@@ -0,0 +1,5 @@
+-- Migration: cancellation_policies (rollback)
+-- Description: Drop cancellation policies and the booking refund column
+
+ALTER TABLE bookings DROP COLUMN IF EXISTS refund_amount;
+DROP TABLE IF EXISTS cancellation_policies;
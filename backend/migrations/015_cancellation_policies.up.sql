@@ -0,0 +1,30 @@
+-- Migration: cancellation_policies
+-- Description: Add tiered cancellation policies and the refund due on cancelled bookings
+
+CREATE TABLE IF NOT EXISTS cancellation_policies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    car_id UUID REFERENCES cars(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    tiers JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+-- At most one policy per car and one global policy
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cancellation_policies_car_id ON cancellation_policies(car_id)
+    WHERE car_id IS NOT NULL AND deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cancellation_policies_global ON cancellation_policies((car_id IS NULL))
+    WHERE car_id IS NULL AND deleted_at IS NULL;
+
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS refund_amount DECIMAL(10,2);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'update_cancellation_policies_updated_at') THEN
+        CREATE TRIGGER update_cancellation_policies_updated_at
+        BEFORE UPDATE ON cancellation_policies
+        FOR EACH ROW
+        EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+END$$;
@@ -0,0 +1,5 @@
+-- Migration: invoices (rollback)
+-- Description: Drop invoices and the invoice number sequence
+
+DROP TABLE IF EXISTS invoices;
+DROP SEQUENCE IF EXISTS invoice_number_seq;
@@ -0,0 +1,46 @@
+-- Migration: invoices
+-- Description: Add invoices issued for completed bookings, numbered from a sequence
+
+CREATE SEQUENCE IF NOT EXISTS invoice_number_seq START WITH 1 INCREMENT BY 1;
+
+CREATE TABLE IF NOT EXISTS invoices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    booking_id UUID NOT NULL REFERENCES bookings(id),
+    number VARCHAR(20) NOT NULL,
+    issued_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    customer_name VARCHAR(255) NOT NULL,
+    customer_contact VARCHAR(255) NOT NULL DEFAULT '',
+    owner_name VARCHAR(255) NOT NULL,
+    owner_contact VARCHAR(255) NOT NULL DEFAULT '',
+    car_make VARCHAR(100) NOT NULL,
+    car_model VARCHAR(100) NOT NULL,
+    car_year INTEGER NOT NULL,
+    car_vehicle_number VARCHAR(50) NOT NULL,
+    rental_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    rental_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    lines JSONB NOT NULL DEFAULT '[]',
+    subtotal DECIMAL(10,2) NOT NULL,
+    discount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    fees DECIMAL(10,2) NOT NULL DEFAULT 0,
+    taxes DECIMAL(10,2) NOT NULL DEFAULT 0,
+    total DECIMAL(10,2) NOT NULL,
+    deposit_held DECIMAL(10,2) NOT NULL DEFAULT 0,
+    deposit_captured DECIMAL(10,2) NOT NULL DEFAULT 0,
+    deposit_refunded DECIMAL(10,2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_booking_id ON invoices(booking_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_number ON invoices(number);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'update_invoices_updated_at') THEN
+        CREATE TRIGGER update_invoices_updated_at
+        BEFORE UPDATE ON invoices
+        FOR EACH ROW
+        EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+END$$;
@@ -0,0 +1,29 @@
+-- Migration: tax_rates (rollback)
+-- Description: Drop tax rates and the tax columns
+
+DROP INDEX IF EXISTS idx_invoices_issued_at;
+
+ALTER TABLE invoices
+    DROP COLUMN IF EXISTS tax_jurisdiction,
+    DROP COLUMN IF EXISTS tax_rate,
+    DROP COLUMN IF EXISTS tax_inclusive;
+
+ALTER TABLE deposit_entries
+    DROP COLUMN IF EXISTS tax_amount;
+
+ALTER TABLE booking_charges
+    DROP COLUMN IF EXISTS tax_amount;
+
+ALTER TABLE bookings
+    DROP COLUMN IF EXISTS tax_jurisdiction,
+    DROP COLUMN IF EXISTS tax_name,
+    DROP COLUMN IF EXISTS tax_rate,
+    DROP COLUMN IF EXISTS tax_inclusive,
+    DROP COLUMN IF EXISTS tax_on_fees,
+    DROP COLUMN IF EXISTS tax_on_deposits,
+    DROP COLUMN IF EXISTS tax_amount;
+
+ALTER TABLE cars
+    DROP COLUMN IF EXISTS tax_jurisdiction;
+
+DROP TABLE IF EXISTS tax_rates;
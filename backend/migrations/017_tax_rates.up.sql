@@ -0,0 +1,55 @@
+-- Migration: tax_rates
+-- Description: Add tax rates by jurisdiction and store the tax charged on bookings, charges, deposit captures and invoices
+
+CREATE TABLE IF NOT EXISTS tax_rates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    jurisdiction VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    rate DECIMAL(6,3) NOT NULL CHECK (rate >= 0 AND rate <= 100),
+    inclusive BOOLEAN NOT NULL DEFAULT false,
+    tax_fees BOOLEAN NOT NULL DEFAULT false,
+    tax_deposits BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+-- One rate per jurisdiction among rates that have not been deleted
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tax_rates_jurisdiction ON tax_rates(jurisdiction) WHERE deleted_at IS NULL;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'update_tax_rates_updated_at') THEN
+        CREATE TRIGGER update_tax_rates_updated_at
+        BEFORE UPDATE ON tax_rates
+        FOR EACH ROW
+        EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+END$$;
+
+ALTER TABLE cars
+    ADD COLUMN IF NOT EXISTS tax_jurisdiction VARCHAR(50) NOT NULL DEFAULT '';
+
+-- Tax treatment copied from the rate in force when the booking was made
+ALTER TABLE bookings
+    ADD COLUMN IF NOT EXISTS tax_jurisdiction VARCHAR(50) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS tax_name VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS tax_rate DECIMAL(6,3) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax_inclusive BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS tax_on_fees BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS tax_on_deposits BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE booking_charges
+    ADD COLUMN IF NOT EXISTS tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE deposit_entries
+    ADD COLUMN IF NOT EXISTS tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE invoices
+    ADD COLUMN IF NOT EXISTS tax_jurisdiction VARCHAR(50) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS tax_rate DECIMAL(6,3) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax_inclusive BOOLEAN NOT NULL DEFAULT false;
+
+-- Speeds up the tax report
+CREATE INDEX IF NOT EXISTS idx_invoices_issued_at ON invoices(issued_at);
@@ -0,0 +1,85 @@
+-- Migration: money_minor_units (rollback)
+-- Description: Store money as decimals again
+--
+-- Amounts are converted back from minor units of the configured CURRENCY, see
+-- the up migration.
+
+DO $$
+BEGIN
+    IF COALESCE(current_setting('app.minor_unit_scale', true), '') = '' THEN
+        RAISE EXCEPTION 'app.minor_unit_scale is not set, run migrations with bin/migrate.go';
+    END IF;
+END$$;
+
+CREATE OR REPLACE FUNCTION pg_temp.from_minor_units(value BIGINT) RETURNS NUMERIC AS $$
+    SELECT value / current_setting('app.minor_unit_scale')::NUMERIC
+$$ LANGUAGE SQL;
+
+UPDATE invoices SET lines = COALESCE((
+    SELECT jsonb_agg(line
+        || jsonb_build_object('unit_price', pg_temp.from_minor_units((line->'unit_price'->>'amount')::BIGINT)::DECIMAL(10,2))
+        || jsonb_build_object('amount', pg_temp.from_minor_units((line->'amount'->>'amount')::BIGINT)::DECIMAL(10,2))
+        ORDER BY ordinality)
+    FROM jsonb_array_elements(lines) WITH ORDINALITY AS elements(line, ordinality)
+), '[]'::jsonb);
+
+ALTER TABLE invoices
+    ALTER COLUMN subtotal TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(subtotal),
+    ALTER COLUMN discount TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(discount),
+    ALTER COLUMN fees TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(fees),
+    ALTER COLUMN taxes TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(taxes),
+    ALTER COLUMN total TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(total),
+    ALTER COLUMN deposit_held TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(deposit_held),
+    ALTER COLUMN deposit_captured TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(deposit_captured),
+    ALTER COLUMN deposit_refunded TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(deposit_refunded);
+
+ALTER TABLE payments
+    ALTER COLUMN amount TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(amount),
+    ALTER COLUMN captured_amount TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(captured_amount),
+    ALTER COLUMN refunded_amount TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(refunded_amount);
+
+ALTER TABLE deposit_entries
+    ALTER COLUMN amount TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(amount),
+    ALTER COLUMN tax_amount TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(tax_amount);
+
+ALTER TABLE deposits
+    ALTER COLUMN amount TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(amount),
+    ALTER COLUMN captured_amount TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(captured_amount),
+    ALTER COLUMN refunded_amount TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(refunded_amount);
+
+ALTER TABLE promotion_redemptions
+    ALTER COLUMN amount TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(amount);
+
+ALTER TABLE promotions DROP CONSTRAINT IF EXISTS check_promotion_discount;
+UPDATE promotions SET discount_percent = pg_temp.from_minor_units(discount_amount)
+    WHERE discount_type = 'FIXED';
+ALTER TABLE promotions
+    DROP COLUMN IF EXISTS discount_amount,
+    ALTER COLUMN max_discount TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(max_discount);
+ALTER TABLE promotions RENAME COLUMN discount_percent TO discount_value;
+ALTER TABLE promotions
+    ADD CONSTRAINT promotions_discount_value_check CHECK (discount_value > 0),
+    ADD CONSTRAINT check_promotion_percentage CHECK (discount_type <> 'PERCENTAGE' OR discount_value <= 100);
+
+ALTER TABLE booking_charges
+    ALTER COLUMN unit_price TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(unit_price),
+    ALTER COLUMN amount TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(amount),
+    ALTER COLUMN tax_amount TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(tax_amount);
+
+ALTER TABLE bookings
+    ALTER COLUMN total_price TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(total_price),
+    ALTER COLUMN discount_amount TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(discount_amount),
+    ALTER COLUMN refund_amount TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(refund_amount),
+    ALTER COLUMN tax_amount TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(tax_amount);
+
+ALTER TABLE rate_rules
+    ALTER COLUMN rental_price_per_day TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(rental_price_per_day),
+    ALTER COLUMN rental_price_per_hour TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(rental_price_per_hour);
+
+ALTER TABLE car_rental_infos
+    ALTER COLUMN rental_price_per_day TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(rental_price_per_day),
+    ALTER COLUMN rental_price_per_hour TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(rental_price_per_hour),
+    ALTER COLUMN security_deposit TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(security_deposit),
+    ALTER COLUMN late_fee_per_hour TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(late_fee_per_hour),
+    ALTER COLUMN rental_extend_fee_per_day TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(rental_extend_fee_per_day),
+    ALTER COLUMN rental_extend_fee_per_hour TYPE DECIMAL(10,2) USING pg_temp.from_minor_units(rental_extend_fee_per_hour);
@@ -0,0 +1,93 @@
+-- Migration: money_minor_units
+-- Description: Store money as integer minor units of the configured currency instead of decimals
+--
+-- Existing amounts are converted to minor units of the configured CURRENCY,
+-- so 12.34 becomes 1234 in USD, 12 in JPY and 12340 in KWD. bin/migrate.go
+-- sets app.minor_unit_scale from CURRENCY; the migration refuses to run
+-- without it rather than guess.
+
+DO $$
+BEGIN
+    IF COALESCE(current_setting('app.minor_unit_scale', true), '') = '' THEN
+        RAISE EXCEPTION 'app.minor_unit_scale is not set, run migrations with bin/migrate.go';
+    END IF;
+END$$;
+
+CREATE OR REPLACE FUNCTION pg_temp.to_minor_units(value NUMERIC) RETURNS BIGINT AS $$
+    SELECT ROUND(value * current_setting('app.minor_unit_scale')::NUMERIC)::BIGINT
+$$ LANGUAGE SQL;
+
+ALTER TABLE car_rental_infos
+    ALTER COLUMN rental_price_per_day TYPE BIGINT USING pg_temp.to_minor_units(rental_price_per_day),
+    ALTER COLUMN rental_price_per_hour TYPE BIGINT USING pg_temp.to_minor_units(rental_price_per_hour),
+    ALTER COLUMN security_deposit TYPE BIGINT USING pg_temp.to_minor_units(security_deposit),
+    ALTER COLUMN late_fee_per_hour TYPE BIGINT USING pg_temp.to_minor_units(late_fee_per_hour),
+    ALTER COLUMN rental_extend_fee_per_day TYPE BIGINT USING pg_temp.to_minor_units(rental_extend_fee_per_day),
+    ALTER COLUMN rental_extend_fee_per_hour TYPE BIGINT USING pg_temp.to_minor_units(rental_extend_fee_per_hour);
+
+ALTER TABLE rate_rules
+    ALTER COLUMN rental_price_per_day TYPE BIGINT USING pg_temp.to_minor_units(rental_price_per_day),
+    ALTER COLUMN rental_price_per_hour TYPE BIGINT USING pg_temp.to_minor_units(rental_price_per_hour);
+
+ALTER TABLE bookings
+    ALTER COLUMN total_price TYPE BIGINT USING pg_temp.to_minor_units(total_price),
+    ALTER COLUMN discount_amount TYPE BIGINT USING pg_temp.to_minor_units(discount_amount),
+    ALTER COLUMN refund_amount TYPE BIGINT USING pg_temp.to_minor_units(refund_amount),
+    ALTER COLUMN tax_amount TYPE BIGINT USING pg_temp.to_minor_units(tax_amount);
+
+ALTER TABLE booking_charges
+    ALTER COLUMN unit_price TYPE BIGINT USING pg_temp.to_minor_units(unit_price),
+    ALTER COLUMN amount TYPE BIGINT USING pg_temp.to_minor_units(amount),
+    ALTER COLUMN tax_amount TYPE BIGINT USING pg_temp.to_minor_units(tax_amount);
+
+-- Percentage promotions keep their percentage; fixed promotions move their
+-- amount into discount_amount
+ALTER TABLE promotions DROP CONSTRAINT IF EXISTS promotions_discount_value_check;
+ALTER TABLE promotions DROP CONSTRAINT IF EXISTS check_promotion_percentage;
+ALTER TABLE promotions RENAME COLUMN discount_value TO discount_percent;
+ALTER TABLE promotions
+    ADD COLUMN IF NOT EXISTS discount_amount BIGINT NOT NULL DEFAULT 0,
+    ALTER COLUMN max_discount TYPE BIGINT USING pg_temp.to_minor_units(max_discount);
+UPDATE promotions SET discount_amount = pg_temp.to_minor_units(discount_percent), discount_percent = 0
+    WHERE discount_type = 'FIXED';
+ALTER TABLE promotions
+    ADD CONSTRAINT check_promotion_discount CHECK (
+        (discount_type = 'PERCENTAGE' AND discount_percent > 0 AND discount_percent <= 100)
+        OR (discount_type = 'FIXED' AND discount_amount > 0)
+    );
+
+ALTER TABLE promotion_redemptions
+    ALTER COLUMN amount TYPE BIGINT USING pg_temp.to_minor_units(amount);
+
+ALTER TABLE deposits
+    ALTER COLUMN amount TYPE BIGINT USING pg_temp.to_minor_units(amount),
+    ALTER COLUMN captured_amount TYPE BIGINT USING pg_temp.to_minor_units(captured_amount),
+    ALTER COLUMN refunded_amount TYPE BIGINT USING pg_temp.to_minor_units(refunded_amount);
+
+ALTER TABLE deposit_entries
+    ALTER COLUMN amount TYPE BIGINT USING pg_temp.to_minor_units(amount),
+    ALTER COLUMN tax_amount TYPE BIGINT USING pg_temp.to_minor_units(tax_amount);
+
+ALTER TABLE payments
+    ALTER COLUMN amount TYPE BIGINT USING pg_temp.to_minor_units(amount),
+    ALTER COLUMN captured_amount TYPE BIGINT USING pg_temp.to_minor_units(captured_amount),
+    ALTER COLUMN refunded_amount TYPE BIGINT USING pg_temp.to_minor_units(refunded_amount);
+
+ALTER TABLE invoices
+    ALTER COLUMN subtotal TYPE BIGINT USING pg_temp.to_minor_units(subtotal),
+    ALTER COLUMN discount TYPE BIGINT USING pg_temp.to_minor_units(discount),
+    ALTER COLUMN fees TYPE BIGINT USING pg_temp.to_minor_units(fees),
+    ALTER COLUMN taxes TYPE BIGINT USING pg_temp.to_minor_units(taxes),
+    ALTER COLUMN total TYPE BIGINT USING pg_temp.to_minor_units(total),
+    ALTER COLUMN deposit_held TYPE BIGINT USING pg_temp.to_minor_units(deposit_held),
+    ALTER COLUMN deposit_captured TYPE BIGINT USING pg_temp.to_minor_units(deposit_captured),
+    ALTER COLUMN deposit_refunded TYPE BIGINT USING pg_temp.to_minor_units(deposit_refunded);
+
+-- Invoice lines hold amounts in their JSON form, {"amount": <minor units>}
+UPDATE invoices SET lines = COALESCE((
+    SELECT jsonb_agg(line
+        || jsonb_build_object('unit_price', jsonb_build_object('amount', pg_temp.to_minor_units((line->>'unit_price')::NUMERIC)))
+        || jsonb_build_object('amount', jsonb_build_object('amount', pg_temp.to_minor_units((line->>'amount')::NUMERIC)))
+        ORDER BY ordinality)
+    FROM jsonb_array_elements(lines) WITH ORDINALITY AS elements(line, ordinality)
+), '[]'::jsonb);
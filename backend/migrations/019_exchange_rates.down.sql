@@ -0,0 +1,9 @@
+-- Migration: exchange_rates (rollback)
+-- Description: Drop exchange rates and the booking rate snapshot
+
+ALTER TABLE bookings
+    DROP COLUMN IF EXISTS exchange_currency,
+    DROP COLUMN IF EXISTS exchange_rate,
+    DROP COLUMN IF EXISTS exchange_as_of;
+
+DROP TABLE IF EXISTS exchange_rates;
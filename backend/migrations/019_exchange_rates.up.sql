@@ -0,0 +1,30 @@
+-- Migration: exchange_rates
+-- Description: Add admin-uploaded exchange rates and keep the rate a booking was shown prices in
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    currency VARCHAR(3) NOT NULL,
+    rate DECIMAL(20,10) NOT NULL CHECK (rate > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+-- One rate per currency among rates that have not been replaced
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exchange_rates_currency ON exchange_rates(currency) WHERE deleted_at IS NULL;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'update_exchange_rates_updated_at') THEN
+        CREATE TRIGGER update_exchange_rates_updated_at
+        BEFORE UPDATE ON exchange_rates
+        FOR EACH ROW
+        EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+END$$;
+
+-- Snapshot of the rate in force when the booking was made, empty for the stored currency
+ALTER TABLE bookings
+    ADD COLUMN IF NOT EXISTS exchange_currency VARCHAR(3) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS exchange_rate DECIMAL(20,10) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS exchange_as_of TIMESTAMP WITH TIME ZONE;
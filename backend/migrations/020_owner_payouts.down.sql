@@ -0,0 +1,11 @@
+-- Migration: owner_payouts (rollback)
+-- Description: Drop payouts and the revenue share columns
+
+DROP TABLE IF EXISTS payout_lines;
+DROP TABLE IF EXISTS payouts;
+
+ALTER TABLE cars
+    DROP COLUMN IF EXISTS revenue_share_percent;
+
+ALTER TABLE owners
+    DROP COLUMN IF EXISTS revenue_share_percent;
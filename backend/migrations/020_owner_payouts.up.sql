@@ -0,0 +1,68 @@
+-- Migration: owner_payouts
+-- Description: Add owner revenue shares and the payout statements settled from completed bookings
+
+ALTER TABLE owners
+    ADD COLUMN IF NOT EXISTS revenue_share_percent DECIMAL(5,2) NOT NULL DEFAULT 80
+        CHECK (revenue_share_percent >= 0 AND revenue_share_percent <= 100);
+
+-- Overrides the owner's share for a single car
+ALTER TABLE cars
+    ADD COLUMN IF NOT EXISTS revenue_share_percent DECIMAL(5,2)
+        CHECK (revenue_share_percent >= 0 AND revenue_share_percent <= 100);
+
+CREATE TABLE IF NOT EXISTS payouts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner_id UUID NOT NULL REFERENCES owners(id),
+    period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    bookings INTEGER NOT NULL DEFAULT 0,
+    revenue BIGINT NOT NULL DEFAULT 0,
+    amount BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    paid_at TIMESTAMP WITH TIME ZONE,
+    paid_by UUID REFERENCES users(id),
+    reference VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT check_payout_status CHECK (status IN ('PENDING', 'PAID')),
+    CONSTRAINT check_payout_period CHECK (period_end > period_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payouts_owner_id ON payouts(owner_id);
+
+CREATE TABLE IF NOT EXISTS payout_lines (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payout_id UUID NOT NULL REFERENCES payouts(id) ON DELETE CASCADE,
+    booking_id UUID NOT NULL REFERENCES bookings(id),
+    car_id UUID NOT NULL REFERENCES cars(id),
+    invoice_number VARCHAR(20) NOT NULL,
+    share_percent DECIMAL(5,2) NOT NULL,
+    revenue BIGINT NOT NULL,
+    amount BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_payout_lines_payout_id ON payout_lines(payout_id);
+
+-- A booking is settled in at most one payout
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payout_lines_booking_id ON payout_lines(booking_id) WHERE deleted_at IS NULL;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'update_payouts_updated_at') THEN
+        CREATE TRIGGER update_payouts_updated_at
+        BEFORE UPDATE ON payouts
+        FOR EACH ROW
+        EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'update_payout_lines_updated_at') THEN
+        CREATE TRIGGER update_payout_lines_updated_at
+        BEFORE UPDATE ON payout_lines
+        FOR EACH ROW
+        EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+END$$;
@@ -0,0 +1,8 @@
+-- Migration: owner_users (rollback)
+-- Description: Remove the owner link from users
+
+UPDATE users SET role = 'user' WHERE role = 'owner';
+
+DROP INDEX IF EXISTS idx_users_owner_id;
+
+ALTER TABLE users DROP COLUMN IF EXISTS owner_id;
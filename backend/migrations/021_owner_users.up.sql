@@ -0,0 +1,7 @@
+-- Migration: owner_users
+-- Description: Link owner-role users to the owner whose cars they manage
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS owner_id UUID REFERENCES owners(id);
+
+CREATE INDEX IF NOT EXISTS idx_users_owner_id ON users(owner_id);
@@ -0,0 +1,5 @@
+-- Migration: refresh_tokens (rollback)
+-- Description: Drop refresh tokens and the revocation list
+
+DROP TABLE IF EXISTS revoked_tokens;
+DROP TABLE IF EXISTS refresh_tokens;
@@ -0,0 +1,4 @@
+-- Migration: signing_keys (rollback)
+-- Description: Drop the token signing keys
+
+DROP TABLE IF EXISTS signing_keys;
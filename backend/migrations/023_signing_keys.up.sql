@@ -0,0 +1,28 @@
+-- Migration: signing_keys
+-- Description: Add the rotating asymmetric keys access tokens are signed with
+
+CREATE TABLE IF NOT EXISTS signing_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kid VARCHAR(64) NOT NULL,
+    algorithm VARCHAR(10) NOT NULL,
+    public_key TEXT NOT NULL,
+    private_key TEXT NOT NULL,
+    activated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    retired_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT check_signing_key_algorithm CHECK (algorithm IN ('RS256', 'EdDSA'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_signing_keys_kid ON signing_keys(kid);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'update_signing_keys_updated_at') THEN
+        CREATE TRIGGER update_signing_keys_updated_at
+        BEFORE UPDATE ON signing_keys
+        FOR EACH ROW
+        EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+END$$;
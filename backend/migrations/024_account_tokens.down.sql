@@ -0,0 +1,6 @@
+-- Migration: account_tokens (rollback)
+-- Description: Drop account tokens and email verification
+
+DROP TABLE IF EXISTS account_tokens;
+
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
@@ -0,0 +1,4 @@
+-- Migration: audit_logs (rollback)
+-- Description: Drop the audit log
+
+DROP TABLE IF EXISTS audit_logs;
@@ -0,0 +1,4 @@
+-- Migration: password_history (rollback)
+-- Description: Drop the password history
+
+DROP TABLE IF EXISTS password_history;
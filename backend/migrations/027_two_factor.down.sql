@@ -0,0 +1,7 @@
+-- Migration: two_factor (rollback)
+-- Description: Drop two-factor authentication
+
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS two_factor_credentials;
+
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS two_factor;
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountTokenPurpose is what an account token can be used for
+type AccountTokenPurpose string
+
+const (
+	AccountTokenEmailVerification AccountTokenPurpose = "email_verification"
+	AccountTokenPasswordReset     AccountTokenPurpose = "password_reset"
+)
+
+// AccountToken is a single-use, expiring token emailed to a user to verify
+// their address or reset their password. Only the SHA-256 hash of the token is
+// stored.
+type AccountToken struct {
+	Base
+	UserID    uuid.UUID           `json:"user_id" gorm:"index"`
+	Purpose   AccountTokenPurpose `json:"purpose" gorm:"type:varchar(30)"`
+	TokenHash string              `json:"-" gorm:"uniqueIndex"`
+	ExpiresAt time.Time           `json:"expires_at"`
+	UsedAt    *time.Time          `json:"used_at,omitempty"`
+}
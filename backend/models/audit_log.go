@@ -0,0 +1,21 @@
+package models
+
+import "github.com/google/uuid"
+
+// Audit log actions
+const (
+	AuditActionAccountLocked   = "account.locked"
+	AuditActionAccountUnlocked = "account.unlocked"
+	AuditActionIPLocked        = "login.ip_locked"
+)
+
+// AuditLog records a security event such as an account lockout
+type AuditLog struct {
+	Base
+	Action    string     `json:"action" gorm:"type:varchar(50);index"`
+	UserID    *uuid.UUID `json:"user_id,omitempty" gorm:"index"` // the account the event concerns, if known
+	ActorID   *uuid.UUID `json:"actor_id,omitempty"`             // the user who caused it, for admin actions
+	Email     string     `json:"email,omitempty"`
+	IPAddress string     `json:"ip_address,omitempty"`
+	Details   string     `json:"details,omitempty"`
+}
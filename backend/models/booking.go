@@ -1,6 +1,7 @@
 package models
 
 import (
+	"car-rental-backend/money"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,11 +10,50 @@ import (
 type BookingStatus string
 
 const (
-	BookingStatusBooked    BookingStatus = "BOOKED"
-	BookingStatusCancelled BookingStatus = "CANCELLED"
+	BookingStatusPending   BookingStatus = "PENDING"
+	BookingStatusConfirmed BookingStatus = "CONFIRMED"
+	BookingStatusPickedUp  BookingStatus = "PICKED_UP"
+	BookingStatusReturned  BookingStatus = "RETURNED"
 	BookingStatusCompleted BookingStatus = "COMPLETED"
+	BookingStatusCancelled BookingStatus = "CANCELLED"
+	BookingStatusNoShow    BookingStatus = "NO_SHOW"
 )
 
+// ActiveBookingStatuses are the statuses in which a booking holds the car
+var ActiveBookingStatuses = []BookingStatus{
+	BookingStatusPending,
+	BookingStatusConfirmed,
+	BookingStatusPickedUp,
+}
+
+// bookingTransitions lists the statuses each status may move to
+var bookingTransitions = map[BookingStatus][]BookingStatus{
+	BookingStatusPending:   {BookingStatusConfirmed, BookingStatusCancelled},
+	BookingStatusConfirmed: {BookingStatusPickedUp, BookingStatusCancelled, BookingStatusNoShow},
+	BookingStatusPickedUp:  {BookingStatusReturned},
+	BookingStatusReturned:  {BookingStatusCompleted},
+}
+
+// CanTransitionTo reports whether a booking in this status may move to next
+func (s BookingStatus) CanTransitionTo(next BookingStatus) bool {
+	for _, allowed := range bookingTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActive reports whether a booking in this status holds the car
+func (s BookingStatus) IsActive() bool {
+	for _, active := range ActiveBookingStatuses {
+		if s == active {
+			return true
+		}
+	}
+	return false
+}
+
 type Booking struct {
 	Base
 	UserID     uuid.UUID     `json:"user_id"`
@@ -23,7 +63,67 @@ type Booking struct {
 	StartTime  time.Time     `json:"start_time"`
 	EndTime    time.Time     `json:"end_time"`
 	Status     BookingStatus `json:"status" gorm:"type:varchar(20)"`
-	TotalPrice float64       `json:"total_price"`
+	TotalPrice money.Money   `json:"total_price"`
+	PickedUpAt *time.Time    `json:"picked_up_at,omitempty"`
+	ReturnedAt *time.Time    `json:"returned_at,omitempty"`
+
+	// Promotion applied at booking time
+	PromotionID    *uuid.UUID  `json:"promotion_id,omitempty"`
+	PromoCode      string      `json:"promo_code,omitempty"`
+	DiscountAmount money.Money `json:"discount_amount"`
+
+	// Refund due under the cancellation policy, set when the booking is cancelled
+	RefundAmount *money.Money `json:"refund_amount,omitempty"`
+
+	// Tax treatment at booking time and the tax charged so far
+	Tax BookingTax `json:"tax" gorm:"embedded;embeddedPrefix:tax_"`
+
+	// Exchange rate the customer was shown prices in, empty for the stored currency
+	ExchangeRate ExchangeRateSnapshot `json:"exchange_rate" gorm:"embedded;embeddedPrefix:exchange_"`
+
+	Charges  []BookingCharge `json:"charges,omitempty"`
+	Payments []Payment       `json:"payments,omitempty"`
+}
+
+type BookingChargeType string
+
+const (
+	BookingChargeLateFee   BookingChargeType = "LATE_FEE"
+	BookingChargeExtension BookingChargeType = "EXTENSION"
+)
+
+// BookingCharge is an extra amount added to a booking after it was created
+type BookingCharge struct {
+	Base
+	BookingID   uuid.UUID         `json:"booking_id" gorm:"index"`
+	Type        BookingChargeType `json:"type" gorm:"type:varchar(20)"`
+	Description string            `json:"description"`
+	Quantity    float64           `json:"quantity"`
+	UnitPrice   money.Money       `json:"unit_price"`
+	Amount      money.Money       `json:"amount"`
+	TaxAmount   money.Money       `json:"tax_amount"`
+}
+
+// OverdueBooking is a picked-up booking whose car has not come back in time
+type OverdueBooking struct {
+	Booking
+	HoursOverdue   int         `json:"hours_overdue"`
+	AccruedLateFee money.Money `json:"accrued_late_fee"`
+}
+
+// BookingStatusHistory records a single status transition of a booking
+type BookingStatusHistory struct {
+	Base
+	BookingID  uuid.UUID     `json:"booking_id" gorm:"index"`
+	FromStatus BookingStatus `json:"from_status" gorm:"type:varchar(20)"`
+	ToStatus   BookingStatus `json:"to_status" gorm:"type:varchar(20)"`
+	ChangedBy  uuid.UUID     `json:"changed_by"`
+	Note       string        `json:"note,omitempty"`
+}
+
+// TableName overrides the default pluralised table name
+func (BookingStatusHistory) TableName() string {
+	return "booking_status_history"
 }
 
 type BookingResponse struct {
@@ -33,7 +133,7 @@ type BookingResponse struct {
 	StartTime  string        `json:"start_time"`
 	EndTime    string        `json:"end_time"`
 	Status     BookingStatus `json:"status"`
-	TotalPrice float64       `json:"total_price"`
+	TotalPrice money.Money   `json:"total_price"`
 	CreatedAt  string        `json:"created_at"`
 	UpdatedAt  string        `json:"updated_at"`
 }
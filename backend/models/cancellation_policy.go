@@ -0,0 +1,43 @@
+package models
+
+import (
+	"car-rental-backend/money"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// CancellationTier refunds a percentage of the booking price when it is
+// cancelled at least HoursBeforeStart hours before the rental starts
+type CancellationTier struct {
+	HoursBeforeStart int     `json:"hours_before_start"`
+	RefundPercent    float64 `json:"refund_percent"`
+}
+
+// CancellationPolicy decides how much of a booking's price is refunded on
+// cancellation. A policy is attached to a single car, or applies globally to
+// every car without its own policy when CarID is nil.
+type CancellationPolicy struct {
+	Base
+	CarID *uuid.UUID         `json:"car_id,omitempty" gorm:"index"`
+	Name  string             `json:"name"`
+	Tiers []CancellationTier `json:"tiers" gorm:"type:jsonb;serializer:json"`
+}
+
+// RefundFor returns the refund for cancelling a booking of the given price
+// hoursBeforeStart hours before it starts. The tier with the largest threshold
+// that has been met applies; if none has been met, nothing is refunded.
+func (p *CancellationPolicy) RefundFor(price money.Money, hoursBeforeStart float64) money.Money {
+	tiers := make([]CancellationTier, len(p.Tiers))
+	copy(tiers, p.Tiers)
+	sort.Slice(tiers, func(i, j int) bool {
+		return tiers[i].HoursBeforeStart > tiers[j].HoursBeforeStart
+	})
+
+	for _, tier := range tiers {
+		if hoursBeforeStart >= float64(tier.HoursBeforeStart) {
+			return price.Percent(tier.RefundPercent)
+		}
+	}
+	return money.New(0, price.Currency)
+}
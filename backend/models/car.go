@@ -1,6 +1,7 @@
 package models
 
 import (
+	"car-rental-backend/money"
 	"time"
 
 	"github.com/google/uuid"
@@ -32,14 +33,14 @@ const (
 // CarRentalInfo represents the rental-related information
 type CarRentalInfo struct {
 	Base
-	CarID                  uuid.UUID `json:"-" gorm:"index"`
-	RentalPricePerDay      float64   `json:"rental_price_per_day"`
-	RentalPricePerHour     float64   `json:"rental_price_per_hour"`
-	MinimumRentDuration    int       `json:"minimum_rent_duration"` // in hours
-	SecurityDeposit        float64   `json:"security_deposit"`
-	LateFeePerHour         float64   `json:"late_fee_per_hour"`
-	RentalExtendFeePerDay  float64   `json:"rental_extend_fee_per_day"`
-	RentalExtendFeePerHour float64   `json:"rental_extend_fee_per_hour"`
+	CarID                  uuid.UUID   `json:"-" gorm:"index"`
+	RentalPricePerDay      money.Money `json:"rental_price_per_day"`
+	RentalPricePerHour     money.Money `json:"rental_price_per_hour"`
+	MinimumRentDuration    int         `json:"minimum_rent_duration"` // in hours
+	SecurityDeposit        money.Money `json:"security_deposit"`
+	LateFeePerHour         money.Money `json:"late_fee_per_hour"`
+	RentalExtendFeePerDay  money.Money `json:"rental_extend_fee_per_day"`
+	RentalExtendFeePerHour money.Money `json:"rental_extend_fee_per_hour"`
 }
 
 // CarMedia represents the media assets of a car
@@ -52,6 +53,9 @@ type CarMedia struct {
 }
 
 // CarStatus represents the current status and maintenance information
+//
+// IsAvailable marks whether the car is in service at all; whether it can be
+// booked for a given window is derived from its bookings and maintenance blocks.
 type CarStatus struct {
 	Base
 	CarID                  uuid.UUID `json:"-" gorm:"index"`
@@ -60,12 +64,40 @@ type CarStatus struct {
 	DamagesOrIssues        []string  `json:"damages_or_issues,omitempty" gorm:"type:jsonb;serializer:json"`
 }
 
+// CarMaintenanceBlock represents a period during which a car cannot be booked
+type CarMaintenanceBlock struct {
+	Base
+	CarID     uuid.UUID `json:"car_id" gorm:"index"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Reason    string    `json:"reason"`
+}
+
+// CalendarEntryType identifies what occupies a slot in a car's calendar
+type CalendarEntryType string
+
+const (
+	CalendarEntryBooking     CalendarEntryType = "booking"
+	CalendarEntryMaintenance CalendarEntryType = "maintenance"
+)
+
+// CarCalendarEntry is a single occupied window in a car's calendar
+type CarCalendarEntry struct {
+	Type      CalendarEntryType `json:"type"`
+	ID        string            `json:"id"`
+	StartTime time.Time         `json:"start_time"`
+	EndTime   time.Time         `json:"end_time"`
+	Status    string            `json:"status,omitempty"`
+	Reason    string            `json:"reason,omitempty"`
+}
+
 // Owner represents a car owner entity
 type Owner struct {
 	Base
-	Name        string `json:"name"`
-	ContactInfo string `json:"contact_info"`
-	Cars        []Car  `json:"cars,omitempty" gorm:"foreignKey:OwnerID"`
+	Name                string  `json:"name"`
+	ContactInfo         string  `json:"contact_info"`
+	RevenueSharePercent float64 `json:"revenue_share_percent"` // share of rental revenue paid to the owner
+	Cars                []Car   `json:"cars,omitempty" gorm:"foreignKey:OwnerID"`
 }
 
 // Car represents the main car entity with all its details
@@ -83,6 +115,10 @@ type Car struct {
 	Color           string           `json:"color"`
 	SeatingCapacity int              `json:"seating_capacity"`
 	VehicleNumber   string           `json:"vehicle_number" gorm:"uniqueIndex"`
+	TaxJurisdiction string           `json:"tax_jurisdiction,omitempty"` // selects the tax rate its rentals are charged
+
+	// Share of this car's rental revenue paid to the owner, overriding the owner's share
+	RevenueSharePercent *float64 `json:"revenue_share_percent,omitempty"`
 
 	// Relationships
 	OwnerID       uuid.UUID      `json:"owner_id" gorm:"index"`
@@ -113,20 +149,23 @@ type CarResponse struct {
 	OwnerContact string `json:"owner_contact,omitempty"`
 
 	// Rental Info for backward compatibility
-	RentalPricePerDay      float64 `json:"rental_price_per_day,omitempty"`
-	RentalPricePerHour     float64 `json:"rental_price_per_hour,omitempty"`
-	MinimumRentDuration    int     `json:"minimum_rent_duration,omitempty"`
-	SecurityDeposit        float64 `json:"security_deposit,omitempty"`
-	LateFeePerHour         float64 `json:"late_fee_per_hour,omitempty"`
-	RentalExtendFeePerDay  float64 `json:"rental_extend_fee_per_day,omitempty"`
-	RentalExtendFeePerHour float64 `json:"rental_extend_fee_per_hour,omitempty"`
+	RentalPricePerDay      *money.Money `json:"rental_price_per_day,omitempty"`
+	RentalPricePerHour     *money.Money `json:"rental_price_per_hour,omitempty"`
+	MinimumRentDuration    int          `json:"minimum_rent_duration,omitempty"`
+	SecurityDeposit        *money.Money `json:"security_deposit,omitempty"`
+	LateFeePerHour         *money.Money `json:"late_fee_per_hour,omitempty"`
+	RentalExtendFeePerDay  *money.Money `json:"rental_extend_fee_per_day,omitempty"`
+	RentalExtendFeePerHour *money.Money `json:"rental_extend_fee_per_hour,omitempty"`
+
+	// Exchange rate the prices were converted with, if shown in another currency
+	ExchangeRate *ExchangeRateSnapshot `json:"exchange_rate,omitempty"`
 
 	// Media for backward compatibility
 	Images []string `json:"images,omitempty"`
 	Video  *string  `json:"video,omitempty"`
 
 	// Status for backward compatibility
-	IsAvailable            bool     `json:"is_available,omitempty"`
+	IsAvailable            bool     `json:"is_available,omitempty"` // false when the car is out of service
 	CurrentOdometerReading float64  `json:"current_odometer_reading,omitempty"`
 	DamagesOrIssues        []string `json:"damages_or_issues,omitempty"`
 
@@ -161,13 +200,13 @@ func (c *Car) ToCarResponse() CarResponse {
 
 	// Add rental info if available
 	if c.RentalInfo != nil {
-		response.RentalPricePerDay = c.RentalInfo.RentalPricePerDay
-		response.RentalPricePerHour = c.RentalInfo.RentalPricePerHour
+		response.RentalPricePerDay = &c.RentalInfo.RentalPricePerDay
+		response.RentalPricePerHour = &c.RentalInfo.RentalPricePerHour
 		response.MinimumRentDuration = c.RentalInfo.MinimumRentDuration
-		response.SecurityDeposit = c.RentalInfo.SecurityDeposit
-		response.LateFeePerHour = c.RentalInfo.LateFeePerHour
-		response.RentalExtendFeePerDay = c.RentalInfo.RentalExtendFeePerDay
-		response.RentalExtendFeePerHour = c.RentalInfo.RentalExtendFeePerHour
+		response.SecurityDeposit = &c.RentalInfo.SecurityDeposit
+		response.LateFeePerHour = &c.RentalInfo.LateFeePerHour
+		response.RentalExtendFeePerDay = &c.RentalInfo.RentalExtendFeePerDay
+		response.RentalExtendFeePerHour = &c.RentalInfo.RentalExtendFeePerHour
 	}
 
 	// Add media if available
@@ -193,3 +232,21 @@ func (c *Car) ToCarResponse() CarResponse {
 
 	return response
 }
+
+// ConvertPrices shows the response's prices in the currency of the given rate
+func (r *CarResponse) ConvertPrices(rate *ExchangeRate) {
+	for _, price := range []**money.Money{
+		&r.RentalPricePerDay,
+		&r.RentalPricePerHour,
+		&r.SecurityDeposit,
+		&r.LateFeePerHour,
+		&r.RentalExtendFeePerDay,
+		&r.RentalExtendFeePerHour,
+	} {
+		if *price != nil {
+			converted := rate.Convert(**price)
+			*price = &converted
+		}
+	}
+	r.ExchangeRate = rate.Snapshot()
+}
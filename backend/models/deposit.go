@@ -0,0 +1,70 @@
+package models
+
+import (
+	"car-rental-backend/money"
+	"github.com/google/uuid"
+)
+
+type DepositStatus string
+
+const (
+	DepositStatusHeld              DepositStatus = "HELD"
+	DepositStatusPartiallyCaptured DepositStatus = "PARTIALLY_CAPTURED"
+	DepositStatusCaptured          DepositStatus = "CAPTURED"
+	DepositStatusReleased          DepositStatus = "RELEASED"
+)
+
+type DepositEntryType string
+
+const (
+	DepositEntryHold    DepositEntryType = "HOLD"
+	DepositEntryCapture DepositEntryType = "CAPTURE"
+	DepositEntryRelease DepositEntryType = "RELEASE"
+)
+
+// Deposit is the security deposit held against a booking
+type Deposit struct {
+	Base
+	BookingID      uuid.UUID      `json:"booking_id" gorm:"uniqueIndex"`
+	Amount         money.Money    `json:"amount"`
+	CapturedAmount money.Money    `json:"captured_amount"`
+	RefundedAmount money.Money    `json:"refunded_amount"`
+	Status         DepositStatus  `json:"status" gorm:"type:varchar(20)"`
+	Entries        []DepositEntry `json:"entries,omitempty"`
+}
+
+// Balance returns the amount still held
+func (d *Deposit) Balance() (money.Money, error) {
+	settled, err := d.CapturedAmount.Add(d.RefundedAmount)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return d.Amount.Sub(settled)
+}
+
+// IsSettled reports whether no further captures or releases can be made
+func (d *Deposit) IsSettled() bool {
+	return d.Status == DepositStatusCaptured || d.Status == DepositStatusReleased
+}
+
+// DepositEntry is a single movement in a deposit's ledger
+type DepositEntry struct {
+	Base
+	DepositID uuid.UUID        `json:"deposit_id" gorm:"index"`
+	Type      DepositEntryType `json:"type" gorm:"type:varchar(20)"`
+	Amount    money.Money      `json:"amount"`
+	TaxAmount money.Money      `json:"tax_amount"`
+	Reason    string           `json:"reason,omitempty"`
+	CreatedBy uuid.UUID        `json:"created_by"`
+}
+
+// DepositStatement summarises what was held, captured and refunded for a booking
+type DepositStatement struct {
+	BookingID uuid.UUID      `json:"booking_id"`
+	Status    DepositStatus  `json:"status"`
+	Held      money.Money    `json:"held"`
+	Captured  money.Money    `json:"captured"`
+	Refunded  money.Money    `json:"refunded"`
+	Balance   money.Money    `json:"balance"`
+	Entries   []DepositEntry `json:"entries"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"car-rental-backend/money"
+	"time"
+)
+
+// ExchangeRate is how many units of Currency one unit of the currency amounts
+// are stored in buys. Rates are uploaded by admins and used to show prices in
+// the currency a customer asks for; amounts are always charged and stored in
+// the stored currency.
+type ExchangeRate struct {
+	Base
+	Currency string  `json:"currency" gorm:"type:varchar(3)"`
+	Rate     float64 `json:"rate"`
+}
+
+// Convert returns amount in the rate's currency
+func (r *ExchangeRate) Convert(amount money.Money) money.Money {
+	return amount.Convert(r.Currency, r.Rate)
+}
+
+// Snapshot returns the rate as it stands, to be kept with a quote or booking
+func (r *ExchangeRate) Snapshot() *ExchangeRateSnapshot {
+	asOf := r.CreatedAt
+	return &ExchangeRateSnapshot{Currency: r.Currency, Rate: r.Rate, AsOf: &asOf}
+}
+
+// ExchangeRateSnapshot is the exchange rate a quote or booking was shown in
+type ExchangeRateSnapshot struct {
+	Currency string     `json:"currency,omitempty"`
+	Rate     float64    `json:"rate,omitempty"`
+	AsOf     *time.Time `json:"as_of,omitempty"` // when the rate was uploaded
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"car-rental-backend/money"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invoice line types
+const (
+	InvoiceLineRental   = "RENTAL"
+	InvoiceLineDiscount = "DISCOUNT"
+	InvoiceLineCharge   = "CHARGE"
+	InvoiceLineDeposit  = "DEPOSIT_CAPTURE"
+	InvoiceLineTax      = "TAX"
+)
+
+// InvoiceLine is a single line of an invoice
+type InvoiceLine struct {
+	Type        string      `json:"type"`
+	Description string      `json:"description"`
+	Quantity    float64     `json:"quantity"`
+	UnitPrice   money.Money `json:"unit_price"`
+	Amount      money.Money `json:"amount"`
+}
+
+// InvoiceParty is a snapshot of the customer or owner named on an invoice
+type InvoiceParty struct {
+	Name    string `json:"name"`
+	Contact string `json:"contact"`
+}
+
+// InvoiceCar is a snapshot of the rented car named on an invoice
+type InvoiceCar struct {
+	Make          string `json:"make"`
+	Model         string `json:"model"`
+	Year          int    `json:"year"`
+	VehicleNumber string `json:"vehicle_number"`
+}
+
+// Invoice is the immutable record of what a completed booking cost. Everything
+// shown on it is copied at issue time so later edits to the booking, car,
+// owner or customer do not change it.
+type Invoice struct {
+	Base
+	BookingID uuid.UUID `json:"booking_id" gorm:"uniqueIndex"`
+	Number    string    `json:"number" gorm:"uniqueIndex"`
+	IssuedAt  time.Time `json:"issued_at"`
+
+	Customer    InvoiceParty `json:"customer" gorm:"embedded;embeddedPrefix:customer_"`
+	Owner       InvoiceParty `json:"owner" gorm:"embedded;embeddedPrefix:owner_"`
+	Car         InvoiceCar   `json:"car" gorm:"embedded;embeddedPrefix:car_"`
+	RentalStart time.Time    `json:"rental_start"`
+	RentalEnd   time.Time    `json:"rental_end"`
+
+	Lines    []InvoiceLine `json:"lines" gorm:"type:jsonb;serializer:json"`
+	Subtotal money.Money   `json:"subtotal"`
+	Discount money.Money   `json:"discount"`
+	Fees     money.Money   `json:"fees"`
+	Taxes    money.Money   `json:"taxes"`
+	Total    money.Money   `json:"total"`
+
+	TaxJurisdiction string  `json:"tax_jurisdiction,omitempty"`
+	TaxRate         float64 `json:"tax_rate"`
+	TaxInclusive    bool    `json:"tax_inclusive"`
+
+	DepositHeld     money.Money `json:"deposit_held"`
+	DepositCaptured money.Money `json:"deposit_captured"`
+	DepositRefunded money.Money `json:"deposit_refunded"`
+}
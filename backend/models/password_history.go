@@ -0,0 +1,16 @@
+package models
+
+import "github.com/google/uuid"
+
+// PasswordHistory records a password hash a user has had, so recent passwords
+// cannot be chosen again
+type PasswordHistory struct {
+	Base
+	UserID       uuid.UUID `json:"user_id" gorm:"index"`
+	PasswordHash string    `json:"-"`
+}
+
+// TableName overrides the default pluralised table name
+func (PasswordHistory) TableName() string {
+	return "password_history"
+}
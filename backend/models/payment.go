@@ -0,0 +1,41 @@
+package models
+
+import (
+	"car-rental-backend/money"
+	"github.com/google/uuid"
+)
+
+type PaymentStatus string
+
+const (
+	PaymentStatusAuthorized        PaymentStatus = "AUTHORIZED"
+	PaymentStatusCaptured          PaymentStatus = "CAPTURED"
+	PaymentStatusPartiallyRefunded PaymentStatus = "PARTIALLY_REFUNDED"
+	PaymentStatusRefunded          PaymentStatus = "REFUNDED"
+	PaymentStatusFailed            PaymentStatus = "FAILED"
+)
+
+// Payment is money collected from a customer for a booking through a payment provider
+type Payment struct {
+	Base
+	BookingID      uuid.UUID     `json:"booking_id" gorm:"index"`
+	UserID         uuid.UUID     `json:"user_id" gorm:"index"`
+	Provider       string        `json:"provider" gorm:"type:varchar(50)"`
+	Reference      string        `json:"reference" gorm:"type:varchar(255);index"`
+	Amount         money.Money   `json:"amount"`
+	CapturedAmount money.Money   `json:"captured_amount"`
+	RefundedAmount money.Money   `json:"refunded_amount"`
+	Status         PaymentStatus `json:"status" gorm:"type:varchar(20)"`
+	FailureReason  string        `json:"failure_reason,omitempty"`
+}
+
+// PaymentWebhookEvent records a webhook received from a payment provider, so
+// that redelivered events are only applied once
+type PaymentWebhookEvent struct {
+	Base
+	Provider  string `json:"provider" gorm:"type:varchar(50)"`
+	EventID   string `json:"event_id" gorm:"type:varchar(255)"`
+	Type      string `json:"type" gorm:"type:varchar(50)"`
+	Reference string `json:"reference" gorm:"type:varchar(255)"`
+	Payload   string `json:"payload" gorm:"type:jsonb"`
+}
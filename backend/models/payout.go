@@ -0,0 +1,56 @@
+package models
+
+import (
+	"car-rental-backend/money"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultRevenueSharePercent is the share of rental revenue new owners are paid
+const DefaultRevenueSharePercent = 80
+
+type PayoutStatus string
+
+const (
+	PayoutStatusPending PayoutStatus = "PENDING"
+	PayoutStatusPaid    PayoutStatus = "PAID"
+)
+
+// Payout is the statement of what an owner is owed for the bookings of their
+// cars invoiced in a settlement period
+type Payout struct {
+	Base
+	OwnerID     uuid.UUID    `json:"owner_id" gorm:"index"`
+	PeriodStart time.Time    `json:"period_start"`
+	PeriodEnd   time.Time    `json:"period_end"`
+	Bookings    int          `json:"bookings"`
+	Revenue     money.Money  `json:"revenue"` // invoiced amount excluding tax
+	Amount      money.Money  `json:"amount"`  // owner's share of the revenue
+	Status      PayoutStatus `json:"status" gorm:"type:varchar(20)"`
+	PaidAt      *time.Time   `json:"paid_at,omitempty"`
+	PaidBy      *uuid.UUID   `json:"paid_by,omitempty"`
+	Reference   string       `json:"reference,omitempty"` // e.g. the bank transfer reference
+	Lines       []PayoutLine `json:"lines,omitempty"`
+}
+
+// PayoutLine is the owner's share of a single booking. A booking is settled
+// in at most one payout.
+type PayoutLine struct {
+	Base
+	PayoutID      uuid.UUID   `json:"payout_id" gorm:"index"`
+	BookingID     uuid.UUID   `json:"booking_id"`
+	CarID         uuid.UUID   `json:"car_id"`
+	InvoiceNumber string      `json:"invoice_number"`
+	SharePercent  float64     `json:"share_percent"`
+	Revenue       money.Money `json:"revenue"`
+	Amount        money.Money `json:"amount"`
+}
+
+// OwnerEarnings summarises what an owner has been paid and is still owed
+type OwnerEarnings struct {
+	Paid      money.Money `json:"paid"`      // payouts marked as paid
+	Pending   money.Money `json:"pending"`   // payouts settled but not yet paid
+	Unsettled money.Money `json:"unsettled"` // share of completed bookings not yet in a payout
+	Payouts   []Payout    `json:"payouts"`
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"car-rental-backend/money"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DiscountType string
+
+const (
+	DiscountTypePercentage DiscountType = "PERCENTAGE"
+	DiscountTypeFixed      DiscountType = "FIXED"
+)
+
+// Promotion is an admin-managed promo code that discounts a booking
+type Promotion struct {
+	Base
+	Code                  string       `json:"code" gorm:"index:idx_promotions_code,unique,where:deleted_at IS NULL"`
+	Description           string       `json:"description"`
+	DiscountType          DiscountType `json:"discount_type" gorm:"type:varchar(20)"`
+	DiscountPercent       float64      `json:"discount_percent,omitempty"` // PERCENTAGE promotions only
+	DiscountAmount        money.Money  `json:"discount_amount"`            // FIXED promotions only
+	MaxDiscount           money.Money  `json:"max_discount"`               // caps percentage discounts, 0 for no cap
+	ValidFrom             time.Time    `json:"valid_from"`
+	ValidUntil            time.Time    `json:"valid_until"`
+	MaxRedemptions        int          `json:"max_redemptions"`          // 0 for unlimited
+	MaxRedemptionsPerUser int          `json:"max_redemptions_per_user"` // 0 for unlimited
+	RedemptionCount       int          `json:"redemption_count"`
+	EligibleCarIDs        []string     `json:"eligible_car_ids,omitempty" gorm:"type:jsonb;serializer:json"`
+	EligibleBodyTypes     []BodyType   `json:"eligible_body_types,omitempty" gorm:"type:jsonb;serializer:json"`
+	MinDurationHours      int          `json:"min_duration_hours"`
+	IsActive              bool         `json:"is_active" gorm:"default:true"`
+}
+
+// AppliesToCar reports whether the promotion may be used for the given car
+func (p *Promotion) AppliesToCar(car *Car) bool {
+	if len(p.EligibleCarIDs) > 0 {
+		eligible := false
+		for _, id := range p.EligibleCarIDs {
+			if id == car.ID.String() {
+				eligible = true
+				break
+			}
+		}
+		if !eligible {
+			return false
+		}
+	}
+
+	if len(p.EligibleBodyTypes) > 0 {
+		for _, bodyType := range p.EligibleBodyTypes {
+			if bodyType == car.BodyType {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// DiscountFor returns the discount the promotion gives on the given amount
+func (p *Promotion) DiscountFor(amount money.Money) (money.Money, error) {
+	discount := money.New(0, amount.Currency)
+
+	switch p.DiscountType {
+	case DiscountTypePercentage:
+		discount = amount.Percent(p.DiscountPercent)
+		if p.MaxDiscount.IsPositive() {
+			var err error
+			if discount, err = discount.Min(p.MaxDiscount); err != nil {
+				return money.Money{}, err
+			}
+		}
+	case DiscountTypeFixed:
+		discount = p.DiscountAmount
+	}
+
+	return discount.Min(amount)
+}
+
+// PromotionRedemption records a promotion used on a booking
+type PromotionRedemption struct {
+	Base
+	PromotionID uuid.UUID   `json:"promotion_id" gorm:"index"`
+	UserID      uuid.UUID   `json:"user_id" gorm:"index"`
+	BookingID   uuid.UUID   `json:"booking_id" gorm:"index"`
+	Amount      money.Money `json:"amount"`
+}
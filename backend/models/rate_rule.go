@@ -0,0 +1,59 @@
+package models
+
+import (
+	"car-rental-backend/money"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RateRuleKind string
+
+const (
+	RateRuleSeason  RateRuleKind = "SEASON"
+	RateRuleWeekend RateRuleKind = "WEEKEND"
+	RateRuleHoliday RateRuleKind = "HOLIDAY"
+)
+
+// RateRule overrides a car's daily and hourly prices on matching days.
+// A rule is attached either to a single car or to every car of a body type.
+type RateRule struct {
+	Base
+	CarID              *uuid.UUID   `json:"car_id,omitempty" gorm:"index"`
+	BodyType           *BodyType    `json:"body_type,omitempty" gorm:"type:varchar(20)"`
+	Name               string       `json:"name"`
+	Kind               RateRuleKind `json:"kind" gorm:"type:varchar(20)"`
+	StartDate          *time.Time   `json:"start_date,omitempty" gorm:"type:date"` // SEASON rules only
+	EndDate            *time.Time   `json:"end_date,omitempty" gorm:"type:date"`   // inclusive
+	Priority           int          `json:"priority"`
+	RentalPricePerDay  money.Money  `json:"rental_price_per_day"`
+	RentalPricePerHour money.Money  `json:"rental_price_per_hour"`
+}
+
+// Matches reports whether the rule applies on the calendar day of t. Callers
+// convert t to the business timezone first, so the day does not depend on the
+// offset a request was sent with.
+func (r *RateRule) Matches(t time.Time, isHoliday bool) bool {
+	switch r.Kind {
+	case RateRuleWeekend:
+		return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+	case RateRuleHoliday:
+		return isHoliday
+	case RateRuleSeason:
+		if r.StartDate == nil || r.EndDate == nil {
+			return false
+		}
+		day := t.Format("2006-01-02")
+		return day >= r.StartDate.Format("2006-01-02") && day <= r.EndDate.Format("2006-01-02")
+	}
+	return false
+}
+
+// Outranks reports whether r should be applied instead of other when both match.
+// Higher priority wins; on a tie a car-specific rule beats a body-type rule.
+func (r *RateRule) Outranks(other *RateRule) bool {
+	if r.Priority != other.Priority {
+		return r.Priority > other.Priority
+	}
+	return r.CarID != nil && other.CarID == nil
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// SigningKey is an asymmetric key pair used to sign access tokens. The newest
+// unretired key signs; retired keys still verify tokens during the grace period.
+type SigningKey struct {
+	Base
+	Kid         string     `json:"kid" gorm:"uniqueIndex"`
+	Algorithm   string     `json:"algorithm" gorm:"type:varchar(10)"` // RS256 or EdDSA
+	PublicKey   string     `json:"public_key"`                        // base64 PKIX DER
+	PrivateKey  string     `json:"-"`                                 // base64 PKCS#8 DER, encrypted with JWT_KEY_SECRET
+	ActivatedAt time.Time  `json:"activated_at"`
+	RetiredAt   *time.Time `json:"retired_at,omitempty"`
+}
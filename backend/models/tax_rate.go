@@ -0,0 +1,52 @@
+package models
+
+import (
+	"car-rental-backend/money"
+	"time"
+)
+
+// TaxRate is the tax charged on rentals of cars registered to a jurisdiction,
+// such as a state or a branch. With inclusive pricing the car's rates already
+// contain the tax; with exclusive pricing the tax is added on top.
+type TaxRate struct {
+	Base
+	Jurisdiction string  `json:"jurisdiction"`
+	Name         string  `json:"name"`
+	Rate         float64 `json:"rate"` // percentage, e.g. 18 for 18%
+	Inclusive    bool    `json:"inclusive"`
+	TaxFees      bool    `json:"tax_fees"`     // whether late fees are taxed
+	TaxDeposits  bool    `json:"tax_deposits"` // whether amounts kept from the deposit are taxed
+}
+
+// BookingTax is the tax treatment a booking was priced with, copied from the
+// tax rate in force when it was created, and the tax charged on it so far
+type BookingTax struct {
+	Jurisdiction string      `json:"jurisdiction,omitempty"`
+	Name         string      `json:"name,omitempty"`
+	Rate         float64     `json:"rate"`
+	Inclusive    bool        `json:"inclusive"`
+	OnFees       bool        `json:"on_fees"`
+	OnDeposits   bool        `json:"on_deposits"`
+	Amount       money.Money `json:"amount"`
+}
+
+// Exclusive reports whether tax is charged on top of the booking's prices
+func (t BookingTax) Exclusive() bool {
+	return t.Rate > 0 && !t.Inclusive
+}
+
+// TaxReportRow totals the taxes invoiced for one jurisdiction
+type TaxReportRow struct {
+	Jurisdiction string      `json:"jurisdiction"`
+	Invoices     int         `json:"invoices"`
+	Net          money.Money `json:"net"`
+	Taxes        money.Money `json:"taxes"`
+	Total        money.Money `json:"total"`
+}
+
+// TaxReport totals the taxes invoiced in a period by jurisdiction
+type TaxReport struct {
+	From time.Time      `json:"from"`
+	To   time.Time      `json:"to"`
+	Rows []TaxReportRow `json:"rows"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is a single-use token exchanged for a new access token. Each
+// login starts a family; every refresh marks the token used and issues the
+// next one in the same family. Only the SHA-256 hash of the token is stored.
+type RefreshToken struct {
+	Base
+	UserID    uuid.UUID  `json:"user_id" gorm:"index"`
+	FamilyID  uuid.UUID  `json:"family_id" gorm:"index"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`    // when it was exchanged for its successor
+	RevokedAt *time.Time `json:"revoked_at,omitempty"` // when its family was revoked
+	TwoFactor bool       `json:"two_factor"`           // the login passed two-factor authentication
+}
+
+// RevokedToken is an entry in the revocation list checked by AuthMiddleware.
+// TokenID is either an access token's jti or a token family ID, which revokes
+// every access token issued to the family.
+type RevokedToken struct {
+	Base
+	TokenID   uuid.UUID `json:"token_id" gorm:"uniqueIndex"`
+	ExpiresAt time.Time `json:"expires_at"` // entries can be purged once every token they cover has expired
+	Reason    string    `json:"reason"`
+}
+
+// Token revocation reasons
+const (
+	RevocationReasonLogout         = "logout"
+	RevocationReasonReuse          = "refresh token reuse"
+	RevocationReasonPasswordReset  = "password reset"
+	RevocationReasonPasswordChange = "password change"
+	RevocationReasonChallengeUsed  = "two-factor challenge used"
+	RevocationReasonTwoFactorOff   = "two-factor disabled"
+)
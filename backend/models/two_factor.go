@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TwoFactorCredential is a user's TOTP authenticator. It is pending until the
+// user confirms enrollment with a code, and only confirmed credentials are
+// asked for at login.
+type TwoFactorCredential struct {
+	Base
+	UserID       uuid.UUID  `json:"user_id" gorm:"uniqueIndex"`
+	Secret       string     `json:"-"` // base32 TOTP secret, AES-256-GCM encrypted
+	ConfirmedAt  *time.Time `json:"confirmed_at,omitempty"`
+	LastUsedStep int64      `json:"-"` // time step of the last accepted code, so a code works once
+}
+
+// IsConfirmed reports whether enrollment has been completed
+func (c *TwoFactorCredential) IsConfirmed() bool {
+	return c.ConfirmedAt != nil
+}
+
+// RecoveryCode is a single-use code that replaces a TOTP code when the user
+// has lost their authenticator. Only the SHA-256 hash of the code is stored.
+type RecoveryCode struct {
+	Base
+	UserID   uuid.UUID  `json:"user_id" gorm:"index"`
+	CodeHash string     `json:"-" gorm:"uniqueIndex"`
+	UsedAt   *time.Time `json:"used_at,omitempty"`
+}
+
+// TwoFactorStatus describes a user's two-factor authentication setup
+type TwoFactorStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	Required               bool       `json:"required"` // the user's role must use two-factor authentication
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorEnrollment is what a user needs to add the authenticator to their app
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth:// URI to show as a QR code
+}
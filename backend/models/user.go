@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -11,15 +12,23 @@ type UserRole string
 const (
 	UserRoleUser  UserRole = "user"
 	UserRoleAdmin UserRole = "admin"
+	UserRoleOwner UserRole = "owner"
 )
 
 type User struct {
 	Base
-	Name         string    `json:"name"`
-	Email        string    `gorm:"index:idx_users_email,unique,where:deleted_at IS NULL" json:"email"`
-	PasswordHash string    `json:"-"`
-	Role         UserRole  `json:"role" gorm:"type:varchar(20);default:'user'"`
-	Bookings     []Booking `json:"bookings,omitempty"`
+	Name            string     `json:"name"`
+	Email           string     `gorm:"index:idx_users_email,unique,where:deleted_at IS NULL" json:"email"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"` // users cannot log in until they verify their email
+	PasswordHash    string     `json:"-"`
+	Role            UserRole   `json:"role" gorm:"type:varchar(20);default:'user'"`
+	OwnerID         *uuid.UUID `json:"owner_id,omitempty" gorm:"index"` // the owner an owner-role user acts for
+	Bookings        []Booking  `json:"bookings,omitempty"`
+}
+
+// IsEmailVerified reports whether the user has verified their email address
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
 }
 
 func (u *User) SetPassword(password string) error {
@@ -37,9 +46,11 @@ func (u *User) CheckPassword(password string) bool {
 }
 
 type UserResponse struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Role      UserRole  `json:"role"`
-	CreatedAt time.Time `json:"created_at"`
+	ID              string     `json:"id"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	Role            UserRole   `json:"role"`
+	OwnerID         *uuid.UUID `json:"owner_id,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
 }
@@ -0,0 +1,332 @@
+// Package money represents amounts of money as integer minor units of an ISO
+// 4217 currency, so prices never pick up floating point fractions of a cent.
+//
+// Every operation that cannot be exact (percentages, ratios, converting from a
+// decimal amount) rounds half away from zero to the nearest minor unit.
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrCurrencyMismatch is returned when amounts in different currencies are combined or stored
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	// ErrInvalidCurrency is returned for a currency code that is not three letters
+	ErrInvalidCurrency = errors.New("invalid currency code")
+)
+
+// minorUnitDigits lists the currencies whose minor unit is not a hundredth
+var minorUnitDigits = map[string]int{
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+}
+
+var (
+	defaultCurrency   = "USD"
+	defaultCurrencyMu sync.RWMutex
+)
+
+// SetDefaultCurrency sets the currency amounts are stored in and that amounts
+// without a currency are assumed to be in
+func SetDefaultCurrency(currency string) error {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !ValidCurrency(currency) {
+		return fmt.Errorf("%w %q", ErrInvalidCurrency, currency)
+	}
+
+	defaultCurrencyMu.Lock()
+	defer defaultCurrencyMu.Unlock()
+	defaultCurrency = currency
+	return nil
+}
+
+// DefaultCurrency returns the currency amounts are stored in
+func DefaultCurrency() string {
+	defaultCurrencyMu.RLock()
+	defer defaultCurrencyMu.RUnlock()
+	return defaultCurrency
+}
+
+// ValidCurrency reports whether code looks like an ISO 4217 currency code
+func ValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// MinorUnitDigits returns the number of decimal places of a currency's minor unit
+func MinorUnitDigits(currency string) int {
+	if digits, ok := minorUnitDigits[currency]; ok {
+		return digits
+	}
+	return 2
+}
+
+// Money is an amount in minor units (e.g. cents) of a currency. The zero value
+// is zero in the default currency.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// New returns an amount of minor units in the given currency
+func New(minor int64, currency string) Money {
+	return Money{Amount: minor, Currency: currency}
+}
+
+// Zero returns zero in the default currency
+func Zero() Money {
+	return Money{Currency: DefaultCurrency()}
+}
+
+// FromMinor returns an amount of minor units in the default currency
+func FromMinor(minor int64) Money {
+	return Money{Amount: minor, Currency: DefaultCurrency()}
+}
+
+// currency returns m's currency, treating an empty one as the default
+func (m Money) currency() string {
+	if m.Currency == "" {
+		return DefaultCurrency()
+	}
+	return m.Currency
+}
+
+// same returns the currency shared by m and other, or ErrCurrencyMismatch if
+// they are in different currencies
+func (m Money) same(other Money) (string, error) {
+	a, b := m.currency(), other.currency()
+	if a != b {
+		return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, a, b)
+	}
+	return a, nil
+}
+
+// Add returns m + other. Amounts in different currencies cannot be added.
+func (m Money) Add(other Money) (Money, error) {
+	currency, err := m.same(other)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: currency}, nil
+}
+
+// Sub returns m - other. Amounts in different currencies cannot be subtracted.
+func (m Money) Sub(other Money) (Money, error) {
+	currency, err := m.same(other)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount - other.Amount, Currency: currency}, nil
+}
+
+// Neg returns -m
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.currency()}
+}
+
+// Mul returns m multiplied by a whole quantity
+func (m Money) Mul(quantity int64) Money {
+	return Money{Amount: m.Amount * quantity, Currency: m.currency()}
+}
+
+// Percent returns percent% of m. The percentage is taken to a thousandth of a
+// percent and the result rounded half away from zero.
+func (m Money) Percent(percent float64) Money {
+	return Money{Amount: divRound(m.Amount*milli(percent), 100_000), Currency: m.currency()}
+}
+
+// PercentOfGross returns the part of m that is percent% tax on top of a net
+// amount, i.e. m * percent / (100 + percent), rounded half away from zero
+func (m Money) PercentOfGross(percent float64) Money {
+	rate := milli(percent)
+	return Money{Amount: divRound(m.Amount*rate, 100_000+rate), Currency: m.currency()}
+}
+
+// Convert returns m in another currency, where rate is how many units of
+// currency one unit of m's currency buys. The result is for display and is
+// rounded half away from zero to the nearest minor unit of currency.
+func (m Money) Convert(currency string, rate float64) Money {
+	from := m.currency()
+	if currency == from {
+		return Money{Amount: m.Amount, Currency: from}
+	}
+	scale := math.Pow10(MinorUnitDigits(currency) - MinorUnitDigits(from))
+	return Money{Amount: int64(math.Round(float64(m.Amount) * rate * scale)), Currency: currency}
+}
+
+// SameCurrency reports whether m and other are in the same currency
+func (m Money) SameCurrency(other Money) bool {
+	return m.currency() == other.currency()
+}
+
+// IsZero reports whether m is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsPositive reports whether m is greater than zero
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// IsNegative reports whether m is less than zero
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// GreaterThan reports whether m > other. Amounts in different currencies
+// cannot be compared.
+func (m Money) GreaterThan(other Money) (bool, error) {
+	if _, err := m.same(other); err != nil {
+		return false, err
+	}
+	return m.Amount > other.Amount, nil
+}
+
+// LessThan reports whether m < other. Amounts in different currencies cannot
+// be compared.
+func (m Money) LessThan(other Money) (bool, error) {
+	if _, err := m.same(other); err != nil {
+		return false, err
+	}
+	return m.Amount < other.Amount, nil
+}
+
+// Min returns the smaller of m and other. Amounts in different currencies
+// cannot be compared.
+func (m Money) Min(other Money) (Money, error) {
+	less, err := other.LessThan(m)
+	if err != nil {
+		return Money{}, err
+	}
+	if less {
+		return other, nil
+	}
+	return m, nil
+}
+
+// Decimal formats m in major units without the currency, e.g. "1234.50"
+func (m Money) Decimal() string {
+	digits := MinorUnitDigits(m.currency())
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	if digits == 0 {
+		return fmt.Sprintf("%s%d", sign, amount)
+	}
+
+	scale := int64(math.Pow10(digits))
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/scale, digits, amount%scale)
+}
+
+// String formats m with its currency, e.g. "1234.50 USD"
+func (m Money) String() string {
+	return m.Decimal() + " " + m.currency()
+}
+
+// moneyJSON is the JSON form of Money
+type moneyJSON struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON encodes m as {"amount": <minor units>, "currency": "<code>"}
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Amount, Currency: m.currency()})
+}
+
+// UnmarshalJSON decodes {"amount": <minor units>, "currency": "<code>"}. The
+// currency may be omitted, in which case the default currency is assumed.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("money must be an object with an integer amount in minor units: %w", err)
+	}
+
+	v.Currency = strings.ToUpper(v.Currency)
+	if v.Currency == "" {
+		v.Currency = DefaultCurrency()
+	}
+	if !ValidCurrency(v.Currency) {
+		return fmt.Errorf("%w %q", ErrInvalidCurrency, v.Currency)
+	}
+
+	*m = Money{Amount: v.Amount, Currency: v.Currency}
+	return nil
+}
+
+// Value stores m as an integer number of minor units. Amounts are stored in
+// the default currency, so storing any other currency is an error.
+func (m Money) Value() (driver.Value, error) {
+	if m.currency() != DefaultCurrency() {
+		return nil, fmt.Errorf("%w: cannot store %s amount in %s column", ErrCurrencyMismatch, m.Currency, DefaultCurrency())
+	}
+	return m.Amount, nil
+}
+
+// Scan reads an integer number of minor units in the default currency. Sums of
+// integer columns come back from the database as numerics, so whole decimal
+// strings are accepted too.
+func (m *Money) Scan(src interface{}) error {
+	var amount int64
+
+	switch v := src.(type) {
+	case nil:
+		amount = 0
+	case int64:
+		amount = v
+	case int32:
+		amount = int64(v)
+	case float64:
+		amount = int64(math.Round(v))
+	case []byte:
+		return m.Scan(string(v))
+	case string:
+		whole, _, _ := strings.Cut(v, ".")
+		parsed, err := strconv.ParseInt(whole, 10, 64)
+		if err != nil {
+			return fmt.Errorf("cannot scan %q into money: %w", v, err)
+		}
+		amount = parsed
+	default:
+		return fmt.Errorf("cannot scan %T into money", src)
+	}
+
+	*m = Money{Amount: amount, Currency: DefaultCurrency()}
+	return nil
+}
+
+// milli converts a percentage to thousandths of a percent
+func milli(percent float64) int64 {
+	return int64(math.Round(percent * 1000))
+}
+
+// divRound divides n by d, rounding half away from zero
+func divRound(n, d int64) int64 {
+	if d < 0 {
+		n, d = -n, -d
+	}
+	if n < 0 {
+		return -((-n + d/2) / d)
+	}
+	return (n + d/2) / d
+}
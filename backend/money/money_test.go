@@ -0,0 +1,136 @@
+package money
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  int64
+		percent float64
+		want    int64
+	}{
+		{"whole percent", 10000, 10, 1000},
+		{"rounds down below half", 1004, 10, 100},
+		{"rounds half up", 1005, 10, 101},
+		{"rounds negative half away from zero", -1005, 10, -101},
+		{"thousandth of a percent", 100000, 7.125, 7125},
+		{"finer percent is rounded to a thousandth", 100000, 7.1254, 7125},
+		{"zero percent", 12345, 0, 0},
+		{"over a hundred percent", 1000, 150, 1500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := New(tt.amount, "USD").Percent(tt.percent)
+			if got != New(tt.want, "USD") {
+				t.Errorf("got %v, want %v", got, New(tt.want, "USD"))
+			}
+		})
+	}
+}
+
+func TestPercentOfGross(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  int64
+		percent float64
+		want    int64
+	}{
+		{"exact", 11000, 10, 1000},
+		{"rounds up above half", 1000, 20, 167},
+		{"rounds half up", 1, 100, 1},
+		{"rounds negative half away from zero", -1, 100, -1},
+		{"fractional percent", 10825, 8.25, 825},
+		{"zero percent", 12345, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := New(tt.amount, "USD").PercentOfGross(tt.percent)
+			if got != New(tt.want, "USD") {
+				t.Errorf("got %v, want %v", got, New(tt.want, "USD"))
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount Money
+		to     string
+		rate   float64
+		want   Money
+	}{
+		{"same currency is unchanged", New(1234, "USD"), "USD", 2, New(1234, "USD")},
+		{"same minor unit", New(1000, "USD"), "EUR", 0.9, New(900, "EUR")},
+		{"rounds half up", New(1, "USD"), "EUR", 0.5, New(1, "EUR")},
+		{"rounds negative half away from zero", New(-1, "USD"), "EUR", 0.5, New(-1, "EUR")},
+		{"to a currency without minor units", New(1050, "USD"), "JPY", 150, New(1575, "JPY")},
+		{"from a currency without minor units", New(1575, "JPY"), "USD", 1.0 / 150, New(1050, "USD")},
+		{"to a currency with three decimals", New(1000, "USD"), "KWD", 0.307, New(3070, "KWD")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.amount.Convert(tt.to, tt.rate)
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDivRound(t *testing.T) {
+	tests := []struct {
+		n, d, want int64
+	}{
+		{10, 5, 2},
+		{7, 2, 4},
+		{5, 3, 2},
+		{4, 3, 1},
+		{-7, 2, -4},
+		{-4, 3, -1},
+		{7, -2, -4},
+		{-7, -2, 4},
+		{0, 3, 0},
+		{1, 3, 0},
+	}
+
+	for _, tt := range tests {
+		if got := divRound(tt.n, tt.d); got != tt.want {
+			t.Errorf("divRound(%d, %d) = %d, want %d", tt.n, tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestCurrencyMismatch(t *testing.T) {
+	usd, eur := New(100, "USD"), New(100, "EUR")
+
+	if _, err := usd.Add(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add: got error %v, want %v", err, ErrCurrencyMismatch)
+	}
+	if _, err := usd.Sub(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sub: got error %v, want %v", err, ErrCurrencyMismatch)
+	}
+	if _, err := usd.GreaterThan(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("GreaterThan: got error %v, want %v", err, ErrCurrencyMismatch)
+	}
+	if _, err := usd.LessThan(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("LessThan: got error %v, want %v", err, ErrCurrencyMismatch)
+	}
+	if _, err := usd.Min(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Min: got error %v, want %v", err, ErrCurrencyMismatch)
+	}
+
+	sum, err := usd.Add(New(50, ""))
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if sum != New(150, "USD") {
+		t.Errorf("got %v, want an empty currency to be the default", sum)
+	}
+}
@@ -0,0 +1,144 @@
+package payments
+
+import (
+	"car-rental-backend/money"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// FakeDeclinedPaymentMethod is the payment method token the fake gateway always declines
+const FakeDeclinedPaymentMethod = "tok_decline"
+
+// fakePayment is the fake gateway's record of a payment
+type fakePayment struct {
+	authorized money.Money
+	captured   money.Money
+	refunded   money.Money
+}
+
+// FakeGateway is an in-process gateway for tests and local development. It
+// keeps payments in memory, approves every payment method except
+// FakeDeclinedPaymentMethod and signs webhooks with HMAC-SHA256.
+type FakeGateway struct {
+	secret   []byte
+	mu       sync.Mutex
+	payments map[string]*fakePayment
+}
+
+// NewFakeGateway creates a fake gateway that signs webhooks with the given secret
+func NewFakeGateway(webhookSecret string) *FakeGateway {
+	return &FakeGateway{
+		secret:   []byte(webhookSecret),
+		payments: make(map[string]*fakePayment),
+	}
+}
+
+// Name implements PaymentGateway
+func (g *FakeGateway) Name() string {
+	return "fake"
+}
+
+// Authorize implements PaymentGateway
+func (g *FakeGateway) Authorize(req AuthorizeRequest) (*Result, error) {
+	if !req.Amount.IsPositive() {
+		return nil, ErrInvalidAmount
+	}
+	if req.PaymentMethod == FakeDeclinedPaymentMethod {
+		return nil, ErrPaymentDeclined
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	reference := "fake_" + uuid.NewString()
+	g.payments[reference] = &fakePayment{
+		authorized: req.Amount,
+		captured:   money.New(0, req.Amount.Currency),
+		refunded:   money.New(0, req.Amount.Currency),
+	}
+
+	return &Result{Reference: reference, Amount: req.Amount, Status: "authorized"}, nil
+}
+
+// Capture implements PaymentGateway
+func (g *FakeGateway) Capture(reference string, amount money.Money) (*Result, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	payment, ok := g.payments[reference]
+	if !ok {
+		return nil, ErrUnknownReference
+	}
+	if !amount.SameCurrency(payment.authorized) {
+		return nil, fmt.Errorf("%w: capture in %s of a %s payment", ErrInvalidAmount, amount.Currency, payment.authorized.Currency)
+	}
+	captured, err := payment.captured.Add(amount)
+	if err != nil {
+		return nil, err
+	}
+	if exceeds, err := captured.GreaterThan(payment.authorized); err != nil {
+		return nil, err
+	} else if !amount.IsPositive() || exceeds {
+		return nil, fmt.Errorf("%w: capture exceeds authorized amount", ErrInvalidAmount)
+	}
+
+	payment.captured = captured
+	return &Result{Reference: reference, Amount: amount, Status: "captured"}, nil
+}
+
+// Refund implements PaymentGateway
+func (g *FakeGateway) Refund(reference string, amount money.Money) (*Result, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	payment, ok := g.payments[reference]
+	if !ok {
+		return nil, ErrUnknownReference
+	}
+	if !amount.SameCurrency(payment.captured) {
+		return nil, fmt.Errorf("%w: refund in %s of a %s payment", ErrInvalidAmount, amount.Currency, payment.captured.Currency)
+	}
+	refunded, err := payment.refunded.Add(amount)
+	if err != nil {
+		return nil, err
+	}
+	if exceeds, err := refunded.GreaterThan(payment.captured); err != nil {
+		return nil, err
+	} else if !amount.IsPositive() || exceeds {
+		return nil, fmt.Errorf("%w: refund exceeds captured amount", ErrInvalidAmount)
+	}
+
+	payment.refunded = refunded
+	return &Result{Reference: reference, Amount: amount, Status: "refunded"}, nil
+}
+
+// VerifyWebhook implements PaymentGateway
+func (g *FakeGateway) VerifyWebhook(payload []byte, signature string) (*WebhookEvent, error) {
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, g.sign(payload)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	return &event, nil
+}
+
+// SignWebhook returns the signature the fake gateway expects for a webhook payload
+func (g *FakeGateway) SignWebhook(payload []byte) string {
+	return hex.EncodeToString(g.sign(payload))
+}
+
+func (g *FakeGateway) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
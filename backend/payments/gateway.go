@@ -0,0 +1,126 @@
+package payments
+
+import (
+	"car-rental-backend/money"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrPaymentDeclined is returned when the provider refuses to authorize a payment
+	ErrPaymentDeclined = errors.New("payment declined")
+	// ErrUnknownReference is returned when the provider has no payment with the given reference
+	ErrUnknownReference = errors.New("unknown payment reference")
+	// ErrInvalidAmount is returned when a capture or refund amount is not allowed
+	ErrInvalidAmount = errors.New("invalid payment amount")
+	// ErrInvalidSignature is returned when a webhook payload fails signature verification
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrGatewayNotConfigured is returned when no gateway has been initialised
+	ErrGatewayNotConfigured = errors.New("payment gateway is not configured")
+)
+
+// Webhook event types
+const (
+	EventAuthorized = "payment.authorized"
+	EventCaptured   = "payment.captured"
+	EventRefunded   = "payment.refunded"
+	EventFailed     = "payment.failed"
+)
+
+// AuthorizeRequest describes a payment to authorize
+type AuthorizeRequest struct {
+	Amount         money.Money
+	PaymentMethod  string // provider token for the customer's card or wallet
+	Description    string
+	IdempotencyKey string
+}
+
+// Result is the provider's response to a payment operation
+type Result struct {
+	Reference string      `json:"reference"`
+	Amount    money.Money `json:"amount"`
+	Status    string      `json:"status"`
+}
+
+// WebhookEvent is a verified notification sent by the provider
+type WebhookEvent struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Reference string `json:"reference"`
+	Amount    int64  `json:"amount"`   // minor units
+	Currency  string `json:"currency"` // ISO 4217, the default currency if empty
+}
+
+// Money returns the event's amount
+func (e *WebhookEvent) Money() money.Money {
+	currency := strings.ToUpper(e.Currency)
+	if currency == "" {
+		currency = money.DefaultCurrency()
+	}
+	return money.New(e.Amount, currency)
+}
+
+// PaymentGateway is a payment provider that can move money for bookings
+type PaymentGateway interface {
+	// Name identifies the provider in stored payments
+	Name() string
+	// Authorize reserves the amount on the customer's payment method
+	Authorize(req AuthorizeRequest) (*Result, error)
+	// Capture collects up to the authorized amount
+	Capture(reference string, amount money.Money) (*Result, error)
+	// Refund returns up to the captured amount to the customer
+	Refund(reference string, amount money.Money) (*Result, error)
+	// VerifyWebhook checks the payload signature and decodes the event
+	VerifyWebhook(payload []byte, signature string) (*WebhookEvent, error)
+}
+
+var (
+	gateway   PaymentGateway
+	gatewayMu sync.RWMutex
+)
+
+// fakeGatewayEnvironments are the environments the fake gateway may run in.
+// It approves payments without moving money, so it must never be used in
+// production.
+var fakeGatewayEnvironments = map[string]bool{
+	"development": true,
+	"test":        true,
+}
+
+// InitGateway configures the payment gateway used by the application. The
+// provider must be set explicitly; the fake gateway is refused outside
+// development and test environments.
+func InitGateway(provider, webhookSecret, environment string) error {
+	var g PaymentGateway
+
+	switch strings.ToLower(provider) {
+	case "":
+		return errors.New("PAYMENT_GATEWAY is not set")
+	case "fake":
+		if !fakeGatewayEnvironments[strings.ToLower(environment)] {
+			return fmt.Errorf("the fake payment gateway cannot be used in the %q environment", environment)
+		}
+		g = NewFakeGateway(webhookSecret)
+	default:
+		return fmt.Errorf("unsupported payment gateway %q", provider)
+	}
+
+	SetGateway(g)
+	return nil
+}
+
+// SetGateway replaces the payment gateway used by the application
+func SetGateway(g PaymentGateway) {
+	gatewayMu.Lock()
+	defer gatewayMu.Unlock()
+	gateway = g
+}
+
+// GetGateway returns the configured payment gateway, or nil if none is configured
+func GetGateway() PaymentGateway {
+	gatewayMu.RLock()
+	defer gatewayMu.RUnlock()
+	return gateway
+}
@@ -0,0 +1,23 @@
+package pricing
+
+import (
+	"car-rental-backend/models"
+	"car-rental-backend/money"
+)
+
+// Convert shows the quote's amounts in the currency of an exchange rate. Each
+// amount is converted and rounded on its own, so converted line items may not
+// add up to the converted totals to the last minor unit.
+func (q *Quote) Convert(rate *models.ExchangeRate) {
+	for _, amount := range []*money.Money{
+		&q.DaysAmount, &q.HoursAmount, &q.Base, &q.Discount,
+		&q.Taxes, &q.Total, &q.Deposit, &q.AmountDue,
+	} {
+		*amount = rate.Convert(*amount)
+	}
+	for i := range q.LineItems {
+		q.LineItems[i].UnitPrice = rate.Convert(q.LineItems[i].UnitPrice)
+		q.LineItems[i].Amount = rate.Convert(q.LineItems[i].Amount)
+	}
+	q.ExchangeRate = rate.Snapshot()
+}
@@ -0,0 +1,60 @@
+package pricing
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Holiday is a single public holiday entry in the calendar file
+type Holiday struct {
+	Date string `json:"date"` // YYYY-MM-DD
+	Name string `json:"name"`
+}
+
+// HolidayCalendar is a set of public holidays keyed by date
+type HolidayCalendar struct {
+	days map[string]string
+}
+
+var holidays = &HolidayCalendar{days: map[string]string{}}
+
+// LoadHolidayCalendar reads a JSON array of holidays from path and makes it the
+// calendar used by the pricing engines
+func LoadHolidayCalendar(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read holiday calendar: %v", err)
+	}
+
+	var entries []Holiday
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("failed to parse holiday calendar: %v", err)
+	}
+
+	calendar := &HolidayCalendar{days: make(map[string]string, len(entries))}
+	for _, entry := range entries {
+		if _, err := time.Parse("2006-01-02", entry.Date); err != nil {
+			return fmt.Errorf("invalid holiday date %q: %v", entry.Date, err)
+		}
+		calendar.days[entry.Date] = entry.Name
+	}
+
+	holidays = calendar
+	return nil
+}
+
+// GetHolidayCalendar returns the loaded holiday calendar
+func GetHolidayCalendar() *HolidayCalendar {
+	return holidays
+}
+
+// IsHoliday reports whether the calendar day of t is a public holiday
+func (h *HolidayCalendar) IsHoliday(t time.Time) bool {
+	if h == nil {
+		return false
+	}
+	_, ok := h.days[t.Format("2006-01-02")]
+	return ok
+}
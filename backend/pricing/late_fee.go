@@ -0,0 +1,19 @@
+package pricing
+
+import (
+	"car-rental-backend/models"
+	"car-rental-backend/money"
+	"time"
+)
+
+// LateFee computes the late fee for a car returned at returned when it was due
+// back at due. Returns within the grace period are free; past it, every started
+// hour after due is charged at the car's late fee rate.
+func LateFee(info *models.CarRentalInfo, due, returned time.Time, grace time.Duration) (hours int, amount money.Money) {
+	if info == nil || !returned.After(due.Add(grace)) {
+		return 0, money.Zero()
+	}
+
+	hours = BillableHours(due, returned)
+	return hours, info.LateFeePerHour.Mul(int64(hours))
+}
@@ -0,0 +1,129 @@
+package pricing
+
+import (
+	"car-rental-backend/models"
+	"car-rental-backend/money"
+	"errors"
+	"math"
+	"time"
+)
+
+var (
+	// ErrInvalidWindow is returned when the rental window is empty or reversed
+	ErrInvalidWindow = errors.New("end time must be after start time")
+	// ErrBelowMinimumDuration is returned when the window is shorter than the car's minimum rent duration
+	ErrBelowMinimumDuration = errors.New("rental duration is below the minimum rent duration")
+	// ErrRentalInfoMissing is returned when there are no rates to price with
+	ErrRentalInfoMissing = errors.New("car rental information is missing")
+)
+
+// Line item types
+const (
+	LineItemDays     = "DAYS"
+	LineItemHours    = "HOURS"
+	LineItemDiscount = "DISCOUNT"
+	LineItemTax      = "TAX"
+	LineItemDeposit  = "DEPOSIT"
+)
+
+// PricingEngine prices a rental window for a car
+type PricingEngine interface {
+	// Quote returns an itemised price for renting the car between start and end
+	Quote(info *models.CarRentalInfo, start, end time.Time) (*Quote, error)
+	// QuoteExtension returns an itemised price for moving a booking's end from currentEnd to newEnd
+	QuoteExtension(info *models.CarRentalInfo, currentEnd, newEnd time.Time) (*Quote, error)
+}
+
+// LineItem is a single priced component of a quote
+type LineItem struct {
+	Type        string      `json:"type"`
+	Description string      `json:"description"`
+	Quantity    float64     `json:"quantity"`
+	UnitPrice   money.Money `json:"unit_price"`
+	Amount      money.Money `json:"amount"`
+}
+
+// Quote is an itemised price for a rental window
+type Quote struct {
+	StartTime     time.Time `json:"start_time"`
+	EndTime       time.Time `json:"end_time"`
+	BillableHours int       `json:"billable_hours"`
+	Days          int       `json:"days"`
+	LeftoverHours int       `json:"leftover_hours"`
+
+	DaysAmount  money.Money `json:"days_amount"`
+	HoursAmount money.Money `json:"hours_amount"`
+	Base        money.Money `json:"base"`
+	Discount    money.Money `json:"discount"`
+	Taxes       money.Money `json:"taxes"`
+	Total       money.Money `json:"total"`
+	Deposit     money.Money `json:"deposit"`
+	AmountDue   money.Money `json:"amount_due"`
+
+	TaxRate      float64 `json:"tax_rate"`
+	TaxInclusive bool    `json:"tax_inclusive"`
+
+	// Exchange rate the amounts were converted with, if shown in another currency
+	ExchangeRate *models.ExchangeRateSnapshot `json:"exchange_rate,omitempty"`
+
+	LineItems []LineItem `json:"line_items"`
+}
+
+// Recalculate refreshes the totals after the base, discount or tax rate change.
+// Tax is charged on the discounted base; inclusive tax is already part of it.
+// The deposit is refundable, so it is part of the amount due but not of the total.
+func (q *Quote) Recalculate() error {
+	var err error
+	if q.Base, err = q.DaysAmount.Add(q.HoursAmount); err != nil {
+		return err
+	}
+	if q.Discount, err = q.Discount.Min(q.Base); err != nil {
+		return err
+	}
+	if q.Total, err = q.Base.Sub(q.Discount); err != nil {
+		return err
+	}
+	q.Taxes = Tax(q.Total, q.TaxRate, q.TaxInclusive)
+	if !q.TaxInclusive {
+		if q.Total, err = q.Total.Add(q.Taxes); err != nil {
+			return err
+		}
+	}
+	if q.AmountDue, err = q.Total.Add(q.Deposit); err != nil {
+		return err
+	}
+
+	for i := range q.LineItems {
+		if q.LineItems[i].Type == LineItemTax {
+			q.LineItems[i].UnitPrice = q.Taxes
+			q.LineItems[i].Amount = q.Taxes
+		}
+	}
+	return nil
+}
+
+// ApplyDiscount deducts a discount from the quote's base and refreshes the totals
+func (q *Quote) ApplyDiscount(amount money.Money, description string) error {
+	if !amount.IsPositive() {
+		return nil
+	}
+
+	discount, err := q.Discount.Add(amount)
+	if err != nil {
+		return err
+	}
+	q.Discount = discount
+	q.LineItems = append(q.LineItems, LineItem{
+		Type:        LineItemDiscount,
+		Description: description,
+		Quantity:    1,
+		UnitPrice:   amount.Neg(),
+		Amount:      amount.Neg(),
+	})
+	return q.Recalculate()
+}
+
+// BillableHours returns the number of started hours in the window
+func BillableHours(start, end time.Time) int {
+	return int(math.Ceil(end.Sub(start).Hours()))
+}
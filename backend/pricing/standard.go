@@ -0,0 +1,220 @@
+package pricing
+
+import (
+	"car-rental-backend/models"
+	"car-rental-backend/money"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RateRuleSource provides the rate rules that apply to a car
+type RateRuleSource interface {
+	RateRulesForCar(carID uuid.UUID) ([]models.RateRule, error)
+}
+
+// StandardEngine prices a window as whole days at the daily rate plus leftover
+// hours at the hourly rate. Leftover hours never cost more than one extra day.
+//
+// The window is walked one 24-hour block at a time; each block is priced at the
+// highest-ranked rate rule matching the calendar day it starts on, falling back
+// to the car's own rates when no rule matches.
+type StandardEngine struct {
+	rules    RateRuleSource
+	holidays *HolidayCalendar
+}
+
+// NewStandardEngine creates a new standard pricing engine. Both arguments are
+// optional; without them every day is priced at the car's own rates.
+func NewStandardEngine(rules RateRuleSource, holidays *HolidayCalendar) *StandardEngine {
+	return &StandardEngine{
+		rules:    rules,
+		holidays: holidays,
+	}
+}
+
+// dayRate is the pricing that applies to a single day of the window
+type dayRate struct {
+	name   string
+	daily  money.Money
+	hourly money.Money
+}
+
+// Quote implements PricingEngine
+func (e *StandardEngine) Quote(info *models.CarRentalInfo, start, end time.Time) (*Quote, error) {
+	if info == nil {
+		return nil, ErrRentalInfoMissing
+	}
+	if !end.After(start) {
+		return nil, ErrInvalidWindow
+	}
+
+	hours := BillableHours(start, end)
+	if hours < info.MinimumRentDuration {
+		return nil, fmt.Errorf("%w of %d hours", ErrBelowMinimumDuration, info.MinimumRentDuration)
+	}
+
+	rules, err := e.rulesFor(info)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &Quote{
+		StartTime:     start,
+		EndTime:       end,
+		BillableHours: hours,
+		Days:          hours / 24,
+		LeftoverHours: hours % 24,
+		Deposit:       info.SecurityDeposit,
+	}
+
+	err = e.priceDays(quote, start, func(t time.Time) dayRate {
+		return e.rateOn(info, rules, t)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if quote.Deposit.IsPositive() {
+		quote.LineItems = append(quote.LineItems, LineItem{
+			Type:        LineItemDeposit,
+			Description: "Refundable security deposit",
+			Quantity:    1,
+			UnitPrice:   quote.Deposit,
+			Amount:      quote.Deposit,
+		})
+	}
+
+	if err := quote.Recalculate(); err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
+
+// QuoteExtension implements PricingEngine. Extensions are priced at the car's
+// extend fees; a fee left at zero falls back to the regular rate of that day.
+// The car's minimum rent duration does not apply and no deposit is added.
+func (e *StandardEngine) QuoteExtension(info *models.CarRentalInfo, currentEnd, newEnd time.Time) (*Quote, error) {
+	if info == nil {
+		return nil, ErrRentalInfoMissing
+	}
+	if !newEnd.After(currentEnd) {
+		return nil, ErrInvalidWindow
+	}
+
+	rules, err := e.rulesFor(info)
+	if err != nil {
+		return nil, err
+	}
+
+	hours := BillableHours(currentEnd, newEnd)
+	quote := &Quote{
+		StartTime:     currentEnd,
+		EndTime:       newEnd,
+		BillableHours: hours,
+		Days:          hours / 24,
+		LeftoverHours: hours % 24,
+	}
+
+	err = e.priceDays(quote, currentEnd, func(t time.Time) dayRate {
+		rate := e.rateOn(info, rules, t)
+		if info.RentalExtendFeePerDay.IsPositive() {
+			rate.daily = info.RentalExtendFeePerDay
+		}
+		if info.RentalExtendFeePerHour.IsPositive() {
+			rate.hourly = info.RentalExtendFeePerHour
+		}
+		rate.name += " extension"
+		return rate
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := quote.Recalculate(); err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
+
+// priceDays fills in the days and leftover hours of a quote starting at start,
+// pricing each 24-hour block at the rate returned for the time it starts at
+func (e *StandardEngine) priceDays(quote *Quote, start time.Time, rateFor func(time.Time) dayRate) error {
+	var err error
+
+	// Whole days, grouping consecutive days priced at the same rate into one line
+	current := -1
+	for day := 0; day < quote.Days; day++ {
+		rate := rateFor(start.Add(time.Duration(day) * 24 * time.Hour))
+		if quote.DaysAmount, err = quote.DaysAmount.Add(rate.daily); err != nil {
+			return err
+		}
+
+		description := rate.name + " daily rate"
+		if current >= 0 && quote.LineItems[current].Description == description && quote.LineItems[current].UnitPrice == rate.daily {
+			quote.LineItems[current].Quantity++
+			if quote.LineItems[current].Amount, err = quote.LineItems[current].Amount.Add(rate.daily); err != nil {
+				return err
+			}
+			continue
+		}
+
+		quote.LineItems = append(quote.LineItems, LineItem{
+			Type:        LineItemDays,
+			Description: description,
+			Quantity:    1,
+			UnitPrice:   rate.daily,
+			Amount:      rate.daily,
+		})
+		current = len(quote.LineItems) - 1
+	}
+
+	// Leftover hours are priced at the rate of the day they start on
+	if quote.LeftoverHours > 0 {
+		rate := rateFor(start.Add(time.Duration(quote.Days) * 24 * time.Hour))
+		if quote.HoursAmount, err = rate.hourly.Mul(int64(quote.LeftoverHours)).Min(rate.daily); err != nil {
+			return err
+		}
+		quote.LineItems = append(quote.LineItems, LineItem{
+			Type:        LineItemHours,
+			Description: fmt.Sprintf("%d hour(s) at %s hourly rate", quote.LeftoverHours, rate.name),
+			Quantity:    float64(quote.LeftoverHours),
+			UnitPrice:   rate.hourly,
+			Amount:      quote.HoursAmount,
+		})
+	}
+	return nil
+}
+
+// rulesFor loads the rate rules that apply to the car, if a rule source is configured
+func (e *StandardEngine) rulesFor(info *models.CarRentalInfo) ([]models.RateRule, error) {
+	if e.rules == nil {
+		return nil, nil
+	}
+
+	rules, err := e.rules.RateRulesForCar(info.CarID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rate rules: %w", err)
+	}
+	return rules, nil
+}
+
+// rateOn resolves the rate that applies on the calendar day of t in the
+// business timezone
+func (e *StandardEngine) rateOn(info *models.CarRentalInfo, rules []models.RateRule, t time.Time) dayRate {
+	t = t.In(BusinessLocation())
+	isHoliday := e.holidays.IsHoliday(t)
+
+	var best *models.RateRule
+	for i := range rules {
+		if rules[i].Matches(t, isHoliday) && (best == nil || rules[i].Outranks(best)) {
+			best = &rules[i]
+		}
+	}
+
+	if best == nil {
+		return dayRate{name: "standard", daily: info.RentalPricePerDay, hourly: info.RentalPricePerHour}
+	}
+	return dayRate{name: best.Name, daily: best.RentalPricePerDay, hourly: best.RentalPricePerHour}
+}
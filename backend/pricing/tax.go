@@ -0,0 +1,40 @@
+package pricing
+
+import (
+	"car-rental-backend/models"
+	"car-rental-backend/money"
+	"fmt"
+)
+
+// Tax returns the tax due on amount at a percentage rate. With inclusive
+// pricing this is the share of amount that is tax; otherwise it is the tax to
+// add on top of amount.
+func Tax(amount money.Money, rate float64, inclusive bool) money.Money {
+	if !amount.IsPositive() || rate <= 0 {
+		return money.New(0, amount.Currency)
+	}
+	if inclusive {
+		return amount.PercentOfGross(rate)
+	}
+	return amount.Percent(rate)
+}
+
+// ApplyTax charges the tax rate on the quote's discounted base. Exclusive tax
+// is added to the total as its own line item; inclusive tax is only reported.
+// A nil rate leaves the quote untaxed.
+func (q *Quote) ApplyTax(rate *models.TaxRate) error {
+	if rate == nil || rate.Rate <= 0 {
+		return nil
+	}
+
+	q.TaxRate = rate.Rate
+	q.TaxInclusive = rate.Inclusive
+	if !rate.Inclusive {
+		q.LineItems = append(q.LineItems, LineItem{
+			Type:        LineItemTax,
+			Description: fmt.Sprintf("%s %g%%", rate.Name, rate.Rate),
+			Quantity:    1,
+		})
+	}
+	return q.Recalculate()
+}
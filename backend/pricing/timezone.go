@@ -0,0 +1,26 @@
+package pricing
+
+import (
+	"fmt"
+	"time"
+)
+
+var businessLocation = time.UTC
+
+// SetBusinessTimezone sets the IANA timezone, e.g. "Europe/Berlin", whose
+// calendar days rate rules and holidays are matched on. A rental starting at
+// the same instant is then priced the same whatever offset it was sent with.
+func SetBusinessTimezone(name string) error {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("invalid business timezone %q: %v", name, err)
+	}
+
+	businessLocation = location
+	return nil
+}
+
+// BusinessLocation returns the timezone rate rules and holidays are matched in
+func BusinessLocation() *time.Location {
+	return businessLocation
+}
@@ -16,38 +16,154 @@ func SetupRoutes(app *fiber.App, cfg *config.Config) {
 	})
 
 	// Public routes
+	app.Get("/.well-known/jwks.json", controllers.GetJWKS)
+
 	auth := app.Group("/auth")
 	auth.Post("/register", controllers.Register)
 	auth.Post("/login", controllers.Login)
+	auth.Post("/login/two-factor", controllers.LoginTwoFactor)
+	auth.Post("/verify-email", controllers.VerifyEmail)
+	auth.Post("/resend-verification", controllers.ResendVerification)
+	auth.Post("/forgot-password", controllers.ForgotPassword)
+	auth.Post("/reset-password", controllers.ResetPassword)
+	auth.Post("/refresh", controllers.Refresh)
+	auth.Post("/logout", middlewares.AuthMiddleware(cfg), controllers.Logout)
+
+	// Payment provider webhooks, authenticated by their signature
+	webhooks := app.Group("/webhooks")
+	webhooks.Post("/payments", controllers.HandlePaymentWebhook)
 
 	// Protected routes
 	api := app.Group("/api", middlewares.AuthMiddleware(cfg))
 
+	// Route permissions. Routes without one are open to every authenticated
+	// user; handlers still check that users only touch their own bookings.
+	manageCars := middlewares.RequirePermission(middlewares.PermManageCars)
+	manageBookings := middlewares.RequirePermission(middlewares.PermManageBookings)
+	managePayments := middlewares.RequirePermission(middlewares.PermManagePayments)
+	managePricing := middlewares.RequirePermission(middlewares.PermManagePricing)
+	manageOwners := middlewares.RequirePermission(middlewares.PermManageOwners)
+	managePayouts := middlewares.RequirePermission(middlewares.PermManagePayouts)
+	manageUsers := middlewares.RequirePermission(middlewares.PermManageUsers)
+
 	// Car routes
 	cars := api.Group("/cars")
 	cars.Get("/", controllers.GetCars)
 	cars.Get("/available", controllers.GetAvailableCars)
 	cars.Get("/:id", controllers.GetCar)
-	cars.Post("/", controllers.CreateCar)
-	cars.Put("/:id", controllers.UpdateCar)
-	cars.Delete("/:id", controllers.DeleteCar)
+	cars.Post("/", manageCars, controllers.CreateCar)
+	cars.Put("/:id", manageCars, controllers.UpdateCar)
+	cars.Delete("/:id", manageCars, controllers.DeleteCar)
+	cars.Get("/:id/calendar", controllers.GetCarCalendar)
+	cars.Post("/:id/maintenance", manageCars, controllers.CreateMaintenanceBlock)
+	cars.Delete("/:id/maintenance/:blockId", manageCars, controllers.DeleteMaintenanceBlock)
+	cars.Get("/:id/rates", controllers.GetCarRates)
+	cars.Post("/:id/rates", manageCars, controllers.CreateCarRate)
+	cars.Put("/:id/rates/:rateId", manageCars, controllers.UpdateCarRate)
+	cars.Delete("/:id/rates/:rateId", manageCars, controllers.DeleteCarRate)
+
+	// Rate rules shared by every car of a body type
+	bodyTypes := api.Group("/body-types")
+	bodyTypes.Get("/:bodyType/rates", controllers.GetBodyTypeRates)
+	bodyTypes.Post("/:bodyType/rates", manageCars, controllers.CreateBodyTypeRate)
+	bodyTypes.Put("/:bodyType/rates/:rateId", manageCars, controllers.UpdateBodyTypeRate)
+	bodyTypes.Delete("/:bodyType/rates/:rateId", manageCars, controllers.DeleteBodyTypeRate)
 
 	// Booking routes
 	bookings := api.Group("/bookings")
 	bookings.Post("/", controllers.CreateBooking)
-	bookings.Get("/", controllers.GetAllBookings)
+	bookings.Get("/", manageBookings, controllers.GetAllBookings)
+	bookings.Get("/overdue", manageBookings, controllers.GetOverdueBookings)
 	bookings.Get("/:id", controllers.GetBooking)
 	bookings.Delete("/:id", controllers.CancelBooking)
+	bookings.Get("/:id/history", controllers.GetBookingHistory)
+	bookings.Post("/:id/confirm", manageBookings, controllers.ConfirmBooking)
+	bookings.Post("/:id/pickup", manageBookings, controllers.PickupBooking)
+	bookings.Post("/:id/return", manageBookings, controllers.ReturnBooking)
+	bookings.Post("/:id/complete", manageBookings, controllers.CompleteBooking)
+	bookings.Post("/:id/no-show", manageBookings, controllers.MarkBookingNoShow)
+	bookings.Post("/:id/extend", controllers.ExtendBooking)
+	bookings.Get("/:id/deposit", controllers.GetDepositStatement)
+	bookings.Post("/:id/deposit/capture", managePayments, controllers.CaptureDeposit)
+	bookings.Post("/:id/deposit/release", managePayments, controllers.ReleaseDeposit)
+	bookings.Get("/:id/payments", controllers.GetBookingPayments)
+	bookings.Post("/:id/payments", controllers.CreateBookingPayment)
+	bookings.Get("/:id/invoice", controllers.GetBookingInvoice)
+
+	// Quote routes
+	api.Post("/quotes", controllers.CreateQuote)
+
+	// Payment routes
+	paymentRoutes := api.Group("/payments", managePayments)
+	paymentRoutes.Post("/:id/capture", controllers.CapturePayment)
+	paymentRoutes.Post("/:id/refund", controllers.RefundPayment)
+
+	// Cancellation policy routes
+	policies := api.Group("/cancellation-policies", managePricing)
+	policies.Get("/", controllers.GetCancellationPolicies)
+	policies.Post("/", controllers.CreateCancellationPolicy)
+	policies.Put("/:id", controllers.UpdateCancellationPolicy)
+	policies.Delete("/:id", controllers.DeleteCancellationPolicy)
+
+	// Tax rate routes
+	taxRates := api.Group("/tax-rates", managePricing)
+	taxRates.Get("/", controllers.GetTaxRates)
+	taxRates.Get("/report", controllers.GetTaxReport)
+	taxRates.Post("/", controllers.CreateTaxRate)
+	taxRates.Put("/:id", controllers.UpdateTaxRate)
+	taxRates.Delete("/:id", controllers.DeleteTaxRate)
+
+	// Exchange rate routes
+	exchangeRates := api.Group("/exchange-rates")
+	exchangeRates.Get("/", controllers.GetExchangeRates)
+	exchangeRates.Put("/", managePricing, controllers.UploadExchangeRates)
+
+	// Promotion routes
+	promotions := api.Group("/promotions", managePricing)
+	promotions.Get("/", controllers.GetPromotions)
+	promotions.Get("/:id", controllers.GetPromotion)
+	promotions.Post("/", controllers.CreatePromotion)
+	promotions.Put("/:id", controllers.UpdatePromotion)
+	promotions.Delete("/:id", controllers.DeletePromotion)
 
 	// User bookings
 	users := api.Group("/users")
+	users.Put("/me/password", controllers.ChangePassword)
+	users.Get("/me/two-factor", controllers.GetTwoFactorStatus)
+	users.Post("/me/two-factor/enroll", controllers.EnrollTwoFactor)
+	users.Post("/me/two-factor/confirm", controllers.ConfirmTwoFactor)
+	users.Post("/me/two-factor/recovery-codes", controllers.RegenerateRecoveryCodes)
+	users.Post("/me/two-factor/disable", controllers.DisableTwoFactor)
 	users.Get("/:userId/bookings", controllers.GetUserBookings)
+	users.Post("/:userId/unlock", manageUsers, controllers.UnlockUser)
 
-	// Owner routes - TO BE IMPLEMENTED
+	// Owner routes
 	owners := api.Group("/owners")
-	owners.Get("/", controllers.GetOwners)
-	owners.Get("/:id", controllers.GetOwner)
-	owners.Post("/", controllers.CreateOwner)
-	owners.Put("/:id", controllers.UpdateOwner)
-	owners.Delete("/:id", controllers.DeleteOwner)
+	owners.Get("/", manageOwners, controllers.GetOwners)
+	owners.Get("/:id", manageOwners, controllers.GetOwner)
+	owners.Post("/", manageOwners, controllers.CreateOwner)
+	owners.Put("/:id", manageOwners, controllers.UpdateOwner)
+	owners.Delete("/:id", manageOwners, controllers.DeleteOwner)
+	owners.Get("/:id/payouts", managePayouts, controllers.GetOwnerPayouts)
+	owners.Post("/:id/users", manageOwners, controllers.LinkOwnerUser)
+
+	// Owner portal, scoped to the authenticated owner's cars. The middleware is
+	// set per route because group middleware on "/owner" would also run for
+	// "/owners".
+	ownerPortal := api.Group("/owner")
+	ownerOnly := middlewares.RequirePermission(middlewares.PermOwnerPortal)
+	asOwner := middlewares.OwnerMiddleware()
+	ownerCar := middlewares.OwnerCarMiddleware()
+	ownerPortal.Get("/cars", ownerOnly, asOwner, controllers.GetMyCars)
+	ownerPortal.Get("/cars/:id/calendar", ownerOnly, asOwner, ownerCar, controllers.GetCarCalendar)
+	ownerPortal.Put("/cars/:id/rental-info", ownerOnly, asOwner, ownerCar, controllers.UpdateMyCarRentalInfo)
+	ownerPortal.Put("/cars/:id/availability", ownerOnly, asOwner, ownerCar, controllers.UpdateMyCarAvailability)
+	ownerPortal.Post("/cars/:id/maintenance", ownerOnly, asOwner, ownerCar, controllers.CreateMaintenanceBlock)
+	ownerPortal.Delete("/cars/:id/maintenance/:blockId", ownerOnly, asOwner, ownerCar, controllers.DeleteMaintenanceBlock)
+	ownerPortal.Get("/bookings", ownerOnly, asOwner, controllers.GetMyBookings)
+	ownerPortal.Get("/earnings", ownerOnly, asOwner, controllers.GetMyEarnings)
+
+	// Payout routes
+	payouts := api.Group("/payouts", managePayouts)
+	payouts.Post("/:id/paid", controllers.MarkPayoutPaid)
 }
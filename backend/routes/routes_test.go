@@ -0,0 +1,258 @@
+package routes
+
+import (
+	"car-rental-backend/config"
+	"car-rental-backend/database"
+	"car-rental-backend/mailer"
+	"car-rental-backend/middlewares"
+	"car-rental-backend/models"
+	"car-rental-backend/payments"
+	"car-rental-backend/services"
+	"car-rental-backend/tokens"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/google/uuid"
+)
+
+// Sessions the route permissions are checked for
+const (
+	asUser            = "user"
+	asOwner           = "owner"
+	asAdmin           = "admin"
+	asAdminWithout2FA = "admin without two-factor"
+)
+
+var everyone = []string{asUser, asOwner, asAdmin, asAdminWithout2FA}
+
+// routeAccess lists the sessions a route lets through. ":me" in the path is
+// replaced with the session's user ID.
+type routeAccess struct {
+	method  string
+	path    string
+	allowed []string
+}
+
+var protectedRoutes = []routeAccess{
+	{fiber.MethodGet, "/api/cars", everyone},
+	{fiber.MethodGet, "/api/cars/available", everyone},
+	{fiber.MethodGet, "/api/cars/:id", everyone},
+	{fiber.MethodPost, "/api/cars", []string{asAdmin}},
+	{fiber.MethodPut, "/api/cars/:id", []string{asAdmin}},
+	{fiber.MethodDelete, "/api/cars/:id", []string{asAdmin}},
+	{fiber.MethodGet, "/api/cars/:id/calendar", everyone},
+	{fiber.MethodPost, "/api/cars/:id/maintenance", []string{asAdmin}},
+	{fiber.MethodDelete, "/api/cars/:id/maintenance/:id", []string{asAdmin}},
+	{fiber.MethodGet, "/api/cars/:id/rates", everyone},
+	{fiber.MethodPost, "/api/cars/:id/rates", []string{asAdmin}},
+	{fiber.MethodPut, "/api/cars/:id/rates/:id", []string{asAdmin}},
+	{fiber.MethodDelete, "/api/cars/:id/rates/:id", []string{asAdmin}},
+	{fiber.MethodGet, "/api/body-types/Sedan/rates", everyone},
+	{fiber.MethodPost, "/api/body-types/Sedan/rates", []string{asAdmin}},
+	{fiber.MethodPut, "/api/body-types/Sedan/rates/:id", []string{asAdmin}},
+	{fiber.MethodDelete, "/api/body-types/Sedan/rates/:id", []string{asAdmin}},
+
+	{fiber.MethodPost, "/api/bookings", everyone},
+	{fiber.MethodGet, "/api/bookings", []string{asAdmin}},
+	{fiber.MethodGet, "/api/bookings/overdue", []string{asAdmin}},
+	{fiber.MethodGet, "/api/bookings/:id", everyone},
+	{fiber.MethodDelete, "/api/bookings/:id", everyone},
+	{fiber.MethodGet, "/api/bookings/:id/history", everyone},
+	{fiber.MethodPost, "/api/bookings/:id/confirm", []string{asAdmin}},
+	{fiber.MethodPost, "/api/bookings/:id/pickup", []string{asAdmin}},
+	{fiber.MethodPost, "/api/bookings/:id/return", []string{asAdmin}},
+	{fiber.MethodPost, "/api/bookings/:id/complete", []string{asAdmin}},
+	{fiber.MethodPost, "/api/bookings/:id/no-show", []string{asAdmin}},
+	{fiber.MethodPost, "/api/bookings/:id/extend", everyone},
+	{fiber.MethodGet, "/api/bookings/:id/deposit", everyone},
+	{fiber.MethodPost, "/api/bookings/:id/deposit/capture", []string{asAdmin}},
+	{fiber.MethodPost, "/api/bookings/:id/deposit/release", []string{asAdmin}},
+	{fiber.MethodGet, "/api/bookings/:id/payments", everyone},
+	{fiber.MethodPost, "/api/bookings/:id/payments", everyone},
+	{fiber.MethodGet, "/api/bookings/:id/invoice", everyone},
+
+	{fiber.MethodPost, "/api/quotes", everyone},
+
+	{fiber.MethodPost, "/api/payments/:id/capture", []string{asAdmin}},
+	{fiber.MethodPost, "/api/payments/:id/refund", []string{asAdmin}},
+
+	{fiber.MethodGet, "/api/cancellation-policies", []string{asAdmin}},
+	{fiber.MethodPost, "/api/cancellation-policies", []string{asAdmin}},
+	{fiber.MethodPut, "/api/cancellation-policies/:id", []string{asAdmin}},
+	{fiber.MethodDelete, "/api/cancellation-policies/:id", []string{asAdmin}},
+
+	{fiber.MethodGet, "/api/tax-rates", []string{asAdmin}},
+	{fiber.MethodGet, "/api/tax-rates/report", []string{asAdmin}},
+	{fiber.MethodPost, "/api/tax-rates", []string{asAdmin}},
+	{fiber.MethodPut, "/api/tax-rates/:id", []string{asAdmin}},
+	{fiber.MethodDelete, "/api/tax-rates/:id", []string{asAdmin}},
+
+	{fiber.MethodGet, "/api/exchange-rates", everyone},
+	{fiber.MethodPut, "/api/exchange-rates", []string{asAdmin}},
+
+	{fiber.MethodGet, "/api/promotions", []string{asAdmin}},
+	{fiber.MethodGet, "/api/promotions/:id", []string{asAdmin}},
+	{fiber.MethodPost, "/api/promotions", []string{asAdmin}},
+	{fiber.MethodPut, "/api/promotions/:id", []string{asAdmin}},
+	{fiber.MethodDelete, "/api/promotions/:id", []string{asAdmin}},
+
+	{fiber.MethodPut, "/api/users/me/password", everyone},
+	{fiber.MethodGet, "/api/users/me/two-factor", everyone},
+	{fiber.MethodPost, "/api/users/me/two-factor/enroll", everyone},
+	{fiber.MethodPost, "/api/users/me/two-factor/confirm", everyone},
+	{fiber.MethodPost, "/api/users/me/two-factor/recovery-codes", everyone},
+	{fiber.MethodPost, "/api/users/me/two-factor/disable", everyone},
+	{fiber.MethodGet, "/api/users/:me/bookings", everyone},
+	{fiber.MethodPost, "/api/users/:id/unlock", []string{asAdmin}},
+
+	{fiber.MethodGet, "/api/owners", []string{asAdmin}},
+	{fiber.MethodGet, "/api/owners/:id", []string{asAdmin}},
+	{fiber.MethodPost, "/api/owners", []string{asAdmin}},
+	{fiber.MethodPut, "/api/owners/:id", []string{asAdmin}},
+	{fiber.MethodDelete, "/api/owners/:id", []string{asAdmin}},
+	{fiber.MethodGet, "/api/owners/:id/payouts", []string{asAdmin}},
+	{fiber.MethodPost, "/api/owners/:id/users", []string{asAdmin}},
+
+	{fiber.MethodGet, "/api/owner/cars", []string{asOwner}},
+	{fiber.MethodGet, "/api/owner/cars/:id/calendar", []string{asOwner}},
+	{fiber.MethodPut, "/api/owner/cars/:id/rental-info", []string{asOwner}},
+	{fiber.MethodPut, "/api/owner/cars/:id/availability", []string{asOwner}},
+	{fiber.MethodPost, "/api/owner/cars/:id/maintenance", []string{asOwner}},
+	{fiber.MethodDelete, "/api/owner/cars/:id/maintenance/:id", []string{asOwner}},
+	{fiber.MethodGet, "/api/owner/bookings", []string{asOwner}},
+	{fiber.MethodGet, "/api/owner/earnings", []string{asOwner}},
+
+	{fiber.MethodPost, "/api/payouts/:id/paid", []string{asAdmin}},
+}
+
+func TestRoutePermissions(t *testing.T) {
+	app, cfg := setupTestApp(t)
+
+	owner := models.Owner{Name: "Route Test Owner", RevenueSharePercent: 70}
+	if err := database.DB.Create(&owner).Error; err != nil {
+		t.Fatalf("failed to create owner: %v", err)
+	}
+
+	sessions := []struct {
+		name      string
+		role      models.UserRole
+		ownerID   *uuid.UUID
+		twoFactor bool
+	}{
+		{asUser, models.UserRoleUser, nil, false},
+		{asOwner, models.UserRoleOwner, &owner.ID, false},
+		{asAdmin, models.UserRoleAdmin, nil, true},
+		{asAdminWithout2FA, models.UserRoleAdmin, nil, false},
+	}
+
+	for _, session := range sessions {
+		user := models.User{
+			Name:    "Route Test " + session.name,
+			Email:   uuid.NewString() + "@example.com",
+			Role:    session.role,
+			OwnerID: session.ownerID,
+		}
+		if err := database.DB.Create(&user).Error; err != nil {
+			t.Fatalf("failed to create %s: %v", session.name, err)
+		}
+
+		token, err := middlewares.GenerateToken(user.ID.String(), user.Role, uuid.New(), session.twoFactor, cfg)
+		if err != nil {
+			t.Fatalf("failed to generate token for %s: %v", session.name, err)
+		}
+
+		for _, route := range protectedRoutes {
+			path := strings.ReplaceAll(route.path, ":me", user.ID.String())
+			path = strings.ReplaceAll(path, ":id", uuid.NewString())
+
+			t.Run(session.name+" "+route.method+" "+route.path, func(t *testing.T) {
+				req := httptest.NewRequest(route.method, path, strings.NewReader("{}"))
+				req.Header.Set("Authorization", "Bearer "+token)
+				req.Header.Set("Content-Type", "application/json")
+
+				resp, err := app.Test(req, -1)
+				if err != nil {
+					t.Fatalf("request failed: %v", err)
+				}
+				defer resp.Body.Close()
+
+				// A handler that panics is turned into a 500 by the recover
+				// middleware, so a 500 means the route is broken, not allowed
+				if isAllowed(route.allowed, session.name) {
+					switch resp.StatusCode {
+					case fiber.StatusForbidden, fiber.StatusUnauthorized:
+						t.Errorf("got status %d, want the request to reach the handler", resp.StatusCode)
+					case fiber.StatusInternalServerError:
+						t.Errorf("got status %d, want the handler to answer the request", resp.StatusCode)
+					}
+				} else if resp.StatusCode != fiber.StatusForbidden {
+					t.Errorf("got status %d, want %d", resp.StatusCode, fiber.StatusForbidden)
+				}
+			})
+		}
+	}
+}
+
+// setupTestApp builds the app against the database in TEST_DATABASE_DSN,
+// which must have been migrated. Tests are skipped if it is not set.
+func setupTestApp(t *testing.T) (*fiber.App, *config.Config) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	db, err := database.GetDirectDB(dsn)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	database.DB = db
+
+	keyManager, err := tokens.NewKeyManager(db, tokens.Options{
+		Algorithm:      tokens.AlgorithmEdDSA,
+		RotationPeriod: 24 * time.Hour,
+		GracePeriod:    time.Hour,
+		Secret:         "test-key-secret",
+	})
+	if err != nil {
+		t.Fatalf("failed to initialize token signing keys: %v", err)
+	}
+	tokens.SetKeyManager(keyManager)
+
+	// Handlers that pay or send mail fail with a 500 when these are missing
+	payments.SetGateway(payments.NewFakeGateway("test-webhook-secret"))
+	mailer.SetMailer(mailer.NewMemoryMailer())
+	t.Cleanup(func() {
+		payments.SetGateway(nil)
+		mailer.SetMailer(nil)
+	})
+
+	services.SetTwoFactorOptions("Car Rental", "test-two-factor-secret")
+	services.SetTwoFactorRequiredRoles(models.UserRoleAdmin)
+	t.Cleanup(func() { services.SetTwoFactorRequiredRoles() })
+
+	cfg := &config.Config{
+		JWTIssuer:          "car-rental-test",
+		AccessTokenMinutes: 15,
+	}
+
+	app := fiber.New()
+	app.Use(recover.New())
+	SetupRoutes(app, cfg)
+	return app, cfg
+}
+
+// isAllowed reports whether the session is in the list
+func isAllowed(allowed []string, session string) bool {
+	for _, name := range allowed {
+		if name == session {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"car-rental-backend/database"
+	"car-rental-backend/mailer"
+	"car-rental-backend/models"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidAccountToken is returned when a verification or reset token is unknown, used or expired
+var ErrInvalidAccountToken = errors.New("invalid or expired token")
+
+// emailVerificationLifetime is how long email verification links are valid for
+var emailVerificationLifetime = 48 * time.Hour
+
+// passwordResetLifetime is how long password reset links are valid for
+var passwordResetLifetime = time.Hour
+
+// appBaseURL is the frontend the links in account emails point to
+var appBaseURL = "http://localhost:3000"
+
+// SetAccountTokenLifetimes configures how long email verification and password
+// reset links are valid for
+func SetAccountTokenLifetimes(verification, reset time.Duration) {
+	if verification > 0 {
+		emailVerificationLifetime = verification
+	}
+	if reset > 0 {
+		passwordResetLifetime = reset
+	}
+}
+
+// SetAppBaseURL configures the frontend URL account email links point to
+func SetAppBaseURL(baseURL string) {
+	if baseURL != "" {
+		appBaseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// AccountService handles email verification and password resets
+type AccountService struct {
+	db *gorm.DB
+}
+
+// NewAccountService creates a new account service
+func NewAccountService() *AccountService {
+	return &AccountService{
+		db: database.GetDB(),
+	}
+}
+
+// SendVerificationEmail emails the user a link to verify their address.
+// Earlier verification links stop working.
+func (s *AccountService) SendVerificationEmail(user *models.User) error {
+	token, err := s.issueToken(user.ID, models.AccountTokenEmailVerification, emailVerificationLifetime)
+	if err != nil {
+		return err
+	}
+
+	return sendMail(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Please verify your email address by opening the link below:\n\n%s\n\n"+
+			"The link expires in %s. If you did not create an account, you can ignore this email.\n",
+			user.Name, accountLink("verify-email", token), formatLifetime(emailVerificationLifetime)),
+	})
+}
+
+// ResendVerificationEmail sends a new verification link to the user with the
+// email, if they exist and are not verified yet. Nothing is reported back, so
+// the endpoint cannot be used to find out which emails are registered.
+func (s *AccountService) ResendVerificationEmail(email string) error {
+	var user models.User
+	if err := s.db.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.IsEmailVerified() {
+		return nil
+	}
+
+	return s.SendVerificationEmail(&user)
+}
+
+// VerifyEmail uses up a verification token and marks the user's email verified
+func (s *AccountService) VerifyEmail(token string) (*models.User, error) {
+	var user models.User
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		record, err := useAccountToken(tx, token, models.AccountTokenEmailVerification)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.First(&user, "id = ?", record.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidAccountToken
+			}
+			return err
+		}
+		if user.IsEmailVerified() {
+			return nil
+		}
+
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		return tx.Model(&user).Update("email_verified_at", now).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// RequestPasswordReset emails a password reset link to the user with the
+// email, if there is one. Like ResendVerificationEmail it reports nothing back.
+func (s *AccountService) RequestPasswordReset(email string) error {
+	var user models.User
+	if err := s.db.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	token, err := s.issueToken(user.ID, models.AccountTokenPasswordReset, passwordResetLifetime)
+	if err != nil {
+		return err
+	}
+
+	return sendMail(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Someone asked to reset the password of your account. Choose a new password by opening the link below:\n\n%s\n\n"+
+			"The link expires in %s and can be used once. If you did not ask for this, you can ignore this email.\n",
+			user.Name, accountLink("reset-password", token), formatLifetime(passwordResetLifetime)),
+	})
+}
+
+// ResetPassword uses up a password reset token and sets the user's new
+// password, which may not be one of their recent passwords. Every session of
+// the user is logged out. Receiving the reset email also proves the user owns
+// the address, so it is marked verified.
+func (s *AccountService) ResetPassword(token, password string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		record, err := useAccountToken(tx, token, models.AccountTokenPasswordReset)
+		if err != nil {
+			return err
+		}
+
+		var user models.User
+		if err := tx.First(&user, "id = ?", record.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidAccountToken
+			}
+			return err
+		}
+
+		if err := setPassword(tx, &user, password); err != nil {
+			return err
+		}
+		if !user.IsEmailVerified() {
+			if err := tx.Model(&user).Update("email_verified_at", time.Now()).Error; err != nil {
+				return err
+			}
+		}
+
+		// Other password reset links are no longer needed
+		if err := tx.Model(&models.AccountToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.ID, models.AccountTokenPasswordReset).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		return revokeUserSessions(tx, user.ID, models.RevocationReasonPasswordReset, uuid.Nil)
+	})
+}
+
+// issueToken replaces the user's unused tokens for the purpose with a new one
+func (s *AccountService) issueToken(userID uuid.UUID, purpose models.AccountTokenPurpose, lifetime time.Duration) (string, error) {
+	token, err := newOpaqueToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+			Delete(&models.AccountToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.AccountToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: hashToken(token),
+			ExpiresAt: time.Now().Add(lifetime),
+		}).Error
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to store token: %w", err)
+	}
+
+	return token, nil
+}
+
+// useAccountToken marks an unused, unexpired token for the purpose as used
+func useAccountToken(tx *gorm.DB, token string, purpose models.AccountTokenPurpose) (*models.AccountToken, error) {
+	var record models.AccountToken
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&record, "token_hash = ? AND purpose = ?", hashToken(token), purpose).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAccountToken
+		}
+		return nil, err
+	}
+
+	if record.UsedAt != nil || time.Now().After(record.ExpiresAt) {
+		return nil, ErrInvalidAccountToken
+	}
+
+	now := time.Now()
+	if err := tx.Model(&record).Update("used_at", now).Error; err != nil {
+		return nil, fmt.Errorf("failed to mark token used: %w", err)
+	}
+	record.UsedAt = &now
+
+	return &record, nil
+}
+
+// sendMail sends the message with the configured mailer
+func sendMail(msg mailer.Message) error {
+	m := mailer.GetMailer()
+	if m == nil {
+		return mailer.ErrMailerNotConfigured
+	}
+	return m.Send(msg)
+}
+
+// accountLink builds the frontend link for an account token
+func accountLink(path, token string) string {
+	return fmt.Sprintf("%s/%s?token=%s", appBaseURL, path, url.QueryEscape(token))
+}
+
+// formatLifetime describes a link lifetime in whole hours or minutes
+func formatLifetime(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		if d == time.Hour {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", int(d/time.Hour))
+	}
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	if minutes == 1 {
+		return "1 minute"
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
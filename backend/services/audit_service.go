@@ -0,0 +1,29 @@
+package services
+
+import (
+	"car-rental-backend/database"
+	"car-rental-backend/models"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// AuditService records security events in the audit log
+type AuditService struct {
+	db *gorm.DB
+}
+
+// NewAuditService creates a new audit service
+func NewAuditService() *AuditService {
+	return &AuditService{
+		db: database.GetDB(),
+	}
+}
+
+// Record adds an entry to the audit log
+func (s *AuditService) Record(entry *models.AuditLog) error {
+	if err := s.db.Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"car-rental-backend/models"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCarInMaintenance is returned when the requested window overlaps a maintenance block
+	ErrCarInMaintenance = errors.New("car is under maintenance for this time period")
+	// ErrMaintenanceBlockNotFound is returned when the requested maintenance block does not exist
+	ErrMaintenanceBlockNotFound = errors.New("maintenance block not found")
+)
+
+// availableBetween restricts a cars query to cars that are in service and have no
+// active booking or maintenance block overlapping the [start, end) window
+func availableBetween(query *gorm.DB, start, end time.Time) *gorm.DB {
+	return query.Joins("JOIN car_statuses ON car_statuses.car_id = cars.id").
+		Where("car_statuses.is_available = ?", true).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM bookings
+			WHERE bookings.car_id = cars.id
+			AND bookings.deleted_at IS NULL
+			AND bookings.status IN ?
+			AND bookings.start_time < ? AND bookings.end_time > ?
+		)`, models.ActiveBookingStatuses, end, start).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM car_maintenance_blocks
+			WHERE car_maintenance_blocks.car_id = cars.id
+			AND car_maintenance_blocks.deleted_at IS NULL
+			AND car_maintenance_blocks.start_time < ? AND car_maintenance_blocks.end_time > ?
+		)`, end, start)
+}
+
+// availableNow restricts a cars query to cars that can be driven away right now
+func availableNow(query *gorm.DB) *gorm.DB {
+	now := time.Now()
+	return availableBetween(query, now, now)
+}
+
+// countOverlappingBookings counts active bookings of a car that overlap the window
+func countOverlappingBookings(db *gorm.DB, carID uuid.UUID, start, end time.Time) (int64, error) {
+	var count int64
+	err := db.Model(&models.Booking{}).
+		Where("car_id = ? AND status IN ? AND start_time < ? AND end_time > ?",
+			carID, models.ActiveBookingStatuses, end, start).
+		Count(&count).Error
+	return count, err
+}
+
+// countOverlappingMaintenance counts maintenance blocks of a car that overlap the window
+func countOverlappingMaintenance(db *gorm.DB, carID uuid.UUID, start, end time.Time) (int64, error) {
+	var count int64
+	err := db.Model(&models.CarMaintenanceBlock{}).
+		Where("car_id = ? AND start_time < ? AND end_time > ?", carID, end, start).
+		Count(&count).Error
+	return count, err
+}
+
+// IsCarAvailable reports whether a car is in service and free for the whole window
+func (s *CarService) IsCarAvailable(carID uuid.UUID, start, end time.Time) (bool, error) {
+	var count int64
+	err := availableBetween(s.db.Model(&models.Car{}), start, end).
+		Where("cars.id = ?", carID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetCarCalendar lists the bookings and maintenance blocks of a car that overlap the window
+func (s *CarService) GetCarCalendar(carID uuid.UUID, from, to time.Time) ([]models.CarCalendarEntry, error) {
+	var bookings []models.Booking
+	if err := s.db.Where("car_id = ? AND status IN ? AND start_time < ? AND end_time > ?",
+		carID, models.ActiveBookingStatuses, to, from).
+		Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+
+	var blocks []models.CarMaintenanceBlock
+	if err := s.db.Where("car_id = ? AND start_time < ? AND end_time > ?", carID, to, from).
+		Find(&blocks).Error; err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.CarCalendarEntry, 0, len(bookings)+len(blocks))
+	for _, booking := range bookings {
+		entries = append(entries, models.CarCalendarEntry{
+			Type:      models.CalendarEntryBooking,
+			ID:        booking.ID.String(),
+			StartTime: booking.StartTime,
+			EndTime:   booking.EndTime,
+			Status:    string(booking.Status),
+		})
+	}
+	for _, block := range blocks {
+		entries = append(entries, models.CarCalendarEntry{
+			Type:      models.CalendarEntryMaintenance,
+			ID:        block.ID.String(),
+			StartTime: block.StartTime,
+			EndTime:   block.EndTime,
+			Reason:    block.Reason,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].StartTime.Before(entries[j].StartTime)
+	})
+
+	return entries, nil
+}
+
+// AddMaintenanceBlock blocks a car for maintenance, refusing windows that overlap active bookings
+func (s *CarService) AddMaintenanceBlock(block *models.CarMaintenanceBlock) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockCar(tx, block.CarID); err != nil {
+			return err
+		}
+
+		conflicts, err := countOverlappingBookings(tx, block.CarID, block.StartTime, block.EndTime)
+		if err != nil {
+			return fmt.Errorf("failed to check booking conflicts: %w", err)
+		}
+		if conflicts > 0 {
+			return ErrBookingConflict
+		}
+
+		if err := tx.Create(block).Error; err != nil {
+			return fmt.Errorf("failed to create maintenance block: %w", err)
+		}
+		return nil
+	})
+}
+
+// RemoveMaintenanceBlock deletes a maintenance block of a car
+func (s *CarService) RemoveMaintenanceBlock(carID, blockID uuid.UUID) error {
+	result := s.db.Where("car_id = ?", carID).Delete(&models.CarMaintenanceBlock{}, "id = ?", blockID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrMaintenanceBlockNotFound
+	}
+	return nil
+}
@@ -0,0 +1,523 @@
+package services
+
+import (
+	"car-rental-backend/database"
+	"car-rental-backend/models"
+	"car-rental-backend/pricing"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrCarNotFound is returned when the requested car does not exist
+	ErrCarNotFound = errors.New("car not found")
+	// ErrCarUnavailable is returned when the car is out of service
+	ErrCarUnavailable = errors.New("car is not available")
+	// ErrBookingConflict is returned when the requested window overlaps an existing booking
+	ErrBookingConflict = errors.New("car is already booked for this time period")
+	// ErrRentalInfoMissing is returned when a car has no rental information to price a booking
+	ErrRentalInfoMissing = errors.New("car rental information is missing")
+	// ErrBookingNotFound is returned when the requested booking does not exist
+	ErrBookingNotFound = errors.New("booking not found")
+	// ErrInvalidTransition is returned when a booking cannot move to the requested status
+	ErrInvalidTransition = errors.New("invalid booking status transition")
+	// ErrBookingNotExtendable is returned when a booking is not in a status that can be extended
+	ErrBookingNotExtendable = errors.New("booking cannot be extended")
+)
+
+// lateReturnGrace is how long after its end time a booking may be returned without a late fee
+var lateReturnGrace = 30 * time.Minute
+
+// SetLateReturnGrace configures the grace period before late returns are charged
+func SetLateReturnGrace(grace time.Duration) {
+	if grace >= 0 {
+		lateReturnGrace = grace
+	}
+}
+
+// BookingService handles all booking-related database operations
+type BookingService struct {
+	db      *gorm.DB
+	pricing pricing.PricingEngine
+}
+
+// NewBookingService creates a new booking service
+func NewBookingService() *BookingService {
+	return &BookingService{
+		db:      database.GetDB(),
+		pricing: pricing.NewStandardEngine(NewRateRuleService(), pricing.GetHolidayCalendar()),
+	}
+}
+
+// BookingInput describes a requested booking or quote
+type BookingInput struct {
+	UserID    uuid.UUID
+	CarID     uuid.UUID
+	StartTime time.Time
+	EndTime   time.Time
+	PromoCode string
+	Currency  string // currency to show prices in, empty for the stored currency
+}
+
+// QuoteBooking prices a rental window for a car without booking it. A promo
+// code is validated and applied to the quote but not redeemed, and tax is
+// charged at the rate of the car's jurisdiction. The quote is shown in the
+// input's currency if one is given.
+func (s *BookingService) QuoteBooking(input BookingInput) (*pricing.Quote, error) {
+	var car models.Car
+	if err := s.db.First(&car, "id = ?", input.CarID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCarNotFound
+		}
+		return nil, err
+	}
+
+	var rentalInfo models.CarRentalInfo
+	if err := s.db.Where("car_id = ?", input.CarID).First(&rentalInfo).Error; err != nil {
+		return nil, ErrRentalInfoMissing
+	}
+
+	quote, err := s.pricing.Quote(&rentalInfo, input.StartTime, input.EndTime)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.PromoCode != "" {
+		promotion, err := findPromotionByCode(s.db, input.PromoCode, false)
+		if err != nil {
+			return nil, err
+		}
+		if err := applyPromotion(s.db, promotion, input.UserID, &car, quote); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := applyCarTax(s.db, &car, quote); err != nil {
+		return nil, err
+	}
+
+	exchangeRate, err := exchangeRateFor(s.db, input.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if exchangeRate != nil {
+		quote.Convert(exchangeRate)
+	}
+
+	return quote, nil
+}
+
+// CreateBooking creates a booking for the given car and time window.
+//
+// The car row is locked for the duration of the transaction so concurrent
+// requests for the same car are serialised; the bookings_no_overlap exclusion
+// constraint is the last line of defence if a writer bypasses this service.
+// A promo code is redeemed in the same transaction, with the promotion row
+// locked so its redemption caps cannot be exceeded, and the car's security
+// deposit is put on hold. The exchange rate of the input's currency is kept
+// on the booking.
+//
+// New bookings are PENDING. They hold the car and are confirmed once their
+// payment is authorized, or by an admin.
+func (s *BookingService) CreateBooking(input BookingInput) (*models.Booking, error) {
+	var booking models.Booking
+	start, end := input.StartTime, input.EndTime
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the car so overlapping requests wait for each other
+		car, err := lockCar(tx, input.CarID)
+		if err != nil {
+			return err
+		}
+
+		// A car that is out of service cannot be booked for any window
+		var status models.CarStatus
+		if err := tx.Where("car_id = ?", car.ID).First(&status).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCarUnavailable
+			}
+			return fmt.Errorf("failed to load car status: %w", err)
+		}
+		if !status.IsAvailable {
+			return ErrCarUnavailable
+		}
+
+		var rentalInfo models.CarRentalInfo
+		if err := tx.Where("car_id = ?", car.ID).First(&rentalInfo).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRentalInfoMissing
+			}
+			return fmt.Errorf("failed to load car rental info: %w", err)
+		}
+
+		quote, err := s.pricing.Quote(&rentalInfo, start, end)
+		if err != nil {
+			return err
+		}
+
+		// Check for booking conflicts
+		conflictCount, err := countOverlappingBookings(tx, car.ID, start, end)
+		if err != nil {
+			return fmt.Errorf("failed to check booking conflicts: %w", err)
+		}
+		if conflictCount > 0 {
+			return ErrBookingConflict
+		}
+
+		// Check for maintenance blocks
+		maintenanceCount, err := countOverlappingMaintenance(tx, car.ID, start, end)
+		if err != nil {
+			return fmt.Errorf("failed to check maintenance blocks: %w", err)
+		}
+		if maintenanceCount > 0 {
+			return ErrCarInMaintenance
+		}
+
+		var promotion *models.Promotion
+		if input.PromoCode != "" {
+			if promotion, err = findPromotionByCode(tx, input.PromoCode, true); err != nil {
+				return err
+			}
+			if err := applyPromotion(tx, promotion, input.UserID, car, quote); err != nil {
+				return err
+			}
+		}
+
+		taxRate, err := applyCarTax(tx, car, quote)
+		if err != nil {
+			return err
+		}
+
+		exchangeRate, err := exchangeRateFor(tx, input.Currency)
+		if err != nil {
+			return err
+		}
+
+		booking = models.Booking{
+			UserID:         input.UserID,
+			CarID:          car.ID,
+			StartTime:      start,
+			EndTime:        end,
+			Status:         models.BookingStatusPending,
+			TotalPrice:     quote.Total,
+			DiscountAmount: quote.Discount,
+			Tax:            bookingTax(taxRate, quote),
+		}
+		if promotion != nil {
+			booking.PromotionID = &promotion.ID
+			booking.PromoCode = promotion.Code
+		}
+		if exchangeRate != nil {
+			booking.ExchangeRate = *exchangeRate.Snapshot()
+		}
+
+		if err := tx.Create(&booking).Error; err != nil {
+			if isOverlapViolation(err) {
+				return ErrBookingConflict
+			}
+			return fmt.Errorf("failed to create booking: %w", err)
+		}
+
+		if promotion != nil {
+			if err := redeemPromotion(tx, promotion, &booking); err != nil {
+				return err
+			}
+		}
+
+		if err := holdDeposit(tx, &booking, quote.Deposit, input.UserID); err != nil {
+			return err
+		}
+
+		return recordStatusChange(tx, &booking, "", input.UserID, "Booking created")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &booking, nil
+}
+
+// TransitionBooking moves a booking to the given status if the state machine allows it,
+// recording the change in the booking's status history
+func (s *BookingService) TransitionBooking(bookingID uuid.UUID, to models.BookingStatus, actorID uuid.UUID, note string) (*models.Booking, error) {
+	var booking models.Booking
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&booking, "id = ?", bookingID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrBookingNotFound
+			}
+			return fmt.Errorf("failed to load booking: %w", err)
+		}
+
+		from := booking.Status
+		if !from.CanTransitionTo(to) {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, from, to)
+		}
+
+		now := time.Now()
+		booking.Status = to
+		switch to {
+		case models.BookingStatusPickedUp:
+			booking.PickedUpAt = &now
+		case models.BookingStatusReturned:
+			booking.ReturnedAt = &now
+			if err := chargeLateFee(tx, &booking, now); err != nil {
+				return err
+			}
+		case models.BookingStatusCancelled:
+			if err := applyCancellationPolicy(tx, &booking, now); err != nil {
+				return err
+			}
+		case models.BookingStatusCompleted:
+			// Whatever is still held on the deposit is refunded, so the invoice
+			// shows the settled deposit
+			if err := releaseBookingDeposit(tx, booking.ID, "Booking completed", actorID); err != nil {
+				return err
+			}
+			if _, err := issueInvoice(tx, &booking, now); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Omit(clause.Associations).Save(&booking).Error; err != nil {
+			return fmt.Errorf("failed to update booking: %w", err)
+		}
+
+		if to == models.BookingStatusCancelled {
+			if err := releasePromotion(tx, &booking); err != nil {
+				return err
+			}
+			if err := releaseBookingDeposit(tx, booking.ID, "Booking cancelled", actorID); err != nil {
+				return err
+			}
+		}
+
+		return recordStatusChange(tx, &booking, from, actorID, note)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &booking, nil
+}
+
+// ExtendBooking moves the end time of an active booking to newEnd. The extra
+// window must be free of other bookings and maintenance; it is priced with the
+// car's extend fees and recorded as an extension charge on the booking.
+func (s *BookingService) ExtendBooking(bookingID uuid.UUID, newEnd time.Time) (*models.Booking, error) {
+	var booking models.Booking
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&booking, "id = ?", bookingID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrBookingNotFound
+			}
+			return fmt.Errorf("failed to load booking: %w", err)
+		}
+
+		if booking.Status != models.BookingStatusConfirmed && booking.Status != models.BookingStatusPickedUp {
+			return fmt.Errorf("%w in status %s", ErrBookingNotExtendable, booking.Status)
+		}
+
+		// Lock the car so the extra window cannot be booked concurrently
+		if _, err := lockCar(tx, booking.CarID); err != nil {
+			return err
+		}
+
+		var rentalInfo models.CarRentalInfo
+		if err := tx.Where("car_id = ?", booking.CarID).First(&rentalInfo).Error; err != nil {
+			return ErrRentalInfoMissing
+		}
+
+		quote, err := s.pricing.QuoteExtension(&rentalInfo, booking.EndTime, newEnd)
+		if err != nil {
+			return err
+		}
+
+		// Check the extra window against the car's other bookings
+		var conflictCount int64
+		if err := tx.Model(&models.Booking{}).
+			Where("car_id = ? AND id <> ? AND status IN ? AND start_time < ? AND end_time > ?",
+				booking.CarID, booking.ID, models.ActiveBookingStatuses, newEnd, booking.EndTime).
+			Count(&conflictCount).Error; err != nil {
+			return fmt.Errorf("failed to check booking conflicts: %w", err)
+		}
+		if conflictCount > 0 {
+			return ErrBookingConflict
+		}
+
+		maintenanceCount, err := countOverlappingMaintenance(tx, booking.CarID, booking.EndTime, newEnd)
+		if err != nil {
+			return fmt.Errorf("failed to check maintenance blocks: %w", err)
+		}
+		if maintenanceCount > 0 {
+			return ErrCarInMaintenance
+		}
+
+		// Extensions are more rental time, so they are always taxed. The extra
+		// time mixes daily and hourly rates, so it is one line at the quoted price.
+		tax, total, err := taxAmount(&booking, quote.Total)
+		if err != nil {
+			return err
+		}
+		description := fmt.Sprintf("Extension from %s to %s, %d hour(s)",
+			booking.EndTime.Format(time.RFC3339), newEnd.Format(time.RFC3339), quote.BillableHours)
+		charge := models.BookingCharge{
+			BookingID:   booking.ID,
+			Type:        models.BookingChargeExtension,
+			Description: description,
+			Quantity:    1,
+			UnitPrice:   quote.Total,
+			Amount:      quote.Total,
+			TaxAmount:   tax,
+		}
+		if err := tx.Create(&charge).Error; err != nil {
+			return fmt.Errorf("failed to record extension: %w", err)
+		}
+
+		booking.EndTime = newEnd
+		if booking.TotalPrice, err = booking.TotalPrice.Add(total); err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Save(&booking).Error; err != nil {
+			if isOverlapViolation(err) {
+				return ErrBookingConflict
+			}
+			return fmt.Errorf("failed to update booking: %w", err)
+		}
+
+		booking.Charges = append(booking.Charges, charge)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &booking, nil
+}
+
+// GetOverdueBookings lists picked-up bookings that are past their end time and
+// grace period, with the late fee accrued so far
+func (s *BookingService) GetOverdueBookings() ([]models.OverdueBooking, error) {
+	now := time.Now()
+
+	// The late fee rates come with the cars, so the list takes one query per
+	// association rather than one per booking
+	var bookings []models.Booking
+	if err := s.db.Preload("Car.RentalInfo").Preload("User").
+		Where("status = ? AND end_time < ?", models.BookingStatusPickedUp, now.Add(-lateReturnGrace)).
+		Order("end_time ASC").
+		Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+
+	overdue := make([]models.OverdueBooking, 0, len(bookings))
+	for _, booking := range bookings {
+		if booking.Car.RentalInfo == nil {
+			return nil, ErrRentalInfoMissing
+		}
+
+		hours, fee := pricing.LateFee(booking.Car.RentalInfo, booking.EndTime, now, lateReturnGrace)
+		overdue = append(overdue, models.OverdueBooking{
+			Booking:        booking,
+			HoursOverdue:   hours,
+			AccruedLateFee: fee,
+		})
+	}
+
+	return overdue, nil
+}
+
+// GetBookingHistory retrieves the status history of a booking, oldest first
+func (s *BookingService) GetBookingHistory(bookingID uuid.UUID) ([]models.BookingStatusHistory, error) {
+	var history []models.BookingStatusHistory
+
+	err := s.db.Where("booking_id = ?", bookingID).Order("created_at ASC").Find(&history).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// lockCar takes a row lock on the car so that concurrent schedule changes are serialised
+func lockCar(tx *gorm.DB, carID uuid.UUID) (*models.Car, error) {
+	var car models.Car
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&car, "id = ?", carID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCarNotFound
+		}
+		return nil, fmt.Errorf("failed to lock car: %w", err)
+	}
+	return &car, nil
+}
+
+// chargeLateFee adds a late fee charge to a booking returned at returnedAt,
+// if it came back after its end time and grace period
+func chargeLateFee(tx *gorm.DB, booking *models.Booking, returnedAt time.Time) error {
+	var rentalInfo models.CarRentalInfo
+	if err := tx.Where("car_id = ?", booking.CarID).First(&rentalInfo).Error; err != nil {
+		return ErrRentalInfoMissing
+	}
+
+	hours, fee := pricing.LateFee(&rentalInfo, booking.EndTime, returnedAt, lateReturnGrace)
+	if hours == 0 {
+		return nil
+	}
+
+	charge := models.BookingCharge{
+		BookingID:   booking.ID,
+		Type:        models.BookingChargeLateFee,
+		Description: fmt.Sprintf("Late return, %d hour(s) overdue", hours),
+		Quantity:    float64(hours),
+		UnitPrice:   rentalInfo.LateFeePerHour,
+		Amount:      fee,
+	}
+	total := fee
+	if booking.Tax.OnFees {
+		var err error
+		if charge.TaxAmount, total, err = taxAmount(booking, fee); err != nil {
+			return err
+		}
+	}
+	if err := tx.Create(&charge).Error; err != nil {
+		return fmt.Errorf("failed to record late fee: %w", err)
+	}
+
+	totalPrice, err := booking.TotalPrice.Add(total)
+	if err != nil {
+		return err
+	}
+	booking.TotalPrice = totalPrice
+	booking.Charges = append(booking.Charges, charge)
+	return nil
+}
+
+// recordStatusChange appends an entry to the booking's status history
+func recordStatusChange(tx *gorm.DB, booking *models.Booking, from models.BookingStatus, actorID uuid.UUID, note string) error {
+	entry := models.BookingStatusHistory{
+		BookingID:  booking.ID,
+		FromStatus: from,
+		ToStatus:   booking.Status,
+		ChangedBy:  actorID,
+		Note:       note,
+	}
+
+	if err := tx.Create(&entry).Error; err != nil {
+		return fmt.Errorf("failed to record booking history: %w", err)
+	}
+	return nil
+}
+
+// isOverlapViolation reports whether err was raised by the bookings_no_overlap exclusion constraint
+func isOverlapViolation(err error) bool {
+	return err != nil && (strings.Contains(err.Error(), "bookings_no_overlap") ||
+		strings.Contains(err.Error(), "violates exclusion constraint"))
+}
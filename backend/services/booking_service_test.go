@@ -0,0 +1,180 @@
+package services
+
+import (
+	"car-rental-backend/database"
+	"car-rental-backend/models"
+	"car-rental-backend/money"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// concurrentBookings is how many overlapping requests race for the same car
+const concurrentBookings = 10
+
+func TestCreateBookingConcurrentOverlappingRequests(t *testing.T) {
+	setupTestDB(t)
+	user, car := createBookableCar(t)
+
+	// Every window overlaps every other, so only one request may win
+	base := time.Now().Add(72 * time.Hour).Truncate(time.Hour)
+	errs := raceBookings(func(i int) error {
+		start := base.Add(time.Duration(i) * 10 * time.Minute)
+		_, err := NewBookingService().CreateBooking(BookingInput{
+			UserID:    user.ID,
+			CarID:     car.ID,
+			StartTime: start,
+			EndTime:   start.Add(4 * time.Hour),
+		})
+		return err
+	})
+
+	created := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, ErrBookingConflict):
+			t.Errorf("got error %v, want %v", err, ErrBookingConflict)
+		}
+	}
+	if created != 1 {
+		t.Errorf("created %d bookings, want 1", created)
+	}
+
+	var bookings []models.Booking
+	if err := database.DB.Where("car_id = ?", car.ID).Find(&bookings).Error; err != nil {
+		t.Fatalf("failed to load bookings: %v", err)
+	}
+	if len(bookings) != 1 {
+		t.Fatalf("found %d bookings for the car, want 1", len(bookings))
+	}
+	if bookings[0].Status != models.BookingStatusPending {
+		t.Errorf("got booking status %s, want %s", bookings[0].Status, models.BookingStatusPending)
+	}
+}
+
+func TestBookingsNoOverlapConstraint(t *testing.T) {
+	setupTestDB(t)
+	user, car := createBookableCar(t)
+
+	// Insert directly, without the car lock, so only the exclusion constraint
+	// stands between the writers
+	start := time.Now().Add(72 * time.Hour).Truncate(time.Hour)
+	errs := raceBookings(func(i int) error {
+		return database.DB.Create(&models.Booking{
+			UserID:     user.ID,
+			CarID:      car.ID,
+			StartTime:  start,
+			EndTime:    start.Add(4 * time.Hour),
+			Status:     models.BookingStatusConfirmed,
+			TotalPrice: money.FromMinor(10000),
+		}).Error
+	})
+
+	created := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !isOverlapViolation(err):
+			t.Errorf("got error %v, want an exclusion constraint violation", err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("created %d bookings, want 1", created)
+	}
+}
+
+// raceBookings runs book concurrentBookings times at once and returns the errors
+func raceBookings(book func(i int) error) []error {
+	errs := make([]error, concurrentBookings)
+	start := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrentBookings; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = book(i)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	return errs
+}
+
+// createBookableCar creates a customer and an available car with rental info
+func createBookableCar(t *testing.T) (*models.User, *models.Car) {
+	t.Helper()
+	db := database.DB
+
+	user := &models.User{
+		Name:  "Booking Test User",
+		Email: uuid.NewString() + "@example.com",
+		Role:  models.UserRoleUser,
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	owner := &models.Owner{Name: "Booking Test Owner", RevenueSharePercent: 70}
+	if err := db.Create(owner).Error; err != nil {
+		t.Fatalf("failed to create owner: %v", err)
+	}
+
+	car := &models.Car{
+		Make:            "Toyota",
+		Model:           "Corolla",
+		Year:            2022,
+		FuelType:        models.FuelTypePetrol,
+		Transmission:    models.TransmissionAutomatic,
+		BodyType:        models.BodyTypeSedan,
+		SeatingCapacity: 5,
+		VehicleNumber:   "TEST-" + uuid.NewString()[:8],
+		OwnerID:         owner.ID,
+	}
+	if err := db.Create(car).Error; err != nil {
+		t.Fatalf("failed to create car: %v", err)
+	}
+
+	if err := db.Create(&models.CarStatus{CarID: car.ID, IsAvailable: true}).Error; err != nil {
+		t.Fatalf("failed to create car status: %v", err)
+	}
+	if err := db.Create(&models.CarRentalInfo{
+		CarID:                  car.ID,
+		RentalPricePerDay:      money.FromMinor(5000),
+		RentalPricePerHour:     money.FromMinor(500),
+		MinimumRentDuration:    1,
+		SecurityDeposit:        money.FromMinor(20000),
+		LateFeePerHour:         money.FromMinor(1000),
+		RentalExtendFeePerDay:  money.FromMinor(6000),
+		RentalExtendFeePerHour: money.FromMinor(600),
+	}).Error; err != nil {
+		t.Fatalf("failed to create car rental info: %v", err)
+	}
+
+	return user, car
+}
+
+// setupTestDB connects to the database in TEST_DATABASE_DSN, which must have
+// been migrated. Tests are skipped if it is not set.
+func setupTestDB(t *testing.T) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	db, err := database.GetDirectDB(dsn)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	database.DB = db
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"car-rental-backend/database"
+	"car-rental-backend/models"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCancellationPolicyNotFound is returned when the requested cancellation policy does not exist
+	ErrCancellationPolicyNotFound = errors.New("cancellation policy not found")
+	// ErrCancellationPolicyExists is returned when the car, or the global scope, already has a policy
+	ErrCancellationPolicyExists = errors.New("a cancellation policy already exists for this scope")
+)
+
+// CancellationPolicyService handles all cancellation-policy-related database operations
+type CancellationPolicyService struct {
+	db *gorm.DB
+}
+
+// NewCancellationPolicyService creates a new cancellation policy service
+func NewCancellationPolicyService() *CancellationPolicyService {
+	return &CancellationPolicyService{
+		db: database.GetDB(),
+	}
+}
+
+// GetPolicies retrieves all cancellation policies, the global policy first
+func (s *CancellationPolicyService) GetPolicies() ([]models.CancellationPolicy, error) {
+	var policies []models.CancellationPolicy
+	if err := s.db.Order("car_id NULLS FIRST, created_at ASC").Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// GetPolicyByID retrieves a cancellation policy by ID
+func (s *CancellationPolicyService) GetPolicyByID(id uuid.UUID) (*models.CancellationPolicy, error) {
+	var policy models.CancellationPolicy
+	if err := s.db.First(&policy, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCancellationPolicyNotFound
+		}
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// CreatePolicy creates a new cancellation policy
+func (s *CancellationPolicyService) CreatePolicy(policy *models.CancellationPolicy) error {
+	return policyScopeError(s.db.Create(policy).Error)
+}
+
+// UpdatePolicy saves changes to an existing cancellation policy
+func (s *CancellationPolicyService) UpdatePolicy(policy *models.CancellationPolicy) error {
+	return policyScopeError(s.db.Save(policy).Error)
+}
+
+// DeletePolicy deletes a cancellation policy
+func (s *CancellationPolicyService) DeletePolicy(policy *models.CancellationPolicy) error {
+	return s.db.Delete(policy).Error
+}
+
+// policyScopeError maps a violation of the one-policy-per-scope indexes to ErrCancellationPolicyExists
+func policyScopeError(err error) error {
+	if err != nil && strings.Contains(err.Error(), "idx_cancellation_policies_") {
+		return ErrCancellationPolicyExists
+	}
+	return err
+}
+
+// policyForCar returns the car's own cancellation policy, falling back to the
+// global policy, or nil if neither exists
+func policyForCar(db *gorm.DB, carID uuid.UUID) (*models.CancellationPolicy, error) {
+	var policies []models.CancellationPolicy
+	if err := db.Where("car_id = ? OR car_id IS NULL", carID).
+		Order("car_id NULLS LAST").
+		Limit(1).
+		Find(&policies).Error; err != nil {
+		return nil, fmt.Errorf("failed to load cancellation policy: %w", err)
+	}
+	if len(policies) == 0 {
+		return nil, nil
+	}
+	return &policies[0], nil
+}
+
+// applyCancellationPolicy sets the refund due on a booking cancelled at cancelledAt.
+// Without any policy the full price is refunded.
+func applyCancellationPolicy(tx *gorm.DB, booking *models.Booking, cancelledAt time.Time) error {
+	policy, err := policyForCar(tx, booking.CarID)
+	if err != nil {
+		return err
+	}
+
+	refund := booking.TotalPrice
+	if policy != nil {
+		refund = policy.RefundFor(booking.TotalPrice, booking.StartTime.Sub(cancelledAt).Hours())
+	}
+
+	booking.RefundAmount = &refund
+	return nil
+}
@@ -3,8 +3,10 @@ package services
 import (
 	"car-rental-backend/database"
 	"car-rental-backend/models"
+	"car-rental-backend/money"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -59,12 +61,11 @@ func (s *CarService) GetAllCars() ([]models.Car, error) {
 	return cars, nil
 }
 
-// GetAvailableCars retrieves all available cars
+// GetAvailableCars retrieves all cars that are in service and not booked or under maintenance right now
 func (s *CarService) GetAvailableCars() ([]models.Car, error) {
 	var cars []models.Car
 
-	err := s.db.Joins("JOIN car_statuses ON car_statuses.car_id = cars.id").
-		Where("car_statuses.is_available = ?", true).
+	err := availableNow(s.db.Model(&models.Car{})).
 		Preload("Owner").
 		Preload("RentalInfo").
 		Preload("Media").
@@ -208,7 +209,7 @@ func (s *CarService) UpdateCar(id uuid.UUID, carUpdates map[string]interface{},
 	return s.GetCarByID(id)
 }
 
-// UpdateCarAvailability updates a car's availability status
+// UpdateCarAvailability marks a car as in or out of service
 func (s *CarService) UpdateCarAvailability(id uuid.UUID, isAvailable bool) error {
 	return s.db.Model(&models.CarStatus{}).Where("car_id = ?", id).Update("is_available", isAvailable).Error
 }
@@ -314,7 +315,7 @@ func (s *CarService) RemoveCarMedia(mediaID uuid.UUID) error {
 }
 
 // SearchCars searches for cars based on various criteria
-func (s *CarService) SearchCars(make, model string, isAvailable *bool, minPrice, maxPrice *float64) ([]models.Car, error) {
+func (s *CarService) SearchCars(make, model string, isAvailable *bool, minPrice, maxPrice *money.Money) ([]models.Car, error) {
 	query := s.db.Model(&models.Car{})
 
 	// Join related tables if needed
@@ -390,7 +391,6 @@ func (s *CarService) OwnerExists(ownerID uuid.UUID) (bool, error) {
 func (s *CarService) GetAllCarsPaginated(page, pageSize int, filters map[string]interface{}) ([]models.Car, int64, error) {
 	var cars []models.Car
 	var totalItems int64
-	var loadBookings bool
 
 	// Start building the query
 	query := s.db.Model(&models.Car{})
@@ -452,18 +452,24 @@ func (s *CarService) GetAllCarsPaginated(page, pageSize int, filters map[string]
 			countQuery = countQuery.Where("body_type = ?", bodyType)
 		}
 
-		// Is Available - for filtering available cars
-		if isAvailable, ok := filters["is_available"].(bool); ok && isAvailable {
+		// Available From/To - cars that are in service and free for the whole window
+		availableFrom, hasFrom := filters["available_from"].(time.Time)
+		availableTo, hasTo := filters["available_to"].(time.Time)
+
+		if hasFrom && hasTo {
+			query = availableBetween(query, availableFrom, availableTo)
+			countQuery = availableBetween(countQuery, availableFrom, availableTo)
+		} else if isAvailable, ok := filters["is_available"].(bool); ok && isAvailable {
+			// Is Available - cars that are in service and free right now
+			query = availableNow(query)
+			countQuery = availableNow(countQuery)
+		} else if inService, ok := filters["in_service"].(bool); ok && inService {
+			// In Service - cars not taken out of service, regardless of bookings
 			query = query.Joins("JOIN car_statuses ON car_statuses.car_id = cars.id").
 				Where("car_statuses.is_available = ?", true)
 			countQuery = countQuery.Joins("JOIN car_statuses ON car_statuses.car_id = cars.id").
 				Where("car_statuses.is_available = ?", true)
 		}
-
-		// Check if we need to load bookings
-		if _, ok := filters["load_bookings"]; ok {
-			loadBookings = true
-		}
 	}
 
 	// Count total items for pagination - use a simpler count query
@@ -477,21 +483,14 @@ func (s *CarService) GetAllCarsPaginated(page, pageSize int, filters map[string]
 	query = query.Offset(offset).Limit(pageSize)
 
 	// Apply ordering
-	query = query.Order("created_at DESC")
+	query = query.Order("cars.created_at DESC")
 
-	// Build the preload query based on needs
-	preloadQuery := query.Preload("Owner").
+	// Execute the query with preloaded relationships
+	err := query.Preload("Owner").
 		Preload("RentalInfo").
 		Preload("Media").
-		Preload("CurrentStatus")
-
-	// Conditionally preload bookings
-	if loadBookings {
-		preloadQuery = preloadQuery.Preload("Bookings")
-	}
-
-	// Execute the query with preloaded relationships
-	err := preloadQuery.Find(&cars).Error
+		Preload("CurrentStatus").
+		Find(&cars).Error
 
 	if err != nil {
 		return nil, 0, err
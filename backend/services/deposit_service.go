@@ -0,0 +1,269 @@
+package services
+
+import (
+	"car-rental-backend/database"
+	"car-rental-backend/models"
+	"car-rental-backend/money"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrDepositNotFound is returned when a booking has no deposit
+	ErrDepositNotFound = errors.New("deposit not found")
+	// ErrDepositSettled is returned when a deposit has already been fully captured or released
+	ErrDepositSettled = errors.New("deposit has already been settled")
+	// ErrDepositNotSettleable is returned when the booking is not in a status in which the deposit can be settled
+	ErrDepositNotSettleable = errors.New("deposit can only be settled after the car is returned and before the booking is completed")
+	// ErrCaptureExceedsBalance is returned when a capture is larger than the amount still held
+	ErrCaptureExceedsBalance = errors.New("capture amount exceeds the deposit balance")
+)
+
+// depositSettleableStatuses are the booking statuses in which an admin may settle the deposit.
+// Completed bookings are left out: captures change the booking's total and tax,
+// which are fixed by the invoice issued on completion.
+var depositSettleableStatuses = []models.BookingStatus{
+	models.BookingStatusReturned,
+	models.BookingStatusNoShow,
+}
+
+// DepositService handles all deposit-related database operations
+type DepositService struct {
+	db *gorm.DB
+}
+
+// NewDepositService creates a new deposit service
+func NewDepositService() *DepositService {
+	return &DepositService{
+		db: database.GetDB(),
+	}
+}
+
+// GetStatement summarises the deposit held against a booking and lists its ledger entries
+func (s *DepositService) GetStatement(bookingID uuid.UUID) (*models.DepositStatement, error) {
+	var deposit models.Deposit
+	if err := s.db.Preload("Entries", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).First(&deposit, "booking_id = ?", bookingID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDepositNotFound
+		}
+		return nil, err
+	}
+
+	balance, err := deposit.Balance()
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DepositStatement{
+		BookingID: deposit.BookingID,
+		Status:    deposit.Status,
+		Held:      deposit.Amount,
+		Captured:  deposit.CapturedAmount,
+		Refunded:  deposit.RefundedAmount,
+		Balance:   balance,
+		Entries:   deposit.Entries,
+	}, nil
+}
+
+// CaptureDeposit keeps part of a returned booking's deposit, e.g. for damage or late fees.
+// If the booking's tax applies to deposits, tax is charged on the amount kept;
+// exclusive tax is added to the booking's total.
+func (s *DepositService) CaptureDeposit(bookingID uuid.UUID, amount money.Money, reason string, actorID uuid.UUID) (*models.Deposit, error) {
+	var deposit *models.Deposit
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		booking, locked, err := lockSettleableDeposit(tx, bookingID)
+		if err != nil {
+			return err
+		}
+		deposit = locked
+
+		if !amount.SameCurrency(deposit.Amount) {
+			return fmt.Errorf("%w: deposit is held in %s", money.ErrCurrencyMismatch, deposit.Amount.Currency)
+		}
+		balance, err := deposit.Balance()
+		if err != nil {
+			return err
+		}
+		if exceeds, err := amount.GreaterThan(balance); err != nil {
+			return err
+		} else if exceeds {
+			return fmt.Errorf("%w of %s", ErrCaptureExceedsBalance, balance)
+		}
+
+		if deposit.CapturedAmount, err = deposit.CapturedAmount.Add(amount); err != nil {
+			return err
+		}
+		deposit.Status = models.DepositStatusPartiallyCaptured
+		if balance, err = balance.Sub(amount); err != nil {
+			return err
+		}
+		if balance.IsZero() {
+			deposit.Status = models.DepositStatusCaptured
+		}
+
+		tax := money.New(0, amount.Currency)
+		if booking.Tax.OnDeposits {
+			var total, growth money.Money
+			if tax, total, err = taxAmount(booking, amount); err != nil {
+				return err
+			}
+			if growth, err = total.Sub(amount); err != nil {
+				return err
+			}
+			if booking.TotalPrice, err = booking.TotalPrice.Add(growth); err != nil {
+				return err
+			}
+			if err := tx.Model(booking).Updates(map[string]interface{}{
+				"total_price": booking.TotalPrice,
+				"tax_amount":  booking.Tax.Amount,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to update booking: %w", err)
+			}
+		}
+
+		return saveDepositEntry(tx, deposit, models.DepositEntryCapture, amount, tax, reason, actorID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deposit, nil
+}
+
+// ReleaseDeposit refunds whatever is still held on a returned booking's deposit and settles it
+func (s *DepositService) ReleaseDeposit(bookingID uuid.UUID, reason string, actorID uuid.UUID) (*models.Deposit, error) {
+	var deposit *models.Deposit
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if _, deposit, err = lockSettleableDeposit(tx, bookingID); err != nil {
+			return err
+		}
+		return releaseDeposit(tx, deposit, reason, actorID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deposit, nil
+}
+
+// holdDeposit records the security deposit taken when a booking is created
+func holdDeposit(tx *gorm.DB, booking *models.Booking, amount money.Money, actorID uuid.UUID) error {
+	if !amount.IsPositive() {
+		return nil
+	}
+
+	deposit := models.Deposit{
+		BookingID: booking.ID,
+		Amount:    amount,
+		Status:    models.DepositStatusHeld,
+	}
+	if err := tx.Create(&deposit).Error; err != nil {
+		return fmt.Errorf("failed to hold deposit: %w", err)
+	}
+
+	entry := models.DepositEntry{
+		DepositID: deposit.ID,
+		Type:      models.DepositEntryHold,
+		Amount:    deposit.Amount,
+		Reason:    "Security deposit",
+		CreatedBy: actorID,
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		return fmt.Errorf("failed to record deposit hold: %w", err)
+	}
+	return nil
+}
+
+// releaseBookingDeposit refunds the deposit of a booking if it has one that is not yet settled
+func releaseBookingDeposit(tx *gorm.DB, bookingID uuid.UUID, reason string, actorID uuid.UUID) error {
+	var deposit models.Deposit
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&deposit, "booking_id = ?", bookingID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to load deposit: %w", err)
+	}
+	if deposit.IsSettled() {
+		return nil
+	}
+	return releaseDeposit(tx, &deposit, reason, actorID)
+}
+
+// releaseDeposit refunds the remaining balance of a locked deposit
+func releaseDeposit(tx *gorm.DB, deposit *models.Deposit, reason string, actorID uuid.UUID) error {
+	refund, err := deposit.Balance()
+	if err != nil {
+		return err
+	}
+	if deposit.RefundedAmount, err = deposit.RefundedAmount.Add(refund); err != nil {
+		return err
+	}
+	deposit.Status = models.DepositStatusReleased
+	return saveDepositEntry(tx, deposit, models.DepositEntryRelease, refund, money.New(0, refund.Currency), reason, actorID)
+}
+
+// lockSettleableDeposit locks a booking that is in a settleable status and its deposit
+func lockSettleableDeposit(tx *gorm.DB, bookingID uuid.UUID) (*models.Booking, *models.Deposit, error) {
+	var booking models.Booking
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&booking, "id = ?", bookingID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrBookingNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to load booking: %w", err)
+	}
+
+	settleable := false
+	for _, status := range depositSettleableStatuses {
+		if booking.Status == status {
+			settleable = true
+			break
+		}
+	}
+	if !settleable {
+		return nil, nil, ErrDepositNotSettleable
+	}
+
+	var deposit models.Deposit
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&deposit, "booking_id = ?", bookingID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrDepositNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to load deposit: %w", err)
+	}
+	if deposit.IsSettled() {
+		return nil, nil, ErrDepositSettled
+	}
+
+	return &booking, &deposit, nil
+}
+
+// saveDepositEntry saves the deposit's new totals and appends the movement to its ledger
+func saveDepositEntry(tx *gorm.DB, deposit *models.Deposit, entryType models.DepositEntryType, amount, tax money.Money, reason string, actorID uuid.UUID) error {
+	if err := tx.Omit(clause.Associations).Save(deposit).Error; err != nil {
+		return fmt.Errorf("failed to update deposit: %w", err)
+	}
+
+	entry := models.DepositEntry{
+		DepositID: deposit.ID,
+		Type:      entryType,
+		Amount:    amount,
+		TaxAmount: tax,
+		Reason:    reason,
+		CreatedBy: actorID,
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		return fmt.Errorf("failed to record deposit entry: %w", err)
+	}
+
+	deposit.Entries = append(deposit.Entries, entry)
+	return nil
+}
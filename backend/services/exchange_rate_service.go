@@ -0,0 +1,104 @@
+package services
+
+import (
+	"car-rental-backend/database"
+	"car-rental-backend/models"
+	"car-rental-backend/money"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrExchangeRateNotFound is returned when prices are asked for in a currency without a rate
+	ErrExchangeRateNotFound = errors.New("no exchange rate for this currency")
+	// ErrInvalidExchangeRates is returned when an uploaded rate table is unusable
+	ErrInvalidExchangeRates = errors.New("invalid exchange rates")
+)
+
+// ExchangeRateService handles all exchange-rate-related database operations
+type ExchangeRateService struct {
+	db *gorm.DB
+}
+
+// NewExchangeRateService creates a new exchange rate service
+func NewExchangeRateService() *ExchangeRateService {
+	return &ExchangeRateService{
+		db: database.GetDB(),
+	}
+}
+
+// GetExchangeRates retrieves the current exchange rates ordered by currency
+func (s *ExchangeRateService) GetExchangeRates() ([]models.ExchangeRate, error) {
+	var rates []models.ExchangeRate
+	if err := s.db.Order("currency ASC").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// GetExchangeRate returns the rate to show prices in currency, or nil if
+// currency is empty or the currency amounts are stored in
+func (s *ExchangeRateService) GetExchangeRate(currency string) (*models.ExchangeRate, error) {
+	return exchangeRateFor(s.db, currency)
+}
+
+// ReplaceExchangeRates replaces the whole rate table with rates. Bookings keep
+// the snapshot of the rate they were made with.
+func (s *ExchangeRateService) ReplaceExchangeRates(rates []models.ExchangeRate) ([]models.ExchangeRate, error) {
+	seen := make(map[string]bool, len(rates))
+	for i := range rates {
+		rates[i].Currency = strings.ToUpper(strings.TrimSpace(rates[i].Currency))
+		currency := rates[i].Currency
+
+		switch {
+		case !money.ValidCurrency(currency):
+			return nil, fmt.Errorf("%w: %q is not a currency code", ErrInvalidExchangeRates, currency)
+		case currency == money.DefaultCurrency():
+			return nil, fmt.Errorf("%w: %s is the stored currency", ErrInvalidExchangeRates, currency)
+		case seen[currency]:
+			return nil, fmt.Errorf("%w: %s is listed twice", ErrInvalidExchangeRates, currency)
+		case rates[i].Rate <= 0:
+			return nil, fmt.Errorf("%w: rate for %s must be positive", ErrInvalidExchangeRates, currency)
+		}
+		seen[currency] = true
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.ExchangeRate{}).Error; err != nil {
+			return fmt.Errorf("failed to clear exchange rates: %w", err)
+		}
+		if len(rates) == 0 {
+			return nil
+		}
+		if err := tx.Create(&rates).Error; err != nil {
+			return fmt.Errorf("failed to save exchange rates: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// exchangeRateFor returns the rate to show prices in currency, or nil if
+// currency is empty or the currency amounts are stored in
+func exchangeRateFor(db *gorm.DB, currency string) (*models.ExchangeRate, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" || currency == money.DefaultCurrency() {
+		return nil, nil
+	}
+
+	var rates []models.ExchangeRate
+	if err := db.Where("currency = ?", currency).Limit(1).Find(&rates).Error; err != nil {
+		return nil, fmt.Errorf("failed to load exchange rate: %w", err)
+	}
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrExchangeRateNotFound, currency)
+	}
+	return &rates[0], nil
+}
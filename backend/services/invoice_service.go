@@ -0,0 +1,220 @@
+package services
+
+import (
+	"car-rental-backend/database"
+	"car-rental-backend/invoicing"
+	"car-rental-backend/models"
+	"car-rental-backend/money"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvoiceNotIssued is returned when a booking has not been completed and so has no invoice
+var ErrInvoiceNotIssued = errors.New("invoice is issued when the booking is completed")
+
+// InvoiceService handles all invoice-related database operations
+type InvoiceService struct {
+	db *gorm.DB
+}
+
+// NewInvoiceService creates a new invoice service
+func NewInvoiceService() *InvoiceService {
+	return &InvoiceService{
+		db: database.GetDB(),
+	}
+}
+
+// GetInvoice retrieves the invoice of a booking. Bookings completed before
+// invoicing existed are invoiced on first request.
+func (s *InvoiceService) GetInvoice(bookingID uuid.UUID) (*models.Invoice, error) {
+	var invoice models.Invoice
+	err := s.db.First(&invoice, "booking_id = ?", bookingID).Error
+	if err == nil {
+		return &invoice, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var issued *models.Invoice
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var booking models.Booking
+		if err := tx.First(&booking, "id = ?", bookingID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrBookingNotFound
+			}
+			return fmt.Errorf("failed to load booking: %w", err)
+		}
+		if booking.Status != models.BookingStatusCompleted {
+			return ErrInvoiceNotIssued
+		}
+
+		var err error
+		issued, err = issueInvoice(tx, &booking, booking.UpdatedAt)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return issued, nil
+}
+
+// issueInvoice snapshots the booking's price breakdown, parties and deposit into
+// a new invoice with the next sequential number. An existing invoice is returned as is.
+func issueInvoice(tx *gorm.DB, booking *models.Booking, issuedAt time.Time) (*models.Invoice, error) {
+	var existing []models.Invoice
+	if err := tx.Where("booking_id = ?", booking.ID).Limit(1).Find(&existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to load invoice: %w", err)
+	}
+	if len(existing) > 0 {
+		return &existing[0], nil
+	}
+
+	var customer models.User
+	if err := tx.First(&customer, "id = ?", booking.UserID).Error; err != nil {
+		return nil, fmt.Errorf("failed to load customer: %w", err)
+	}
+
+	var car models.Car
+	if err := tx.Unscoped().Preload("Owner", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).First(&car, "id = ?", booking.CarID).Error; err != nil {
+		return nil, fmt.Errorf("failed to load car: %w", err)
+	}
+
+	var charges []models.BookingCharge
+	if err := tx.Where("booking_id = ?", booking.ID).Order("created_at ASC").Find(&charges).Error; err != nil {
+		return nil, fmt.Errorf("failed to load booking charges: %w", err)
+	}
+
+	var deposits []models.Deposit
+	if err := tx.Preload("Entries", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).Where("booking_id = ?", booking.ID).Limit(1).Find(&deposits).Error; err != nil {
+		return nil, fmt.Errorf("failed to load deposit: %w", err)
+	}
+
+	var sequence int64
+	if err := tx.Raw("SELECT nextval('invoice_number_seq')").Scan(&sequence).Error; err != nil {
+		return nil, fmt.Errorf("failed to allocate invoice number: %w", err)
+	}
+
+	invoice := models.Invoice{
+		BookingID:   booking.ID,
+		Number:      invoicing.FormatNumber(sequence),
+		IssuedAt:    issuedAt,
+		Customer:    models.InvoiceParty{Name: customer.Name, Contact: customer.Email},
+		Owner:       models.InvoiceParty{Name: car.Owner.Name, Contact: car.Owner.ContactInfo},
+		Car:         models.InvoiceCar{Make: car.Make, Model: car.Model, Year: car.Year, VehicleNumber: car.VehicleNumber},
+		RentalStart: booking.StartTime,
+		RentalEnd:   booking.EndTime,
+		Discount:    booking.DiscountAmount,
+		Taxes:       booking.Tax.Amount,
+
+		TaxJurisdiction: booking.Tax.Jurisdiction,
+		TaxRate:         booking.Tax.Rate,
+		TaxInclusive:    booking.Tax.Inclusive,
+	}
+
+	// The booking total already includes its charges and exclusive taxes and is
+	// net of the discount
+	var err error
+	if invoice.Subtotal, err = booking.TotalPrice.Add(booking.DiscountAmount); err != nil {
+		return nil, err
+	}
+	for _, charge := range charges {
+		if invoice.Subtotal, err = invoice.Subtotal.Sub(charge.Amount); err != nil {
+			return nil, err
+		}
+	}
+	if booking.Tax.Exclusive() {
+		if invoice.Subtotal, err = invoice.Subtotal.Sub(booking.Tax.Amount); err != nil {
+			return nil, err
+		}
+	}
+
+	invoice.Lines = append(invoice.Lines, models.InvoiceLine{
+		Type:        models.InvoiceLineRental,
+		Description: fmt.Sprintf("Rental of %s %s", car.Make, car.Model),
+		Quantity:    1,
+		UnitPrice:   invoice.Subtotal,
+		Amount:      invoice.Subtotal,
+	})
+	if booking.DiscountAmount.IsPositive() {
+		invoice.Lines = append(invoice.Lines, models.InvoiceLine{
+			Type:        models.InvoiceLineDiscount,
+			Description: "Promo code " + booking.PromoCode,
+			Quantity:    1,
+			UnitPrice:   booking.DiscountAmount.Neg(),
+			Amount:      booking.DiscountAmount.Neg(),
+		})
+	}
+	invoice.Fees = money.New(0, booking.TotalPrice.Currency)
+	for _, charge := range charges {
+		if invoice.Fees, err = invoice.Fees.Add(charge.Amount); err != nil {
+			return nil, err
+		}
+		invoice.Lines = append(invoice.Lines, models.InvoiceLine{
+			Type:        models.InvoiceLineCharge,
+			Description: charge.Description,
+			Quantity:    charge.Quantity,
+			UnitPrice:   charge.UnitPrice,
+			Amount:      charge.Amount,
+		})
+	}
+
+	// Amounts kept from the deposit, e.g. for damage, are billed as fees
+	if len(deposits) > 0 {
+		deposit := deposits[0]
+		invoice.DepositHeld = deposit.Amount
+		invoice.DepositCaptured = deposit.CapturedAmount
+		invoice.DepositRefunded = deposit.RefundedAmount
+
+		for _, entry := range deposit.Entries {
+			if entry.Type != models.DepositEntryCapture {
+				continue
+			}
+			if invoice.Fees, err = invoice.Fees.Add(entry.Amount); err != nil {
+				return nil, err
+			}
+			invoice.Lines = append(invoice.Lines, models.InvoiceLine{
+				Type:        models.InvoiceLineDeposit,
+				Description: "Deducted from deposit: " + entry.Reason,
+				Quantity:    1,
+				UnitPrice:   entry.Amount,
+				Amount:      entry.Amount,
+			})
+		}
+	}
+
+	if invoice.Total, err = invoice.Subtotal.Sub(invoice.Discount); err != nil {
+		return nil, err
+	}
+	if invoice.Total, err = invoice.Total.Add(invoice.Fees); err != nil {
+		return nil, err
+	}
+
+	// Inclusive tax is already part of the amounts above
+	if booking.Tax.Exclusive() && invoice.Taxes.IsPositive() {
+		if invoice.Total, err = invoice.Total.Add(invoice.Taxes); err != nil {
+			return nil, err
+		}
+		invoice.Lines = append(invoice.Lines, models.InvoiceLine{
+			Type:        models.InvoiceLineTax,
+			Description: fmt.Sprintf("%s %g%%", booking.Tax.Name, booking.Tax.Rate),
+			Quantity:    1,
+			UnitPrice:   invoice.Taxes,
+			Amount:      invoice.Taxes,
+		})
+	}
+
+	if err := tx.Create(&invoice).Error; err != nil {
+		return nil, fmt.Errorf("failed to create invoice: %w", err)
+	}
+	return &invoice, nil
+}
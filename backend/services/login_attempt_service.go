@@ -0,0 +1,141 @@
+package services
+
+import (
+	"car-rental-backend/database"
+	"car-rental-backend/lockout"
+	"car-rental-backend/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LoginAttemptService throttles password guessing with the configured
+// lockout.Guard and audits the lockouts it causes
+type LoginAttemptService struct {
+	db    *gorm.DB
+	audit *AuditService
+}
+
+// NewLoginAttemptService creates a new login attempt service
+func NewLoginAttemptService() *LoginAttemptService {
+	return &LoginAttemptService{
+		db:    database.GetDB(),
+		audit: NewAuditService(),
+	}
+}
+
+// Begin reserves a login attempt for the email from the IP address if one may
+// be made now. The attempt counts as a failure until it is released, so
+// settle it with RecordFailure or Release. Without a configured guard every
+// attempt is allowed.
+func (s *LoginAttemptService) Begin(email, ip string) (*lockout.Attempt, error) {
+	guard := lockout.GetGuard()
+	if guard == nil {
+		return &lockout.Attempt{Decision: lockout.Decision{Allowed: true}}, nil
+	}
+	return guard.Begin(email, ip)
+}
+
+// Release settles an attempt that did not fail
+func (s *LoginAttemptService) Release(attempt *lockout.Attempt) error {
+	guard := lockout.GetGuard()
+	if guard == nil {
+		return nil
+	}
+	return guard.Release(attempt)
+}
+
+// RecordFailure settles an attempt as a failed login and audits any lockout it causes
+func (s *LoginAttemptService) RecordFailure(attempt *lockout.Attempt, email, ip string) error {
+	guard := lockout.GetGuard()
+	if guard == nil {
+		return nil
+	}
+
+	failure, err := guard.Fail(attempt)
+	if err != nil {
+		return err
+	}
+
+	if failure.EmailLocked {
+		entry := &models.AuditLog{
+			Action:    models.AuditActionAccountLocked,
+			Email:     email,
+			IPAddress: ip,
+			Details:   fmt.Sprintf("locked until %s after %d failed login attempts", failure.LockedUntil.Format(time.RFC3339), failure.Failures),
+		}
+		// Failures for unknown emails are counted too, so the response does not
+		// give away which emails are registered
+		var user models.User
+		if err := s.db.Where("email = ?", email).First(&user).Error; err == nil {
+			entry.UserID = &user.ID
+		}
+		if err := s.audit.Record(entry); err != nil {
+			return err
+		}
+	}
+
+	if failure.IPLocked {
+		if err := s.audit.Record(&models.AuditLog{
+			Action:    models.AuditActionIPLocked,
+			Email:     email,
+			IPAddress: ip,
+			Details:   fmt.Sprintf("locked until %s after too many failed login attempts", failure.LockedUntil.Format(time.RFC3339)),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecordSuccess forgets the email's failed logins
+func (s *LoginAttemptService) RecordSuccess(email string) error {
+	guard := lockout.GetGuard()
+	if guard == nil {
+		return nil
+	}
+	return guard.Succeed(email)
+}
+
+// UnlockUser lifts a login lockout on the user's email and audits the admin
+// who did it. It reports whether the user was locked.
+func (s *LoginAttemptService) UnlockUser(userID, actorID uuid.UUID, ip string) (bool, error) {
+	var user models.User
+	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, ErrUserNotFound
+		}
+		return false, err
+	}
+
+	guard := lockout.GetGuard()
+	if guard == nil {
+		return false, lockout.ErrGuardNotConfigured
+	}
+
+	wasLocked, err := guard.Unlock(user.Email)
+	if err != nil {
+		return false, err
+	}
+
+	details := "failed login attempts cleared"
+	if wasLocked {
+		details = "lockout lifted"
+	}
+	if err := s.audit.Record(&models.AuditLog{
+		Action:    models.AuditActionAccountUnlocked,
+		UserID:    &user.ID,
+		ActorID:   &actorID,
+		Email:     user.Email,
+		IPAddress: ip,
+		Details:   details,
+	}); err != nil {
+		return false, err
+	}
+
+	return wasLocked, nil
+}
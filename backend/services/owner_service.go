@@ -0,0 +1,136 @@
+package services
+
+import (
+	"car-rental-backend/database"
+	"car-rental-backend/models"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUserNotFound is returned when the requested user does not exist
+	ErrUserNotFound = errors.New("user not found")
+	// ErrNotAnOwner is returned when a user is not linked to an owner
+	ErrNotAnOwner = errors.New("user is not linked to an owner")
+)
+
+// OwnerService handles the owner self-service portal: linking owner logins and
+// scoping cars and bookings to the owner
+type OwnerService struct {
+	db *gorm.DB
+}
+
+// NewOwnerService creates a new owner service
+func NewOwnerService() *OwnerService {
+	return &OwnerService{
+		db: database.GetDB(),
+	}
+}
+
+// LinkUser gives a user the owner role and links them to the owner, so they
+// can manage the owner's cars through the owner portal
+func (s *OwnerService) LinkUser(ownerID, userID uuid.UUID) (*models.User, error) {
+	var user models.User
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var owner models.Owner
+		if err := tx.First(&owner, "id = ?", ownerID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOwnerNotFound
+			}
+			return err
+		}
+
+		if err := tx.First(&user, "id = ?", userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+
+		user.Role = models.UserRoleOwner
+		user.OwnerID = &owner.ID
+		return tx.Model(&user).Select("role", "owner_id").Updates(&user).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// GetOwnerIDForUser returns the owner an owner-role user acts for
+func (s *OwnerService) GetOwnerIDForUser(userID uuid.UUID) (uuid.UUID, error) {
+	var user models.User
+	if err := s.db.Select("id", "role", "owner_id").First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return uuid.Nil, ErrUserNotFound
+		}
+		return uuid.Nil, err
+	}
+
+	if user.Role != models.UserRoleOwner || user.OwnerID == nil {
+		return uuid.Nil, ErrNotAnOwner
+	}
+	return *user.OwnerID, nil
+}
+
+// OwnsCar reports whether the car belongs to the owner
+func (s *OwnerService) OwnsCar(ownerID, carID uuid.UUID) (bool, error) {
+	var count int64
+	if err := s.db.Model(&models.Car{}).Where("id = ? AND owner_id = ?", carID, ownerID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetOwnerCars retrieves the owner's cars with their related data
+func (s *OwnerService) GetOwnerCars(ownerID uuid.UUID) ([]models.Car, error) {
+	var cars []models.Car
+
+	err := s.db.Preload("Owner").
+		Preload("RentalInfo").
+		Preload("Media").
+		Preload("CurrentStatus").
+		Where("owner_id = ?", ownerID).
+		Order("created_at DESC").
+		Find(&cars).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cars, nil
+}
+
+// GetOwnerBookings retrieves a page of the bookings of the owner's cars,
+// newest first. Filters: status and car_id.
+func (s *OwnerService) GetOwnerBookings(ownerID uuid.UUID, page, pageSize int, filters map[string]string) ([]models.Booking, int64, error) {
+	query := s.db.Model(&models.Booking{}).
+		Joins("JOIN cars ON cars.id = bookings.car_id").
+		Where("cars.owner_id = ?", ownerID)
+
+	if status := filters["status"]; status != "" {
+		query = query.Where("bookings.status = ?", status)
+	}
+	if carID := filters["car_id"]; carID != "" {
+		query = query.Where("bookings.car_id = ?", carID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var bookings []models.Booking
+	if err := query.Preload("Car").
+		Order("bookings.start_time DESC").
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Find(&bookings).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return bookings, total, nil
+}
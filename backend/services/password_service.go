@@ -0,0 +1,149 @@
+package services
+
+import (
+	"car-rental-backend/database"
+	"car-rental-backend/models"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrIncorrectPassword is returned when the current password given to change it is wrong
+	ErrIncorrectPassword = errors.New("current password is incorrect")
+	// ErrPasswordReused is returned when a new password matches one of the user's recent passwords
+	ErrPasswordReused = errors.New("password was used recently")
+)
+
+// passwordHistorySize is how many recent passwords cannot be chosen again
+var passwordHistorySize = 5
+
+// SetPasswordHistorySize configures how many recent passwords cannot be chosen
+// again. Zero allows any password to be reused.
+func SetPasswordHistorySize(size int) {
+	if size >= 0 {
+		passwordHistorySize = size
+	}
+}
+
+// PasswordService sets user passwords, keeping the history that stops recent
+// passwords from being reused
+type PasswordService struct {
+	db *gorm.DB
+}
+
+// NewPasswordService creates a new password service
+func NewPasswordService() *PasswordService {
+	return &PasswordService{
+		db: database.GetDB(),
+	}
+}
+
+// CreateUser creates the user with the password, starting its password history
+func (s *PasswordService) CreateUser(user *models.User, password string) error {
+	if err := user.SetPassword(password); err != nil {
+		return fmt.Errorf("failed to process password: %w", err)
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return recordPassword(tx, user.ID, user.PasswordHash)
+	})
+}
+
+// ChangePassword replaces the user's password after checking the current one.
+// Every other session of the user is logged out; the one in keepFamilyID stays.
+func (s *PasswordService) ChangePassword(userID uuid.UUID, current, password string, keepFamilyID uuid.UUID) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+
+		if !user.CheckPassword(current) {
+			return ErrIncorrectPassword
+		}
+
+		if err := setPassword(tx, &user, password); err != nil {
+			return err
+		}
+
+		return revokeUserSessions(tx, user.ID, models.RevocationReasonPasswordChange, keepFamilyID)
+	})
+}
+
+// setPassword gives the user a new password, rejecting their recent ones
+func setPassword(tx *gorm.DB, user *models.User, password string) error {
+	reused, err := isRecentPassword(tx, user, password)
+	if err != nil {
+		return err
+	}
+	if reused {
+		return ErrPasswordReused
+	}
+
+	if err := user.SetPassword(password); err != nil {
+		return fmt.Errorf("failed to process password: %w", err)
+	}
+	if err := tx.Model(user).Update("password_hash", user.PasswordHash).Error; err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	return recordPassword(tx, user.ID, user.PasswordHash)
+}
+
+// isRecentPassword reports whether the password is the user's current one or
+// one of the passwordHistorySize before it
+func isRecentPassword(tx *gorm.DB, user *models.User, password string) (bool, error) {
+	if passwordHistorySize == 0 {
+		return false, nil
+	}
+	if user.CheckPassword(password) {
+		return true, nil
+	}
+
+	var history []models.PasswordHistory
+	if err := tx.Where("user_id = ?", user.ID).
+		Order("created_at DESC").
+		Limit(passwordHistorySize).
+		Find(&history).Error; err != nil {
+		return false, fmt.Errorf("failed to load password history: %w", err)
+	}
+
+	for _, entry := range history {
+		previous := models.User{PasswordHash: entry.PasswordHash}
+		if previous.CheckPassword(password) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// recordPassword adds a password hash to the user's history and forgets the
+// hashes that are no longer checked
+func recordPassword(tx *gorm.DB, userID uuid.UUID, hash string) error {
+	if passwordHistorySize == 0 {
+		return nil
+	}
+
+	if err := tx.Create(&models.PasswordHistory{UserID: userID, PasswordHash: hash}).Error; err != nil {
+		return fmt.Errorf("failed to record password history: %w", err)
+	}
+
+	keep := tx.Model(&models.PasswordHistory{}).
+		Select("id").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(passwordHistorySize)
+	return tx.Unscoped().
+		Where("user_id = ? AND id NOT IN (?)", userID, keep).
+		Delete(&models.PasswordHistory{}).Error
+}
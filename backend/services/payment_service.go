@@ -0,0 +1,336 @@
+package services
+
+import (
+	"car-rental-backend/database"
+	"car-rental-backend/models"
+	"car-rental-backend/money"
+	"car-rental-backend/payments"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrPaymentNotFound is returned when the requested payment does not exist
+	ErrPaymentNotFound = errors.New("payment not found")
+	// ErrBookingNotPayable is returned when a booking is not in a status that can be paid for
+	ErrBookingNotPayable = errors.New("booking cannot be paid for in its current status")
+	// ErrNothingToPay is returned when a booking has no outstanding balance
+	ErrNothingToPay = errors.New("booking has no outstanding balance")
+	// ErrPaymentNotCapturable is returned when a payment is not authorized
+	ErrPaymentNotCapturable = errors.New("payment is not authorized")
+	// ErrPaymentNotRefundable is returned when a payment has nothing captured to refund
+	ErrPaymentNotRefundable = errors.New("payment has nothing to refund")
+)
+
+// PaymentService handles all payment-related database operations
+type PaymentService struct {
+	db      *gorm.DB
+	gateway payments.PaymentGateway
+}
+
+// NewPaymentService creates a new payment service using the configured gateway
+func NewPaymentService() *PaymentService {
+	return &PaymentService{
+		db:      database.GetDB(),
+		gateway: payments.GetGateway(),
+	}
+}
+
+// GetBookingPayments retrieves the payments made for a booking, oldest first
+func (s *PaymentService) GetBookingPayments(bookingID uuid.UUID) ([]models.Payment, error) {
+	var bookingPayments []models.Payment
+	if err := s.db.Where("booking_id = ?", bookingID).Order("created_at ASC").Find(&bookingPayments).Error; err != nil {
+		return nil, err
+	}
+	return bookingPayments, nil
+}
+
+// AuthorizeBookingPayment authorizes the booking's outstanding balance on the
+// customer's payment method. Declined payments are stored as FAILED. An
+// authorized payment confirms a PENDING booking.
+//
+// The booking row stays locked while the gateway is called so that two
+// concurrent requests cannot both authorize the same balance.
+func (s *PaymentService) AuthorizeBookingPayment(bookingID, userID uuid.UUID, paymentMethod string) (*models.Payment, error) {
+	if s.gateway == nil {
+		return nil, payments.ErrGatewayNotConfigured
+	}
+
+	var payment models.Payment
+	var declined error
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var booking models.Booking
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&booking, "id = ?", bookingID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrBookingNotFound
+			}
+			return fmt.Errorf("failed to load booking: %w", err)
+		}
+
+		if booking.Status == models.BookingStatusCancelled || booking.Status == models.BookingStatusNoShow {
+			return ErrBookingNotPayable
+		}
+
+		outstanding, err := outstandingBalance(tx, &booking)
+		if err != nil {
+			return err
+		}
+		if !outstanding.IsPositive() {
+			return ErrNothingToPay
+		}
+
+		payment = models.Payment{
+			BookingID: booking.ID,
+			UserID:    userID,
+			Provider:  s.gateway.Name(),
+			Amount:    outstanding,
+		}
+		payment.ID = uuid.New()
+
+		result, err := s.gateway.Authorize(payments.AuthorizeRequest{
+			Amount:         outstanding,
+			PaymentMethod:  paymentMethod,
+			Description:    "Booking " + booking.ID.String(),
+			IdempotencyKey: payment.ID.String(),
+		})
+		if err != nil {
+			if !errors.Is(err, payments.ErrPaymentDeclined) {
+				return fmt.Errorf("failed to authorize payment: %w", err)
+			}
+			// Keep the declined attempt so it shows up in the booking's payments
+			declined = err
+			payment.Status = models.PaymentStatusFailed
+			payment.FailureReason = err.Error()
+		} else {
+			payment.Reference = result.Reference
+			payment.Status = models.PaymentStatusAuthorized
+		}
+
+		if err := tx.Create(&payment).Error; err != nil {
+			return fmt.Errorf("failed to record payment: %w", err)
+		}
+
+		if payment.Status == models.PaymentStatusAuthorized && booking.Status == models.BookingStatusPending {
+			booking.Status = models.BookingStatusConfirmed
+			if err := tx.Model(&booking).Update("status", booking.Status).Error; err != nil {
+				return fmt.Errorf("failed to confirm booking: %w", err)
+			}
+			return recordStatusChange(tx, &booking, models.BookingStatusPending, userID, "Payment authorized")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if declined != nil {
+		return &payment, declined
+	}
+
+	return &payment, nil
+}
+
+// CapturePayment collects an authorized payment. An amount of zero captures the full authorization.
+func (s *PaymentService) CapturePayment(paymentID uuid.UUID, amount money.Money) (*models.Payment, error) {
+	if s.gateway == nil {
+		return nil, payments.ErrGatewayNotConfigured
+	}
+
+	var payment models.Payment
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockPayment(tx, paymentID, &payment); err != nil {
+			return err
+		}
+
+		if payment.Status != models.PaymentStatusAuthorized {
+			return ErrPaymentNotCapturable
+		}
+		if !amount.IsPositive() {
+			amount = payment.Amount
+		}
+		if !amount.SameCurrency(payment.Amount) {
+			return fmt.Errorf("%w: payment is in %s", money.ErrCurrencyMismatch, payment.Amount.Currency)
+		}
+
+		if _, err := s.gateway.Capture(payment.Reference, amount); err != nil {
+			return err
+		}
+
+		payment.CapturedAmount = amount
+		payment.Status = models.PaymentStatusCaptured
+		return savePayment(tx, &payment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
+// RefundPayment returns part or all of a captured payment. An amount of zero refunds everything not yet refunded.
+func (s *PaymentService) RefundPayment(paymentID uuid.UUID, amount money.Money) (*models.Payment, error) {
+	if s.gateway == nil {
+		return nil, payments.ErrGatewayNotConfigured
+	}
+
+	var payment models.Payment
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockPayment(tx, paymentID, &payment); err != nil {
+			return err
+		}
+
+		refundable, err := payment.CapturedAmount.Sub(payment.RefundedAmount)
+		if err != nil {
+			return err
+		}
+		if !refundable.IsPositive() {
+			return ErrPaymentNotRefundable
+		}
+		if !amount.IsPositive() {
+			amount = refundable
+		}
+		if !amount.SameCurrency(payment.Amount) {
+			return fmt.Errorf("%w: payment is in %s", money.ErrCurrencyMismatch, payment.Amount.Currency)
+		}
+
+		if _, err := s.gateway.Refund(payment.Reference, amount); err != nil {
+			return err
+		}
+
+		if payment.RefundedAmount, err = payment.RefundedAmount.Add(amount); err != nil {
+			return err
+		}
+		if err := setRefundStatus(&payment); err != nil {
+			return err
+		}
+		return savePayment(tx, &payment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
+// HandleWebhook verifies a provider webhook and applies it to the matching payment.
+// Event amounts are cumulative, and events already seen are ignored, so
+// redelivered webhooks are safe to apply again.
+func (s *PaymentService) HandleWebhook(payload []byte, signature string) error {
+	if s.gateway == nil {
+		return payments.ErrGatewayNotConfigured
+	}
+
+	event, err := s.gateway.VerifyWebhook(payload, signature)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		record := models.PaymentWebhookEvent{
+			Provider:  s.gateway.Name(),
+			EventID:   event.ID,
+			Type:      event.Type,
+			Reference: event.Reference,
+			Payload:   string(payload),
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if result.Error != nil {
+			return fmt.Errorf("failed to record webhook event: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			// Already processed
+			return nil
+		}
+
+		var payment models.Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("provider = ? AND reference = ?", s.gateway.Name(), event.Reference).
+			First(&payment).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPaymentNotFound
+			}
+			return fmt.Errorf("failed to load payment: %w", err)
+		}
+
+		// Amounts in another currency cannot be compared with the payment's,
+		// so the event is rejected rather than stored
+		amount := event.Money()
+		if (event.Type == payments.EventCaptured || event.Type == payments.EventRefunded) &&
+			!amount.SameCurrency(payment.Amount) {
+			return fmt.Errorf("%w: event %s is in %s, payment is in %s",
+				money.ErrCurrencyMismatch, event.ID, amount.Currency, payment.Amount.Currency)
+		}
+
+		switch event.Type {
+		case payments.EventCaptured:
+			payment.CapturedAmount = amount
+			if payment.Status == models.PaymentStatusAuthorized {
+				payment.Status = models.PaymentStatusCaptured
+			}
+		case payments.EventRefunded:
+			payment.RefundedAmount = amount
+			if err := setRefundStatus(&payment); err != nil {
+				return err
+			}
+		case payments.EventFailed:
+			payment.Status = models.PaymentStatusFailed
+			payment.FailureReason = "Reported failed by " + s.gateway.Name()
+		default:
+			return nil
+		}
+
+		return savePayment(tx, &payment)
+	})
+}
+
+// outstandingBalance returns how much of the booking's total is not yet covered by payments
+func outstandingBalance(tx *gorm.DB, booking *models.Booking) (money.Money, error) {
+	var paid money.Money
+	if err := tx.Model(&models.Payment{}).
+		Where("booking_id = ? AND status <> ?", booking.ID, models.PaymentStatusFailed).
+		Select("COALESCE(SUM(amount - refunded_amount), 0)").
+		Scan(&paid).Error; err != nil {
+		return money.Money{}, fmt.Errorf("failed to sum booking payments: %w", err)
+	}
+	return booking.TotalPrice.Sub(paid)
+}
+
+// lockPayment loads a payment and takes a row lock on it
+func lockPayment(tx *gorm.DB, paymentID uuid.UUID, payment *models.Payment) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(payment, "id = ?", paymentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPaymentNotFound
+		}
+		return fmt.Errorf("failed to load payment: %w", err)
+	}
+	return nil
+}
+
+// setRefundStatus marks a captured payment as partially or fully refunded
+func setRefundStatus(payment *models.Payment) error {
+	if !payment.RefundedAmount.IsPositive() {
+		return nil
+	}
+	partial, err := payment.RefundedAmount.LessThan(payment.CapturedAmount)
+	if err != nil {
+		return err
+	}
+	payment.Status = models.PaymentStatusPartiallyRefunded
+	if !partial {
+		payment.Status = models.PaymentStatusRefunded
+	}
+	return nil
+}
+
+func savePayment(tx *gorm.DB, payment *models.Payment) error {
+	if err := tx.Save(payment).Error; err != nil {
+		return fmt.Errorf("failed to update payment: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"car-rental-backend/database"
+	"car-rental-backend/models"
+	"car-rental-backend/money"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrPayoutNotFound is returned when the requested payout does not exist
+	ErrPayoutNotFound = errors.New("payout not found")
+	// ErrPayoutAlreadyPaid is returned when a payout has already been marked as paid
+	ErrPayoutAlreadyPaid = errors.New("payout has already been paid")
+	// ErrOwnerNotFound is returned when the requested owner does not exist
+	ErrOwnerNotFound = errors.New("owner not found")
+)
+
+// PayoutService handles owner revenue-share settlement and payouts
+type PayoutService struct {
+	db *gorm.DB
+}
+
+// NewPayoutService creates a new payout service
+func NewPayoutService() *PayoutService {
+	return &PayoutService{
+		db: database.GetDB(),
+	}
+}
+
+// settleableBooking is a completed, invoiced booking not yet in a payout
+type settleableBooking struct {
+	BookingID     uuid.UUID
+	CarID         uuid.UUID
+	OwnerID       uuid.UUID
+	InvoiceNumber string
+	SharePercent  float64
+	Revenue       money.Money
+}
+
+// settleableBookings selects the completed, invoiced bookings not yet in a payout
+func settleableBookings(db *gorm.DB) *gorm.DB {
+	return db.Table("invoices").
+		Select("invoices.booking_id, cars.id AS car_id, cars.owner_id, invoices.number AS invoice_number, "+
+			"COALESCE(cars.revenue_share_percent, owners.revenue_share_percent) AS share_percent, "+
+			"invoices.total - invoices.taxes AS revenue").
+		Joins("JOIN bookings ON bookings.id = invoices.booking_id").
+		Joins("JOIN cars ON cars.id = bookings.car_id").
+		Joins("JOIN owners ON owners.id = cars.owner_id").
+		Where("invoices.deleted_at IS NULL AND bookings.status = ?", models.BookingStatusCompleted).
+		Where("NOT EXISTS (SELECT 1 FROM payout_lines WHERE payout_lines.booking_id = invoices.booking_id AND payout_lines.deleted_at IS NULL)")
+}
+
+// SettlePayouts creates a pending payout for every owner with completed
+// bookings invoiced in [from, to) that are not yet in a payout. Each booking
+// pays the owner the car's revenue share, or the owner's if the car has none,
+// of its invoiced amount excluding tax. Running it again for the same period
+// only picks up bookings invoiced since.
+func (s *PayoutService) SettlePayouts(from, to time.Time) ([]models.Payout, error) {
+	var payouts []models.Payout
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var bookings []settleableBooking
+		if err := settleableBookings(tx).
+			Where("invoices.issued_at >= ? AND invoices.issued_at < ?", from, to).
+			Order("cars.owner_id, invoices.issued_at").
+			Scan(&bookings).Error; err != nil {
+			return fmt.Errorf("failed to load bookings to settle: %w", err)
+		}
+
+		byOwner := make(map[uuid.UUID]*models.Payout)
+		var owners []uuid.UUID
+		for _, booking := range bookings {
+			payout, ok := byOwner[booking.OwnerID]
+			if !ok {
+				payout = &models.Payout{
+					OwnerID:     booking.OwnerID,
+					PeriodStart: from,
+					PeriodEnd:   to,
+					Revenue:     money.Zero(),
+					Amount:      money.Zero(),
+					Status:      models.PayoutStatusPending,
+				}
+				byOwner[booking.OwnerID] = payout
+				owners = append(owners, booking.OwnerID)
+			}
+
+			amount := booking.Revenue.Percent(booking.SharePercent)
+			payout.Lines = append(payout.Lines, models.PayoutLine{
+				BookingID:     booking.BookingID,
+				CarID:         booking.CarID,
+				InvoiceNumber: booking.InvoiceNumber,
+				SharePercent:  booking.SharePercent,
+				Revenue:       booking.Revenue,
+				Amount:        amount,
+			})
+			payout.Bookings++
+			var err error
+			if payout.Revenue, err = payout.Revenue.Add(booking.Revenue); err != nil {
+				return err
+			}
+			if payout.Amount, err = payout.Amount.Add(amount); err != nil {
+				return err
+			}
+		}
+
+		for _, ownerID := range owners {
+			payout := byOwner[ownerID]
+			if err := tx.Create(payout).Error; err != nil {
+				return fmt.Errorf("failed to create payout: %w", err)
+			}
+			payouts = append(payouts, *payout)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return payouts, nil
+}
+
+// GetOwnerPayouts retrieves an owner's payouts with their lines, newest first
+func (s *PayoutService) GetOwnerPayouts(ownerID uuid.UUID) ([]models.Payout, error) {
+	var count int64
+	if err := s.db.Model(&models.Owner{}).Where("id = ?", ownerID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrOwnerNotFound
+	}
+
+	var payouts []models.Payout
+	if err := s.db.Preload("Lines").
+		Where("owner_id = ?", ownerID).
+		Order("period_start DESC, created_at DESC").
+		Find(&payouts).Error; err != nil {
+		return nil, err
+	}
+	return payouts, nil
+}
+
+// GetOwnerEarnings summarises an owner's paid and pending payouts and their
+// share of completed bookings that have not been settled yet
+func (s *PayoutService) GetOwnerEarnings(ownerID uuid.UUID) (*models.OwnerEarnings, error) {
+	payouts, err := s.GetOwnerPayouts(ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	earnings := &models.OwnerEarnings{
+		Paid:      money.Zero(),
+		Pending:   money.Zero(),
+		Unsettled: money.Zero(),
+		Payouts:   payouts,
+	}
+	for _, payout := range payouts {
+		if payout.Status == models.PayoutStatusPaid {
+			earnings.Paid, err = earnings.Paid.Add(payout.Amount)
+		} else {
+			earnings.Pending, err = earnings.Pending.Add(payout.Amount)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var bookings []settleableBooking
+	if err := settleableBookings(s.db).Where("cars.owner_id = ?", ownerID).Scan(&bookings).Error; err != nil {
+		return nil, fmt.Errorf("failed to load unsettled bookings: %w", err)
+	}
+	for _, booking := range bookings {
+		if earnings.Unsettled, err = earnings.Unsettled.Add(booking.Revenue.Percent(booking.SharePercent)); err != nil {
+			return nil, err
+		}
+	}
+
+	return earnings, nil
+}
+
+// MarkPayoutPaid records that a pending payout has been paid to the owner
+func (s *PayoutService) MarkPayoutPaid(payoutID, actorID uuid.UUID, reference string) (*models.Payout, error) {
+	var payout models.Payout
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payout, "id = ?", payoutID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPayoutNotFound
+			}
+			return fmt.Errorf("failed to load payout: %w", err)
+		}
+
+		if payout.Status == models.PayoutStatusPaid {
+			return ErrPayoutAlreadyPaid
+		}
+
+		now := time.Now()
+		payout.Status = models.PayoutStatusPaid
+		payout.PaidAt = &now
+		payout.PaidBy = &actorID
+		payout.Reference = reference
+		if err := tx.Omit(clause.Associations).Save(&payout).Error; err != nil {
+			return fmt.Errorf("failed to update payout: %w", err)
+		}
+
+		return tx.Where("payout_id = ?", payout.ID).Find(&payout.Lines).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &payout, nil
+}
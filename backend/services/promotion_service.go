@@ -0,0 +1,173 @@
+package services
+
+import (
+	"car-rental-backend/database"
+	"car-rental-backend/models"
+	"car-rental-backend/pricing"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrPromotionNotFound is returned when no promotion matches the code or ID
+	ErrPromotionNotFound = errors.New("promotion not found")
+	// ErrPromotionNotApplicable is returned when a promotion cannot be used for a booking
+	ErrPromotionNotApplicable = errors.New("promotion cannot be applied")
+)
+
+// PromotionService handles all promotion-related database operations
+type PromotionService struct {
+	db *gorm.DB
+}
+
+// NewPromotionService creates a new promotion service
+func NewPromotionService() *PromotionService {
+	return &PromotionService{
+		db: database.GetDB(),
+	}
+}
+
+// GetPromotions retrieves all promotions, newest first
+func (s *PromotionService) GetPromotions() ([]models.Promotion, error) {
+	var promotions []models.Promotion
+	if err := s.db.Order("created_at DESC").Find(&promotions).Error; err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
+
+// GetPromotionByID retrieves a promotion by ID
+func (s *PromotionService) GetPromotionByID(id uuid.UUID) (*models.Promotion, error) {
+	var promotion models.Promotion
+	if err := s.db.First(&promotion, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPromotionNotFound
+		}
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// CreatePromotion creates a new promotion, normalising its code to upper case
+func (s *PromotionService) CreatePromotion(promotion *models.Promotion) error {
+	promotion.Code = NormalizePromoCode(promotion.Code)
+	return s.db.Create(promotion).Error
+}
+
+// UpdatePromotion saves changes to an existing promotion
+func (s *PromotionService) UpdatePromotion(promotion *models.Promotion) error {
+	promotion.Code = NormalizePromoCode(promotion.Code)
+	return s.db.Omit("redemption_count").Save(promotion).Error
+}
+
+// DeletePromotion deletes a promotion
+func (s *PromotionService) DeletePromotion(promotion *models.Promotion) error {
+	return s.db.Delete(promotion).Error
+}
+
+// NormalizePromoCode returns the canonical form of a promo code
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// findPromotionByCode loads a promotion by code, optionally locking it for redemption
+func findPromotionByCode(db *gorm.DB, code string, lock bool) (*models.Promotion, error) {
+	query := db
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var promotion models.Promotion
+	if err := query.First(&promotion, "code = ?", NormalizePromoCode(code)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPromotionNotFound
+		}
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// applyPromotion checks that the promotion may be used by the user for this car
+// and window, then deducts its discount from the quote
+func applyPromotion(db *gorm.DB, promotion *models.Promotion, userID uuid.UUID, car *models.Car, quote *pricing.Quote) error {
+	now := time.Now()
+
+	if !promotion.IsActive {
+		return fmt.Errorf("%w: promotion is not active", ErrPromotionNotApplicable)
+	}
+	if now.Before(promotion.ValidFrom) || now.After(promotion.ValidUntil) {
+		return fmt.Errorf("%w: promotion is not valid at this time", ErrPromotionNotApplicable)
+	}
+	if promotion.MaxRedemptions > 0 && promotion.RedemptionCount >= promotion.MaxRedemptions {
+		return fmt.Errorf("%w: promotion has been fully redeemed", ErrPromotionNotApplicable)
+	}
+	if !promotion.AppliesToCar(car) {
+		return fmt.Errorf("%w: promotion does not apply to this car", ErrPromotionNotApplicable)
+	}
+	if promotion.MinDurationHours > 0 && quote.BillableHours < promotion.MinDurationHours {
+		return fmt.Errorf("%w: booking must be at least %d hours", ErrPromotionNotApplicable, promotion.MinDurationHours)
+	}
+
+	if promotion.MaxRedemptionsPerUser > 0 {
+		var used int64
+		if err := db.Model(&models.PromotionRedemption{}).
+			Where("promotion_id = ? AND user_id = ?", promotion.ID, userID).
+			Count(&used).Error; err != nil {
+			return fmt.Errorf("failed to count promotion redemptions: %w", err)
+		}
+		if int(used) >= promotion.MaxRedemptionsPerUser {
+			return fmt.Errorf("%w: promotion already used the maximum number of times", ErrPromotionNotApplicable)
+		}
+	}
+
+	discount, err := promotion.DiscountFor(quote.Base)
+	if err != nil {
+		return err
+	}
+	return quote.ApplyDiscount(discount, "Promo code "+promotion.Code)
+}
+
+// redeemPromotion records the promotion as used on the booking
+func redeemPromotion(tx *gorm.DB, promotion *models.Promotion, booking *models.Booking) error {
+	redemption := models.PromotionRedemption{
+		PromotionID: promotion.ID,
+		UserID:      booking.UserID,
+		BookingID:   booking.ID,
+		Amount:      booking.DiscountAmount,
+	}
+	if err := tx.Create(&redemption).Error; err != nil {
+		return fmt.Errorf("failed to record promotion redemption: %w", err)
+	}
+
+	if err := tx.Model(promotion).UpdateColumn("redemption_count", gorm.Expr("redemption_count + 1")).Error; err != nil {
+		return fmt.Errorf("failed to update promotion redemption count: %w", err)
+	}
+	return nil
+}
+
+// releasePromotion returns a cancelled booking's redemption to the promotion
+func releasePromotion(tx *gorm.DB, booking *models.Booking) error {
+	if booking.PromotionID == nil {
+		return nil
+	}
+
+	result := tx.Where("booking_id = ?", booking.ID).Delete(&models.PromotionRedemption{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to release promotion redemption: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	if err := tx.Model(&models.Promotion{}).Where("id = ? AND redemption_count > 0", *booking.PromotionID).
+		UpdateColumn("redemption_count", gorm.Expr("redemption_count - 1")).Error; err != nil {
+		return fmt.Errorf("failed to update promotion redemption count: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"car-rental-backend/database"
+	"car-rental-backend/models"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrRateRuleNotFound is returned when the requested rate rule does not exist for the car or body type
+var ErrRateRuleNotFound = errors.New("rate rule not found")
+
+// RateRuleService handles all rate-rule-related database operations
+type RateRuleService struct {
+	db *gorm.DB
+}
+
+// NewRateRuleService creates a new rate rule service
+func NewRateRuleService() *RateRuleService {
+	return &RateRuleService{
+		db: database.GetDB(),
+	}
+}
+
+// RateRulesForCar retrieves the rules attached to the car or to its body type
+func (s *RateRuleService) RateRulesForCar(carID uuid.UUID) ([]models.RateRule, error) {
+	var car models.Car
+	if err := s.db.Select("id", "body_type").First(&car, "id = ?", carID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCarNotFound
+		}
+		return nil, err
+	}
+
+	var rules []models.RateRule
+	err := s.db.Where("car_id = ? OR (car_id IS NULL AND body_type = ?)", car.ID, car.BodyType).
+		Order("priority DESC, created_at ASC").
+		Find(&rules).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// RateRulesForBodyType retrieves the rules attached to every car of a body type
+func (s *RateRuleService) RateRulesForBodyType(bodyType models.BodyType) ([]models.RateRule, error) {
+	var rules []models.RateRule
+	err := s.db.Where("car_id IS NULL AND body_type = ?", bodyType).
+		Order("priority DESC, created_at ASC").
+		Find(&rules).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// GetCarRateRule retrieves a rate rule attached to the car itself. Body-type
+// rules also apply to other cars, so they are not returned.
+func (s *RateRuleService) GetCarRateRule(carID, ruleID uuid.UUID) (*models.RateRule, error) {
+	return s.getRateRule(s.db.Where("id = ? AND car_id = ?", ruleID, carID))
+}
+
+// GetBodyTypeRateRule retrieves a rate rule attached to a body type
+func (s *RateRuleService) GetBodyTypeRateRule(bodyType models.BodyType, ruleID uuid.UUID) (*models.RateRule, error) {
+	return s.getRateRule(s.db.Where("id = ? AND car_id IS NULL AND body_type = ?", ruleID, bodyType))
+}
+
+func (s *RateRuleService) getRateRule(query *gorm.DB) (*models.RateRule, error) {
+	var rule models.RateRule
+	if err := query.First(&rule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRateRuleNotFound
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// CreateRateRule creates a new rate rule
+func (s *RateRuleService) CreateRateRule(rule *models.RateRule) error {
+	return s.db.Create(rule).Error
+}
+
+// UpdateRateRule saves changes to an existing rate rule
+func (s *RateRuleService) UpdateRateRule(rule *models.RateRule) error {
+	return s.db.Save(rule).Error
+}
+
+// DeleteRateRule deletes a rate rule
+func (s *RateRuleService) DeleteRateRule(rule *models.RateRule) error {
+	return s.db.Delete(rule).Error
+}
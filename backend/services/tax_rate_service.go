@@ -0,0 +1,157 @@
+package services
+
+import (
+	"car-rental-backend/database"
+	"car-rental-backend/models"
+	"car-rental-backend/money"
+	"car-rental-backend/pricing"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrTaxRateNotFound is returned when the requested tax rate does not exist
+	ErrTaxRateNotFound = errors.New("tax rate not found")
+	// ErrTaxRateExists is returned when the jurisdiction already has a tax rate
+	ErrTaxRateExists = errors.New("a tax rate already exists for this jurisdiction")
+)
+
+// TaxRateService handles all tax-rate-related database operations
+type TaxRateService struct {
+	db *gorm.DB
+}
+
+// NewTaxRateService creates a new tax rate service
+func NewTaxRateService() *TaxRateService {
+	return &TaxRateService{
+		db: database.GetDB(),
+	}
+}
+
+// GetTaxRates retrieves all tax rates ordered by jurisdiction
+func (s *TaxRateService) GetTaxRates() ([]models.TaxRate, error) {
+	var rates []models.TaxRate
+	if err := s.db.Order("jurisdiction ASC").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// GetTaxRateByID retrieves a tax rate by ID
+func (s *TaxRateService) GetTaxRateByID(id uuid.UUID) (*models.TaxRate, error) {
+	var rate models.TaxRate
+	if err := s.db.First(&rate, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaxRateNotFound
+		}
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// CreateTaxRate creates a new tax rate
+func (s *TaxRateService) CreateTaxRate(rate *models.TaxRate) error {
+	return taxJurisdictionError(s.db.Create(rate).Error)
+}
+
+// UpdateTaxRate saves changes to an existing tax rate. Bookings already made
+// keep the treatment they were priced with.
+func (s *TaxRateService) UpdateTaxRate(rate *models.TaxRate) error {
+	return taxJurisdictionError(s.db.Save(rate).Error)
+}
+
+// DeleteTaxRate deletes a tax rate
+func (s *TaxRateService) DeleteTaxRate(rate *models.TaxRate) error {
+	return s.db.Delete(rate).Error
+}
+
+// GetTaxReport totals the taxes on invoices issued in [from, to) by jurisdiction
+func (s *TaxRateService) GetTaxReport(from, to time.Time) (*models.TaxReport, error) {
+	report := &models.TaxReport{From: from, To: to, Rows: []models.TaxReportRow{}}
+
+	if err := s.db.Model(&models.Invoice{}).
+		Select("tax_jurisdiction AS jurisdiction, COUNT(*) AS invoices, "+
+			"SUM(total - taxes) AS net, "+
+			"SUM(taxes) AS taxes, SUM(total) AS total").
+		Where("issued_at >= ? AND issued_at < ?", from, to).
+		Group("tax_jurisdiction").
+		Order("tax_jurisdiction ASC").
+		Scan(&report.Rows).Error; err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// taxJurisdictionError maps a violation of the unique jurisdiction index to ErrTaxRateExists
+func taxJurisdictionError(err error) error {
+	if err != nil && strings.Contains(err.Error(), "idx_tax_rates_jurisdiction") {
+		return ErrTaxRateExists
+	}
+	return err
+}
+
+// taxRateForCar returns the tax rate of the car's jurisdiction, or nil if the
+// car has no jurisdiction or the jurisdiction has no rate
+func taxRateForCar(db *gorm.DB, car *models.Car) (*models.TaxRate, error) {
+	if car.TaxJurisdiction == "" {
+		return nil, nil
+	}
+
+	var rates []models.TaxRate
+	if err := db.Where("jurisdiction = ?", car.TaxJurisdiction).Limit(1).Find(&rates).Error; err != nil {
+		return nil, fmt.Errorf("failed to load tax rate: %w", err)
+	}
+	if len(rates) == 0 {
+		return nil, nil
+	}
+	return &rates[0], nil
+}
+
+// applyCarTax charges the tax rate of the car's jurisdiction on a quote
+func applyCarTax(db *gorm.DB, car *models.Car, quote *pricing.Quote) (*models.TaxRate, error) {
+	rate, err := taxRateForCar(db, car)
+	if err != nil {
+		return nil, err
+	}
+	if err := quote.ApplyTax(rate); err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+// bookingTax copies the treatment of a tax rate, and the tax charged on the
+// quote, onto a booking
+func bookingTax(rate *models.TaxRate, quote *pricing.Quote) models.BookingTax {
+	if rate == nil || rate.Rate <= 0 {
+		return models.BookingTax{}
+	}
+	return models.BookingTax{
+		Jurisdiction: rate.Jurisdiction,
+		Name:         rate.Name,
+		Rate:         rate.Rate,
+		Inclusive:    rate.Inclusive,
+		OnFees:       rate.TaxFees,
+		OnDeposits:   rate.TaxDeposits,
+		Amount:       quote.Taxes,
+	}
+}
+
+// taxAmount charges the booking's tax on amount, returning the tax and how much
+// the booking's total grows by; exclusive tax is added on top of amount
+func taxAmount(booking *models.Booking, amount money.Money) (tax, total money.Money, err error) {
+	tax = pricing.Tax(amount, booking.Tax.Rate, booking.Tax.Inclusive)
+	if booking.Tax.Amount, err = booking.Tax.Amount.Add(tax); err != nil {
+		return money.Money{}, money.Money{}, err
+	}
+	if booking.Tax.Exclusive() {
+		total, err = amount.Add(tax)
+		return tax, total, err
+	}
+	return tax, amount, nil
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"car-rental-backend/database"
+	"car-rental-backend/models"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrInvalidRefreshToken is returned when a refresh token is unknown, expired or revoked
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused is returned when an already used refresh token is presented again
+	ErrRefreshTokenReused = errors.New("refresh token has already been used")
+	// ErrChallengeUsed is returned when a two-factor login challenge has already been used
+	ErrChallengeUsed = errors.New("two-factor challenge has already been used")
+)
+
+// accessTokenLifetime is how long access tokens are valid for
+var accessTokenLifetime = 15 * time.Minute
+
+// refreshTokenLifetime is how long each refresh token in a family is valid for
+var refreshTokenLifetime = 30 * 24 * time.Hour
+
+// SetTokenLifetimes configures how long access and refresh tokens are valid for
+func SetTokenLifetimes(access, refresh time.Duration) {
+	if access > 0 {
+		accessTokenLifetime = access
+	}
+	if refresh > 0 {
+		refreshTokenLifetime = refresh
+	}
+}
+
+// TokenService handles refresh token rotation and token revocation
+type TokenService struct {
+	db *gorm.DB
+}
+
+// NewTokenService creates a new token service
+func NewTokenService() *TokenService {
+	return &TokenService{
+		db: database.GetDB(),
+	}
+}
+
+// IssueRefreshToken starts a new token family for the user and returns its
+// first refresh token. twoFactor records whether the login passed two-factor
+// authentication, which every token of the family inherits.
+func (s *TokenService) IssueRefreshToken(userID uuid.UUID, twoFactor bool) (string, *models.RefreshToken, error) {
+	return issueRefreshToken(s.db, userID, uuid.New(), twoFactor)
+}
+
+// RotateRefreshToken exchanges a refresh token for the next one in its family.
+// Presenting a token that was already exchanged means it has leaked, so the
+// whole family is revoked and ErrRefreshTokenReused is returned.
+func (s *TokenService) RotateRefreshToken(token string) (string, *models.RefreshToken, *models.User, error) {
+	var next string
+	var nextToken *models.RefreshToken
+	var user models.User
+	reused := false
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var current models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&current, "token_hash = ?", hashToken(token)).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+
+		if current.RevokedAt != nil {
+			return ErrInvalidRefreshToken
+		}
+		if current.UsedAt != nil {
+			reused = true
+			return revokeFamily(tx, current.FamilyID, models.RevocationReasonReuse)
+		}
+		if time.Now().After(current.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		if err := tx.First(&user, "id = ?", current.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&current).Update("used_at", now).Error; err != nil {
+			return fmt.Errorf("failed to mark refresh token used: %w", err)
+		}
+
+		var err error
+		next, nextToken, err = issueRefreshToken(tx, current.UserID, current.FamilyID, current.TwoFactor)
+		return err
+	})
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if reused {
+		return "", nil, nil, ErrRefreshTokenReused
+	}
+
+	return next, nextToken, &user, nil
+}
+
+// Logout revokes the access token and every token in its family
+func (s *TokenService) Logout(tokenID, familyID uuid.UUID, expiresAt time.Time) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := revokeToken(tx, tokenID, expiresAt, models.RevocationReasonLogout); err != nil {
+			return err
+		}
+		return revokeFamily(tx, familyID, models.RevocationReasonLogout)
+	})
+}
+
+// UseChallenge claims a two-factor login challenge by putting it on the
+// revocation list. The insert is the check, so of several concurrent requests
+// with the same challenge only one gets through; the others get ErrChallengeUsed.
+func (s *TokenService) UseChallenge(challengeID uuid.UUID, expiresAt time.Time) error {
+	entry := &models.RevokedToken{
+		TokenID:   challengeID,
+		ExpiresAt: expiresAt,
+		Reason:    models.RevocationReasonChallengeUsed,
+	}
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(entry)
+	if result.Error != nil {
+		return fmt.Errorf("failed to use challenge: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrChallengeUsed
+	}
+	return nil
+}
+
+// IsRevoked reports whether any of the token IDs is on the revocation list
+func (s *TokenService) IsRevoked(tokenIDs ...uuid.UUID) (bool, error) {
+	var count int64
+	if err := s.db.Model(&models.RevokedToken{}).Where("token_id IN ?", tokenIDs).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// issueRefreshToken creates a refresh token in the family and returns it with its record
+func issueRefreshToken(db *gorm.DB, userID, familyID uuid.UUID, twoFactor bool) (string, *models.RefreshToken, error) {
+	token, err := newOpaqueToken()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	record := &models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(refreshTokenLifetime),
+		TwoFactor: twoFactor,
+	}
+	if err := db.Create(record).Error; err != nil {
+		return "", nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return token, record, nil
+}
+
+// revokeFamily revokes every refresh token in the family and puts the family
+// on the revocation list, which rejects the access tokens issued to it
+func revokeFamily(tx *gorm.DB, familyID uuid.UUID, reason string) error {
+	if err := tx.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	// No more access tokens are issued to the family, so the entry only has to
+	// outlive the ones already issued
+	return revokeToken(tx, familyID, time.Now().Add(accessTokenLifetime), reason)
+}
+
+// revokeUserSessions revokes every active token family of the user except
+// keepFamilyID, logging them out everywhere else. Pass uuid.Nil to keep none.
+func revokeUserSessions(tx *gorm.DB, userID uuid.UUID, reason string, keepFamilyID uuid.UUID) error {
+	var familyIDs []uuid.UUID
+	if err := tx.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL AND expires_at > ?", userID, keepFamilyID, time.Now()).
+		Distinct().Pluck("family_id", &familyIDs).Error; err != nil {
+		return fmt.Errorf("failed to load sessions: %w", err)
+	}
+
+	for _, familyID := range familyIDs {
+		if err := revokeFamily(tx, familyID, reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revokeToken adds a token ID to the revocation list
+func revokeToken(tx *gorm.DB, tokenID uuid.UUID, expiresAt time.Time, reason string) error {
+	entry := &models.RevokedToken{
+		TokenID:   tokenID,
+		ExpiresAt: expiresAt,
+		Reason:    reason,
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+// newOpaqueToken returns a random 256-bit token, base64url encoded
+func newOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken returns the hex SHA-256 hash a refresh or account token is stored under
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
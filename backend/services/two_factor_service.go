@@ -0,0 +1,421 @@
+package services
+
+import (
+	"car-rental-backend/database"
+	"car-rental-backend/models"
+	"car-rental-backend/totp"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrTwoFactorNotEnabled is returned when a user without confirmed two-factor authentication is asked for a code
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+	// ErrTwoFactorAlreadyEnabled is returned when enrolling a user who already has two-factor authentication
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrTwoFactorNotEnrolled is returned when confirming without having started enrollment
+	ErrTwoFactorNotEnrolled = errors.New("two-factor enrollment has not been started")
+	// ErrInvalidTwoFactorCode is returned for wrong, reused or expired TOTP codes and unknown recovery codes
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+)
+
+const (
+	// recoveryCodeCount is how many recovery codes a user gets at a time
+	recoveryCodeCount = 10
+	// recoveryCodeAlphabet leaves out characters that are easily confused
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	// totpSkew is how many time steps of clock drift are allowed either way
+	totpSkew = 1
+)
+
+var (
+	twoFactorIssuer        = "Car Rental"
+	twoFactorSecretKey     string
+	twoFactorRequiredRoles = map[models.UserRole]bool{}
+	twoFactorMu            sync.RWMutex
+)
+
+// SetTwoFactorOptions configures the issuer name shown in authenticator apps
+// and the key TOTP secrets are encrypted with at rest
+func SetTwoFactorOptions(issuer, secretKey string) {
+	twoFactorMu.Lock()
+	defer twoFactorMu.Unlock()
+	if issuer != "" {
+		twoFactorIssuer = issuer
+	}
+	twoFactorSecretKey = secretKey
+}
+
+// SetTwoFactorRequiredRoles configures the roles whose permissions only apply
+// in sessions that passed two-factor authentication
+func SetTwoFactorRequiredRoles(roles ...models.UserRole) {
+	required := make(map[models.UserRole]bool, len(roles))
+	for _, role := range roles {
+		required[role] = true
+	}
+
+	twoFactorMu.Lock()
+	defer twoFactorMu.Unlock()
+	twoFactorRequiredRoles = required
+}
+
+// IsTwoFactorRequired reports whether the role must use two-factor authentication
+func IsTwoFactorRequired(role models.UserRole) bool {
+	twoFactorMu.RLock()
+	defer twoFactorMu.RUnlock()
+	return twoFactorRequiredRoles[role]
+}
+
+// TwoFactorService handles TOTP enrollment, verification and recovery codes
+type TwoFactorService struct {
+	db *gorm.DB
+}
+
+// NewTwoFactorService creates a new two-factor service
+func NewTwoFactorService() *TwoFactorService {
+	return &TwoFactorService{
+		db: database.GetDB(),
+	}
+}
+
+// Status describes the user's two-factor setup
+func (s *TwoFactorService) Status(user *models.User) (*models.TwoFactorStatus, error) {
+	status := &models.TwoFactorStatus{Required: IsTwoFactorRequired(user.Role)}
+
+	credential, err := s.confirmedCredential(s.db, user.ID)
+	if err != nil {
+		if errors.Is(err, ErrTwoFactorNotEnabled) {
+			return status, nil
+		}
+		return nil, err
+	}
+	status.Enabled = true
+	status.EnabledAt = credential.ConfirmedAt
+
+	var remaining int64
+	if err := s.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", user.ID).
+		Count(&remaining).Error; err != nil {
+		return nil, err
+	}
+	status.RecoveryCodesRemaining = int(remaining)
+
+	return status, nil
+}
+
+// IsEnabled reports whether the user has confirmed two-factor authentication
+func (s *TwoFactorService) IsEnabled(userID uuid.UUID) (bool, error) {
+	var count int64
+	if err := s.db.Model(&models.TwoFactorCredential{}).
+		Where("user_id = ? AND confirmed_at IS NOT NULL", userID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Enroll starts enrollment with a new secret, replacing any enrollment that
+// was not confirmed. Two-factor authentication is not enabled until Confirm.
+func (s *TwoFactorService) Enroll(user *models.User) (*models.TwoFactorEnrollment, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+	sealed, err := sealSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.TwoFactorCredential
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&existing, "user_id = ?", user.ID).Error
+		switch {
+		case err == nil && existing.IsConfirmed():
+			return ErrTwoFactorAlreadyEnabled
+		case err == nil:
+			// The user ID is unique, so the pending enrollment is removed for good
+			if err := tx.Unscoped().Delete(&existing).Error; err != nil {
+				return err
+			}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		return tx.Create(&models.TwoFactorCredential{UserID: user.ID, Secret: sealed}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	twoFactorMu.RLock()
+	issuer := twoFactorIssuer
+	twoFactorMu.RUnlock()
+
+	return &models.TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(secret, issuer, user.Email),
+	}, nil
+}
+
+// Confirm completes enrollment with a code from the authenticator app and
+// returns the user's recovery codes, which are only shown this once
+func (s *TwoFactorService) Confirm(userID uuid.UUID, code string) ([]string, error) {
+	var codes []string
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var credential models.TwoFactorCredential
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&credential, "user_id = ?", userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTwoFactorNotEnrolled
+			}
+			return err
+		}
+		if credential.IsConfirmed() {
+			return ErrTwoFactorAlreadyEnabled
+		}
+
+		step, err := checkCode(&credential, code)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&credential).Updates(map[string]interface{}{
+			"confirmed_at":   now,
+			"last_used_step": step,
+		}).Error; err != nil {
+			return err
+		}
+
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Verify checks a TOTP code, or a recovery code if code is empty. Each TOTP
+// code and recovery code is accepted once.
+func (s *TwoFactorService) Verify(userID uuid.UUID, code, recoveryCode string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return verifyTwoFactor(tx, userID, code, recoveryCode)
+	})
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking a
+// TOTP code or recovery code
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uuid.UUID, code, recoveryCode string) ([]string, error) {
+	var codes []string
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := verifyTwoFactor(tx, userID, code, recoveryCode); err != nil {
+			return err
+		}
+
+		var err error
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Disable turns two-factor authentication off after checking a TOTP code or
+// recovery code, removing the secret and the recovery codes. Every session of
+// the user is logged out, since they were marked as having passed two-factor
+// authentication.
+func (s *TwoFactorService) Disable(userID uuid.UUID, code, recoveryCode string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := verifyTwoFactor(tx, userID, code, recoveryCode); err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.TwoFactorCredential{}).Error; err != nil {
+			return err
+		}
+
+		return revokeUserSessions(tx, userID, models.RevocationReasonTwoFactorOff, uuid.Nil)
+	})
+}
+
+// confirmedCredential loads the user's confirmed credential
+func (s *TwoFactorService) confirmedCredential(db *gorm.DB, userID uuid.UUID) (*models.TwoFactorCredential, error) {
+	var credential models.TwoFactorCredential
+	if err := db.First(&credential, "user_id = ? AND confirmed_at IS NOT NULL", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTwoFactorNotEnabled
+		}
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// verifyTwoFactor checks a TOTP code or a recovery code within a transaction,
+// using it up
+func verifyTwoFactor(tx *gorm.DB, userID uuid.UUID, code, recoveryCode string) error {
+	var credential models.TwoFactorCredential
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&credential, "user_id = ? AND confirmed_at IS NOT NULL", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTwoFactorNotEnabled
+		}
+		return err
+	}
+
+	if code != "" {
+		step, err := checkCode(&credential, code)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&credential).Update("last_used_step", step).Error
+	}
+
+	if recoveryCode == "" {
+		return ErrInvalidTwoFactorCode
+	}
+	result := tx.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashToken(normalizeRecoveryCode(recoveryCode))).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// checkCode validates a TOTP code against the credential, rejecting codes from
+// a time step that was already used, and returns the code's time step
+func checkCode(credential *models.TwoFactorCredential, code string) (int64, error) {
+	secret, err := openSecret(credential.Secret)
+	if err != nil {
+		return 0, err
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now(), totpSkew)
+	if !ok || step <= credential.LastUsedStep {
+		return 0, ErrInvalidTwoFactorCode
+	}
+	return step, nil
+}
+
+// replaceRecoveryCodes deletes the user's recovery codes and returns new ones
+func replaceRecoveryCodes(tx *gorm.DB, userID uuid.UUID) ([]string, error) {
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	records := make([]models.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		codes[i] = code
+		records[i] = models.RecoveryCode{UserID: userID, CodeHash: hashToken(normalizeRecoveryCode(code))}
+	}
+
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to store recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+// newRecoveryCode returns a random recovery code like "k7m2p-qx9ta"
+func newRecoveryCode() (string, error) {
+	max := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	var b strings.Builder
+	for i := 0; i < 10; i++ {
+		if i == 5 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(recoveryCodeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// normalizeRecoveryCode ignores case, dashes and spaces in a recovery code
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+// sealSecret encrypts a TOTP secret with AES-256-GCM, base64 encoded
+func sealSecret(secret string) (string, error) {
+	aead, err := twoFactorAEAD()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+// openSecret reverses sealSecret
+func openSecret(sealed string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", err
+	}
+	aead, err := twoFactorAEAD()
+	if err != nil {
+		return "", err
+	}
+	if len(data) < aead.NonceSize() {
+		return "", errors.New("encrypted TOTP secret is too short")
+	}
+	secret, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt TOTP secret, check TWO_FACTOR_SECRET_KEY: %w", err)
+	}
+	return string(secret), nil
+}
+
+// twoFactorAEAD derives the cipher TOTP secrets are encrypted with
+func twoFactorAEAD() (cipher.AEAD, error) {
+	twoFactorMu.RLock()
+	key := twoFactorSecretKey
+	twoFactorMu.RUnlock()
+
+	if key == "" {
+		return nil, errors.New("TWO_FACTOR_SECRET_KEY is not set")
+	}
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,334 @@
+package tokens
+
+import (
+	"car-rental-backend/models"
+	"crypto"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUnsupportedAlgorithm is returned for signing algorithms other than RS256 and EdDSA
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	// ErrUnknownKey is returned when a token's kid does not match any verification key
+	ErrUnknownKey = errors.New("unknown signing key")
+	// ErrNoSigningKey is returned when there is no key to sign with
+	ErrNoSigningKey = errors.New("no signing key")
+	// ErrKeyManagerNotConfigured is returned when no key manager has been initialised
+	ErrKeyManagerNotConfigured = errors.New("token key manager is not configured")
+)
+
+// reloadInterval limits how often an unknown kid triggers a reload of the keys,
+// which picks up keys rotated by other instances
+const reloadInterval = 10 * time.Second
+
+// Options configures a KeyManager
+type Options struct {
+	Algorithm      string        // RS256 or EdDSA, used for new keys
+	RotationPeriod time.Duration // how long a key signs before it is replaced
+	GracePeriod    time.Duration // how long a replaced key still verifies tokens
+	Secret         string        // encrypts private keys at rest
+}
+
+// key is a loaded signing key
+type key struct {
+	kid         string
+	algorithm   string
+	public      crypto.PublicKey
+	private     crypto.Signer
+	activatedAt time.Time
+	retiredAt   *time.Time
+}
+
+// KeyManager signs access tokens with the current key and verifies them with
+// any key that is current or still in its grace period. Keys are stored in the
+// database so every instance signs and verifies with the same set.
+type KeyManager struct {
+	db   *gorm.DB
+	opts Options
+
+	mu         sync.RWMutex
+	keys       []*key // newest first
+	lastReload time.Time
+}
+
+// NewKeyManager loads the stored keys, rotating if there is no current key
+func NewKeyManager(db *gorm.DB, opts Options) (*KeyManager, error) {
+	switch opts.Algorithm {
+	case AlgorithmRS256, AlgorithmEdDSA:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, opts.Algorithm)
+	}
+	if opts.RotationPeriod <= 0 {
+		return nil, errors.New("key rotation period must be positive")
+	}
+
+	m := &KeyManager{db: db, opts: opts}
+	if err := m.EnsureCurrent(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// EnsureCurrent reloads the keys and rotates when the current key has been
+// signing for the rotation period, or when there is none. Keys past their
+// grace period are deleted.
+func (m *KeyManager) EnsureCurrent() error {
+	if err := m.reload(); err != nil {
+		return err
+	}
+
+	current := m.current()
+	if current == nil || time.Since(current.activatedAt) >= m.opts.RotationPeriod {
+		if err := m.Rotate(); err != nil {
+			return err
+		}
+	}
+
+	cutoff := time.Now().Add(-m.opts.GracePeriod)
+	return m.db.Unscoped().Where("retired_at < ?", cutoff).Delete(&models.SigningKey{}).Error
+}
+
+// Rotate creates a new signing key and retires the current ones. Retired keys
+// keep verifying tokens for the grace period.
+func (m *KeyManager) Rotate() error {
+	private, err := generateKey(m.opts.Algorithm)
+	if err != nil {
+		return fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	public, err := encodePublicKey(private.Public())
+	if err != nil {
+		return fmt.Errorf("failed to encode public key: %w", err)
+	}
+	sealed, err := encryptPrivateKey(private, m.opts.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt private key: %w", err)
+	}
+
+	now := time.Now()
+	record := &models.SigningKey{
+		Kid:         uuid.New().String(),
+		Algorithm:   m.opts.Algorithm,
+		PublicKey:   public,
+		PrivateKey:  sealed,
+		ActivatedAt: now,
+	}
+
+	err = m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.SigningKey{}).Where("retired_at IS NULL").Update("retired_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(record).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store signing key: %w", err)
+	}
+
+	log.Printf("Rotated token signing key, new kid %s", record.Kid)
+	return m.reload()
+}
+
+// Start checks the rotation schedule every interval until stop is closed
+func (m *KeyManager) Start(interval time.Duration, stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := m.EnsureCurrent(); err != nil {
+					log.Printf("Failed to rotate token signing key: %v", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Sign signs the claims with the current key, naming it in the kid header
+func (m *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	current := m.current()
+	if current == nil {
+		return "", ErrNoSigningKey
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(current.algorithm), claims)
+	token.Header["kid"] = current.kid
+	return token.SignedString(current.private)
+}
+
+// Keyfunc returns the verification key named by the token's kid header. It is
+// passed to jwt.Parse.
+func (m *KeyManager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrUnknownKey
+	}
+
+	k := m.find(kid)
+	if k == nil && m.reloadDue() {
+		// The key may have been rotated by another instance
+		if err := m.reload(); err != nil {
+			return nil, err
+		}
+		k = m.find(kid)
+	}
+	if k == nil || !m.verifies(k) {
+		return nil, ErrUnknownKey
+	}
+	if token.Method.Alg() != k.algorithm {
+		return nil, fmt.Errorf("%w: token uses %s, key is %s", ErrUnsupportedAlgorithm, token.Method.Alg(), k.algorithm)
+	}
+
+	return k.public, nil
+}
+
+// ValidMethods lists the algorithms accepted when parsing tokens
+func (m *KeyManager) ValidMethods() []string {
+	return []string{AlgorithmRS256, AlgorithmEdDSA}
+}
+
+// JWKS returns the public keys that currently verify tokens
+func (m *KeyManager) JWKS() JWKSet {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, k := range m.keys {
+		if !m.verifies(k) {
+			continue
+		}
+		if jwk, ok := newJWK(k.kid, k.algorithm, k.public); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
+}
+
+// current returns the key that signs new tokens
+func (m *KeyManager) current() *key {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, k := range m.keys {
+		if k.retiredAt == nil && k.private != nil {
+			return k
+		}
+	}
+	return nil
+}
+
+// find returns the loaded key with the kid
+func (m *KeyManager) find(kid string) *key {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, k := range m.keys {
+		if k.kid == kid {
+			return k
+		}
+	}
+	return nil
+}
+
+// verifies reports whether the key still verifies tokens
+func (m *KeyManager) verifies(k *key) bool {
+	return k.retiredAt == nil || time.Since(*k.retiredAt) < m.opts.GracePeriod
+}
+
+// reloadDue reports whether enough time has passed to reload on an unknown kid
+func (m *KeyManager) reloadDue() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return time.Since(m.lastReload) >= reloadInterval
+}
+
+// reload replaces the loaded keys with the stored ones
+func (m *KeyManager) reload() error {
+	var records []models.SigningKey
+	if err := m.db.Order("activated_at DESC").Find(&records).Error; err != nil {
+		return fmt.Errorf("failed to load signing keys: %w", err)
+	}
+
+	keys := make([]*key, 0, len(records))
+	for _, record := range records {
+		public, err := decodePublicKey(record.PublicKey)
+		if err != nil {
+			return fmt.Errorf("failed to decode public key %s: %w", record.Kid, err)
+		}
+
+		k := &key{
+			kid:         record.Kid,
+			algorithm:   record.Algorithm,
+			public:      public,
+			activatedAt: record.ActivatedAt,
+			retiredAt:   record.RetiredAt,
+		}
+		// Only the current key signs, so retired private keys are not decrypted
+		if record.RetiredAt == nil {
+			if k.private, err = decryptPrivateKey(record.PrivateKey, m.opts.Secret); err != nil {
+				return fmt.Errorf("failed to load private key %s: %w", record.Kid, err)
+			}
+		}
+		keys = append(keys, k)
+	}
+
+	m.mu.Lock()
+	m.keys = keys
+	m.lastReload = time.Now()
+	m.mu.Unlock()
+	return nil
+}
+
+var (
+	manager   *KeyManager
+	managerMu sync.RWMutex
+)
+
+// InitKeyManager configures the key manager used to sign and verify access tokens
+func InitKeyManager(db *gorm.DB, opts Options) (*KeyManager, error) {
+	opts.Algorithm = normalizeAlgorithm(opts.Algorithm)
+
+	m, err := NewKeyManager(db, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	SetKeyManager(m)
+	return m, nil
+}
+
+// SetKeyManager replaces the key manager used by the application
+func SetKeyManager(m *KeyManager) {
+	managerMu.Lock()
+	defer managerMu.Unlock()
+	manager = m
+}
+
+// GetKeyManager returns the configured key manager, or nil if none is configured
+func GetKeyManager() *KeyManager {
+	managerMu.RLock()
+	defer managerMu.RUnlock()
+	return manager
+}
+
+// normalizeAlgorithm accepts algorithm names in any case
+func normalizeAlgorithm(algorithm string) string {
+	switch strings.ToUpper(algorithm) {
+	case "", "RS256":
+		return AlgorithmRS256
+	case "EDDSA", "ED25519":
+		return AlgorithmEdDSA
+	default:
+		return algorithm
+	}
+}
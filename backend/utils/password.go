@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// PasswordPolicy is what new passwords must satisfy
+type PasswordPolicy struct {
+	MinLength      int
+	RequireUpper   bool
+	RequireLower   bool
+	RequireDigit   bool
+	RequireSpecial bool
+}
+
+var (
+	passwordPolicy = PasswordPolicy{
+		MinLength:      8,
+		RequireUpper:   true,
+		RequireLower:   true,
+		RequireDigit:   true,
+		RequireSpecial: true,
+	}
+	breachedPasswords = map[string]struct{}{}
+	passwordMu        sync.RWMutex
+)
+
+// SetPasswordPolicy replaces the policy new passwords are checked against
+func SetPasswordPolicy(policy PasswordPolicy) {
+	passwordMu.Lock()
+	defer passwordMu.Unlock()
+	passwordPolicy = policy
+}
+
+// GetPasswordPolicy returns the policy new passwords are checked against
+func GetPasswordPolicy() PasswordPolicy {
+	passwordMu.RLock()
+	defer passwordMu.RUnlock()
+	return passwordPolicy
+}
+
+// LoadBreachedPasswords reads a list of known breached or common passwords,
+// one per line, which new passwords may not match. Blank lines and lines
+// starting with # are skipped, and matching ignores case.
+func LoadBreachedPasswords(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read breached password list: %v", err)
+	}
+	defer file.Close()
+
+	passwords := map[string]struct{}{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read breached password list: %v", err)
+	}
+
+	passwordMu.Lock()
+	breachedPasswords = passwords
+	passwordMu.Unlock()
+	return nil
+}
+
+// PasswordProblems lists the ways a password falls short of the policy, or
+// nothing if it is acceptable
+func PasswordProblems(password string) []string {
+	passwordMu.RLock()
+	policy := passwordPolicy
+	_, breached := breachedPasswords[strings.ToLower(password)]
+	passwordMu.RUnlock()
+
+	var hasUpper, hasLower, hasDigit, hasSpecial bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		default:
+			hasSpecial = true
+		}
+	}
+
+	var problems []string
+	if utf8.RuneCountInString(password) < policy.MinLength {
+		problems = append(problems, fmt.Sprintf("must be at least %d characters long", policy.MinLength))
+	}
+	if policy.RequireUpper && !hasUpper {
+		problems = append(problems, "must contain an upper case letter")
+	}
+	if policy.RequireLower && !hasLower {
+		problems = append(problems, "must contain a lower case letter")
+	}
+	if policy.RequireDigit && !hasDigit {
+		problems = append(problems, "must contain a digit")
+	}
+	if policy.RequireSpecial && !hasSpecial {
+		problems = append(problems, "must contain a special character")
+	}
+	if breached {
+		problems = append(problems, "is too common and has appeared in data breaches")
+	}
+	return problems
+}
+
+// ValidStrongPassword validates that a password satisfies the password policy
+func ValidStrongPassword(fl validator.FieldLevel) bool {
+	return IsStrongPassword(fl.Field().String())
+}
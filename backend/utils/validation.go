@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"car-rental-backend/money"
 	"fmt"
 	"net/url"
 	"reflect"
@@ -22,6 +23,9 @@ func init() {
 	validate.RegisterValidation("validUUID", ValidUUID)
 	validate.RegisterValidation("validDate", ValidDate)
 	validate.RegisterValidation("futureDate", ValidFutureDate)
+	validate.RegisterValidation("positiveMoney", ValidPositiveMoney)
+	validate.RegisterValidation("nonNegativeMoney", ValidNonNegativeMoney)
+	validate.RegisterValidation("strongPassword", ValidStrongPassword)
 }
 
 // ValidateStruct validates a struct using the validator package and returns a list of validation errors
@@ -31,6 +35,13 @@ func ValidateStruct(s interface{}) []string {
 	if err != nil {
 		for _, err := range err.(validator.ValidationErrors) {
 			field := toSnakeCase(err.Field())
+			if err.Tag() == "strongPassword" {
+				// Spell out which parts of the password policy were missed
+				for _, problem := range PasswordProblems(err.Value().(string)) {
+					errors = append(errors, FieldValidationError(field, err.Tag(), "password "+problem))
+				}
+				continue
+			}
 			errors = append(errors, fmt.Sprintf(
 				"Field validation for '%s' failed on the '%s' tag",
 				field,
@@ -41,6 +52,12 @@ func ValidateStruct(s interface{}) []string {
 	return errors
 }
 
+// FieldValidationError formats a field error found outside the validator, such
+// as a reused password, the same way as the errors from ValidateStruct
+func FieldValidationError(field, tag, message string) string {
+	return fmt.Sprintf("Field validation for '%s' failed on the '%s' tag: %s", field, tag, message)
+}
+
 // ValidVehicleNumber validates a vehicle number format (e.g., "KA01AB1234")
 func ValidVehicleNumber(fl validator.FieldLevel) bool {
 	// Check format like "KA01AB1234" or "MH02CD5678"
@@ -69,6 +86,18 @@ func ValidFutureDate(fl validator.FieldLevel) bool {
 	return date.After(time.Now())
 }
 
+// ValidPositiveMoney validates that an amount is above zero and in the currency amounts are stored in
+func ValidPositiveMoney(fl validator.FieldLevel) bool {
+	m, ok := fl.Field().Interface().(money.Money)
+	return ok && m.SameCurrency(money.Zero()) && m.IsPositive()
+}
+
+// ValidNonNegativeMoney validates that an amount is not below zero and in the currency amounts are stored in
+func ValidNonNegativeMoney(fl validator.FieldLevel) bool {
+	m, ok := fl.Field().Interface().(money.Money)
+	return ok && m.SameCurrency(money.Zero()) && !m.IsNegative()
+}
+
 // IsValidEmail checks if a string is a valid email address
 func IsValidEmail(email string) bool {
 	emailPattern := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
@@ -88,19 +117,9 @@ func IsValidPhoneNumber(phone string) bool {
 	return phonePattern.MatchString(phone)
 }
 
-// IsStrongPassword checks if a password meets strength requirements
+// IsStrongPassword checks if a password meets the password policy
 func IsStrongPassword(password string) bool {
-	// At least 8 characters with a mix of upper, lower, number, and special
-	if len(password) < 8 {
-		return false
-	}
-
-	hasUpper := regexp.MustCompile(`[A-Z]`).MatchString(password)
-	hasLower := regexp.MustCompile(`[a-z]`).MatchString(password)
-	hasDigit := regexp.MustCompile(`[0-9]`).MatchString(password)
-	hasSpecial := regexp.MustCompile(`[^a-zA-Z0-9]`).MatchString(password)
-
-	return hasUpper && hasLower && hasDigit && hasSpecial
+	return len(PasswordProblems(password)) == 0
 }
 
 // toSnakeCase converts a camelCase string to snake_case